@@ -0,0 +1,163 @@
+package bind
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// MaxMemory is the maximum number of bytes of a multipart form held in memory,
+// see http.Request.ParseMultipartForm.
+var MaxMemory int64 = 32 << 20
+
+// Request binds r to dst, which must be a pointer to a struct, and validates the
+// result with the Validator set by SetValidator and the SelfValidator interface.
+//
+// The body is decoded as JSON when the Content-Type is application/json or ends
+// in +json and as a form when it is application/x-www-form-urlencoded or
+// multipart/form-data. Query, path and header values are bound afterwards and
+// override values of the body. Embedded structs are bound as if their fields
+// were declared on dst.
+//
+// Values that cannot be bound are returned as an *Error with status
+// http.StatusBadRequest, all fields that fail are reported at once.
+func Request(r *http.Request, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: destination must be a non-nil pointer to a struct, got %T", dst)
+	}
+
+	var fields []FieldError
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if err := decodeJSON(r.Body, dst); err != nil {
+			return err
+		}
+	case mediaType == "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return &Error{Status: http.StatusBadRequest, Fields: []FieldError{{Source: "form", Message: err.Error()}}}
+		}
+		fields = bindValues(rv.Elem(), "form", urlValues(r.PostForm), fields)
+	case mediaType == "multipart/form-data":
+		if err := r.ParseMultipartForm(MaxMemory); err != nil {
+			return &Error{Status: http.StatusBadRequest, Fields: []FieldError{{Source: "form", Message: err.Error()}}}
+		}
+		fields = bindValues(rv.Elem(), "form", urlValues(r.MultipartForm.Value), fields)
+	}
+
+	fields = bindValues(rv.Elem(), "query", urlValues(r.URL.Query()), fields)
+	fields = bindValues(rv.Elem(), "path", pathValues{r}, fields)
+	fields = bindValues(rv.Elem(), "header", headerValues(r.Header), fields)
+
+	if len(fields) > 0 {
+		return &Error{Status: http.StatusBadRequest, Fields: fields}
+	}
+
+	return validate(dst)
+}
+
+func decodeJSON(body io.Reader, dst any) error {
+	if body == nil {
+		return nil
+	}
+
+	err := json.NewDecoder(body).Decode(dst)
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	field := FieldError{Source: "json", Message: "malformed JSON body"}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		field.Field = typeErr.Field
+		field.Message = "must be of type " + typeErr.Type.String()
+	}
+
+	return &Error{Status: http.StatusBadRequest, Fields: []FieldError{field}}
+}
+
+// values is the common interface of the sources fields are bound from.
+type values interface {
+	values(key string) []string
+}
+
+type urlValues map[string][]string
+
+type pathValues struct{ r *http.Request }
+
+func (p pathValues) values(key string) []string {
+	if v := p.r.PathValue(key); v != "" {
+		return []string{v}
+	}
+	return nil
+}
+
+type headerValues http.Header
+
+func (h headerValues) values(key string) []string {
+	return http.Header(h).Values(key)
+}
+
+func (u urlValues) values(key string) []string {
+	return u[key]
+}
+
+func bindValues(v reflect.Value, tag string, vals values, fields []FieldError) []FieldError {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		fv := v.Field(i)
+
+		if sf.Anonymous && sf.Type.Kind() == reflect.Struct {
+			fields = bindValues(fv, tag, vals, fields)
+			continue
+		}
+
+		name, ok := sf.Tag.Lookup(tag)
+		if !ok || !sf.IsExported() {
+			continue
+		}
+
+		name, _, _ = strings.Cut(name, ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		in := vals.values(name)
+		if len(in) == 0 {
+			continue
+		}
+
+		if err := setField(fv, in); err != nil {
+			fields = append(fields, FieldError{Field: name, Source: tag, Message: err.Error()})
+		}
+	}
+
+	return fields
+}
+
+// sources lists the struct tags read by Request in order of precedence.
+var sources = []string{"json", "form", "query", "path", "header"}
+
+// FieldName returns the name and source of a struct field as seen by Request,
+// for example "page" and "query" for a field tagged `query:"page"`. ok is false
+// if the field carries none of the tags read by Request. It is intended for
+// Validator implementations that report field errors.
+func FieldName(sf reflect.StructField) (name, source string, ok bool) {
+	for _, tag := range sources {
+		name, _, _ := strings.Cut(sf.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name, tag, true
+		}
+	}
+
+	return "", "", false
+}
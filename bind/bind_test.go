@@ -0,0 +1,183 @@
+package bind
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type embedded struct {
+	Trace string `header:"X-Trace"`
+}
+
+type createUser struct {
+	embedded
+	OrgID   int           `path:"org"`
+	DryRun  bool          `query:"dry_run"`
+	Tags    []string      `query:"tag"`
+	Limit   *int          `query:"limit"`
+	Timeout time.Duration `query:"timeout"`
+	Name    string        `json:"name"`
+	Email   string        `json:"email" form:"email"`
+}
+
+func Test_Request(t *testing.T) {
+	var got createUser
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orgs/{org}/users", func(w http.ResponseWriter, r *http.Request) {
+		if err := Request(r, &got); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/orgs/42/users?dry_run=true&tag=a&tag=b&limit=10&timeout=5s", strings.NewReader(`{"name":"gopher","email":"gopher@example.com"}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-Trace", "abc")
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	limit := 10
+	want := createUser{
+		embedded: embedded{Trace: "abc"},
+		OrgID:    42,
+		DryRun:   true,
+		Tags:     []string{"a", "b"},
+		Limit:    &limit,
+		Timeout:  5 * time.Second,
+		Name:     "gopher",
+		Email:    "gopher@example.com",
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
+
+func Test_Request_Form(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("email=gopher%40example.com"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var got createUser
+	if err := Request(req, &got); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got.Email != "gopher@example.com" {
+		t.Errorf("expected email to be bound from the form, got %q", got.Email)
+	}
+}
+
+func Test_Request_BindErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		req  func() *http.Request
+		want []FieldError
+	}{
+		{
+			name: "query",
+			req: func() *http.Request {
+				return httptest.NewRequest(http.MethodGet, "/?dry_run=maybe&limit=ten", nil)
+			},
+			want: []FieldError{
+				{Field: "dry_run", Source: "query", Message: "must be a boolean"},
+				{Field: "limit", Source: "query", Message: "must be an integer"},
+			},
+		},
+		{
+			name: "json type",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":1}`))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			want: []FieldError{
+				{Field: "name", Source: "json", Message: "must be of type string"},
+			},
+		},
+		{
+			name: "malformed json",
+			req: func() *http.Request {
+				req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":`))
+				req.Header.Set("Content-Type", "application/json")
+				return req
+			},
+			want: []FieldError{
+				{Source: "json", Message: "malformed JSON body"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dst createUser
+			err := Request(tt.req(), &dst)
+
+			var bindErr *Error
+			if !errors.As(err, &bindErr) {
+				t.Fatalf("expected *Error, got %v", err)
+			}
+
+			if bindErr.HTTPStatus() != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", bindErr.HTTPStatus())
+			}
+
+			if !reflect.DeepEqual(bindErr.Fields, tt.want) {
+				t.Errorf("expected fields %+v, got %+v", tt.want, bindErr.Fields)
+			}
+		})
+	}
+}
+
+func Test_Request_InvalidDestination(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	var dst createUser
+	if err := Request(req, dst); err == nil {
+		t.Error("expected error for non-pointer destination")
+	}
+}
+
+type selfValidated struct {
+	Name string `query:"name"`
+}
+
+func (s *selfValidated) Validate() error {
+	if s.Name == "" {
+		return NewValidationError(FieldError{Field: "name", Source: "query", Message: "is required"})
+	}
+	return nil
+}
+
+func Test_Request_Validate(t *testing.T) {
+	defer SetValidator(nil)
+
+	calls := 0
+	SetValidator(ValidatorFunc(func(v any) error {
+		calls++
+		return nil
+	}))
+
+	var dst selfValidated
+	err := Request(httptest.NewRequest(http.MethodGet, "/", nil), &dst)
+
+	var bindErr *Error
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("expected *Error, got %v", err)
+	}
+
+	if bindErr.HTTPStatus() != http.StatusUnprocessableEntity || bindErr.ErrorCode() != "validation_failed" {
+		t.Errorf("unexpected status %d and code %s", bindErr.HTTPStatus(), bindErr.ErrorCode())
+	}
+
+	if bindErr.Error() != "validation failed: name is required" {
+		t.Errorf("unexpected message %q", bindErr.Error())
+	}
+
+	if calls != 1 {
+		t.Errorf("expected validator to be called once, got %d", calls)
+	}
+}
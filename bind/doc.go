@@ -0,0 +1,32 @@
+// Package bind binds HTTP requests to structs. A single call to Request fills a
+// struct from the JSON or form body, the query string, the path values and the
+// headers of a request, driven by struct tags, and validates the result.
+//
+// Fields are bound from the source named by their tag:
+//
+//	json:"name"   the JSON body, decoded with encoding/json
+//	form:"name"   the urlencoded or multipart form body
+//	query:"name"  the URL query string
+//	path:"name"   the path values of the request, see http.Request.PathValue
+//	header:"Name" the request headers
+//
+// Binding errors and validation failures are returned as *Error, which carries
+// the failing fields and is rendered by server.ErrorBuilder with the matching
+// status code, error code and the field errors as data.
+//
+// Example:
+//
+//	type CreateUser struct {
+//	  OrgID  int    `path:"org"`
+//	  DryRun bool   `query:"dry_run"`
+//	  Name   string `json:"name" validate:"required"`
+//	}
+//
+//	func (h *Handler) Create(w http.ResponseWriter, r *http.Request) error {
+//	  var req CreateUser
+//	  if err := bind.Request(r, &req); err != nil {
+//	    return server.Err(err).Write(r.Context(), w)
+//	  }
+//	  ...
+//	}
+package bind
@@ -0,0 +1,69 @@
+package bind
+
+import (
+	"net/http"
+	"strings"
+)
+
+// FieldError describes a single field that failed to bind or validate.
+type FieldError struct {
+	// Field is the name of the field as it appears in the request, for example
+	// the query parameter or JSON key.
+	Field string `json:"field"`
+	// Source is the part of the request the field is read from, one of "json",
+	// "form", "query", "path" or "header". It is empty for fields that are only
+	// known to the validator.
+	Source string `json:"source,omitempty"`
+	// Message describes why the field is invalid.
+	Message string `json:"message"`
+}
+
+// Error is returned by Request when the request cannot be bound or fails
+// validation. It implements the server.StatusCoder, server.ErrorCoder and
+// server.ErrorDataer interfaces, so server.Err(err) renders the field errors.
+type Error struct {
+	// Status is the HTTP status of the error, http.StatusBadRequest for values
+	// that cannot be bound and http.StatusUnprocessableEntity for values that
+	// fail validation.
+	Status int
+	Fields []FieldError
+}
+
+// NewValidationError returns an *Error for fields that failed validation. It is
+// intended for Validator implementations.
+func NewValidationError(fields ...FieldError) *Error {
+	return &Error{Status: http.StatusUnprocessableEntity, Fields: fields}
+}
+
+func (e *Error) Error() string {
+	var sb strings.Builder
+	if e.Status == http.StatusUnprocessableEntity {
+		sb.WriteString("validation failed")
+	} else {
+		sb.WriteString("invalid request")
+	}
+
+	for i, f := range e.Fields {
+		if i == 0 {
+			sb.WriteString(": ")
+		} else {
+			sb.WriteString("; ")
+		}
+		sb.WriteString(f.Field)
+		sb.WriteString(" ")
+		sb.WriteString(f.Message)
+	}
+
+	return sb.String()
+}
+
+func (e *Error) HTTPStatus() int { return e.Status }
+
+func (e *Error) ErrorCode() string {
+	if e.Status == http.StatusUnprocessableEntity {
+		return "validation_failed"
+	}
+	return "invalid_request"
+}
+
+func (e *Error) ErrorData() any { return e.Fields }
@@ -0,0 +1,165 @@
+// Package playground adapts github.com/go-playground/validator to the
+// bind.Validator interface.
+//
+// Example:
+//
+//	bind.SetValidator(playground.New(nil))
+package playground
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/hay-kot/httpkit/bind"
+)
+
+// Validator validates structs with a *validator.Validate and reports failures
+// as *bind.Error.
+type Validator struct {
+	validate *validator.Validate
+}
+
+// New returns a Validator using v, or a new *validator.Validate if v is nil. The
+// tag name function of v is replaced so that field errors use the names fields
+// are bound from, see bind.FieldName.
+func New(v *validator.Validate) *Validator {
+	if v == nil {
+		v = validator.New(validator.WithRequiredStructEnabled())
+	}
+
+	v.RegisterTagNameFunc(func(sf reflect.StructField) string {
+		if name, _, ok := bind.FieldName(sf); ok {
+			return name
+		}
+		return sf.Name
+	})
+
+	return &Validator{validate: v}
+}
+
+// Validate validates dst and converts validator.ValidationErrors to a
+// *bind.Error. Other errors are returned unchanged.
+func (v *Validator) Validate(dst any) error {
+	err := v.validate.Struct(dst)
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	fields := make([]bind.FieldError, len(verrs))
+	for i, fe := range verrs {
+		fields[i] = bind.FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Source:  fieldSource(reflect.TypeOf(dst), fe.StructNamespace()),
+			Message: message(fe),
+		}
+	}
+
+	return bind.NewValidationError(fields...)
+}
+
+// fieldPath strips the struct name from a namespace such as "User.address.city".
+func fieldPath(ns string) string {
+	_, path, ok := strings.Cut(ns, ".")
+	if !ok {
+		return ns
+	}
+	return path
+}
+
+// fieldSource resolves the source of the field at the struct namespace ns, for
+// example "CreateUser.Items[0].Name", starting at t.
+func fieldSource(t reflect.Type, ns string) string {
+	segments := strings.Split(ns, ".")
+	if len(segments) < 2 {
+		return ""
+	}
+
+	var sf reflect.StructField
+	for _, seg := range segments[1:] {
+		t = elem(t)
+		if t.Kind() != reflect.Struct {
+			return ""
+		}
+
+		name, _, _ := strings.Cut(seg, "[")
+
+		var ok bool
+		sf, ok = t.FieldByName(name)
+		if !ok {
+			return ""
+		}
+		t = sf.Type
+	}
+
+	_, source, _ := bind.FieldName(sf)
+	return source
+}
+
+func elem(t reflect.Type) reflect.Type {
+	for {
+		switch t.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		default:
+			return t
+		}
+	}
+}
+
+func message(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "min", "gte":
+		if unit := lengthUnit(fe); unit != "" {
+			return "must have at least " + fe.Param() + " " + unit
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if unit := lengthUnit(fe); unit != "" {
+			return "must have at most " + fe.Param() + " " + unit
+		}
+		return "must be at most " + fe.Param()
+	case "len":
+		if unit := lengthUnit(fe); unit != "" {
+			return "must have exactly " + fe.Param() + " " + unit
+		}
+		return "must be " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	}
+
+	if fe.Param() != "" {
+		return "failed the " + fe.Tag() + "=" + fe.Param() + " validation"
+	}
+	return "failed the " + fe.Tag() + " validation"
+}
+
+// lengthUnit returns the unit of length based tags for the kind of the field, or
+// an empty string if the tag compares numbers.
+func lengthUnit(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "elements"
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package playground
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/hay-kot/httpkit/bind"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type createUser struct {
+	Page    int       `query:"page" validate:"min=1"`
+	Name    string    `json:"name" validate:"required,max=5"`
+	Role    string    `json:"role" validate:"oneof=admin user"`
+	Address address   `json:"address"`
+	Others  []address `json:"others" validate:"dive"`
+}
+
+func Test_Validator(t *testing.T) {
+	v := New(nil)
+
+	err := v.Validate(&createUser{
+		Page:   0,
+		Name:   "gophers",
+		Role:   "root",
+		Others: []address{{}},
+	})
+
+	var bindErr *bind.Error
+	if !errors.As(err, &bindErr) {
+		t.Fatalf("expected *bind.Error, got %v", err)
+	}
+
+	if bindErr.HTTPStatus() != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", bindErr.HTTPStatus())
+	}
+
+	want := []bind.FieldError{
+		{Field: "page", Source: "query", Message: "must be at least 1"},
+		{Field: "name", Source: "json", Message: "must have at most 5 characters"},
+		{Field: "role", Source: "json", Message: "must be one of admin, user"},
+		{Field: "address.city", Source: "json", Message: "is required"},
+		{Field: "others[0].city", Source: "json", Message: "is required"},
+	}
+
+	if !reflect.DeepEqual(bindErr.Fields, want) {
+		t.Errorf("expected fields %+v, got %+v", want, bindErr.Fields)
+	}
+}
+
+func Test_Validator_Valid(t *testing.T) {
+	err := New(nil).Validate(&createUser{
+		Page:    1,
+		Name:    "gary",
+		Role:    "admin",
+		Address: address{City: "Portland"},
+	})
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package bind
+
+import (
+	"errors"
+)
+
+// Validator validates a bound struct. Implementations should return an *Error,
+// for example created with NewValidationError, so the failing fields are
+// reported to the client.
+type Validator interface {
+	Validate(v any) error
+}
+
+// ValidatorFunc adapts a function to the Validator interface.
+type ValidatorFunc func(v any) error
+
+func (fn ValidatorFunc) Validate(v any) error { return fn(v) }
+
+// SelfValidator is implemented by structs that validate themselves. Request
+// calls Validate after the global validator succeeded.
+type SelfValidator interface {
+	Validate() error
+}
+
+var validator Validator
+
+// SetValidator sets the Validator used by Request after binding. No validation
+// is performed if no validator is set, only SelfValidator is honored.
+//
+// Example:
+//
+//	bind.SetValidator(playground.New(nil))
+func SetValidator(v Validator) {
+	validator = v
+}
+
+func validate(dst any) error {
+	if validator != nil {
+		if err := validator.Validate(dst); err != nil {
+			return err
+		}
+	}
+
+	if sv, ok := dst.(SelfValidator); ok {
+		if err := sv.Validate(); err != nil {
+			var bindErr *Error
+			if errors.As(err, &bindErr) {
+				return err
+			}
+			return NewValidationError(FieldError{Field: "", Message: err.Error()})
+		}
+	}
+
+	return nil
+}
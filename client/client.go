@@ -0,0 +1,183 @@
+// Package client is the client side companion of the server package. It provides
+// a fluent request builder with JSON encoding and decoding, retries with backoff
+// for idempotent requests, per-request timeouts, propagation of the request ID and
+// trace context of the incoming request, and errors that carry the response
+// status.
+//
+// Example:
+//
+//	c := client.New(client.WithBaseURL("https://api.example.com"))
+//
+//	var user User
+//	err := c.Get("/users/%d", id).
+//	  Timeout(2 * time.Second).
+//	  Decode(r.Context(), &user)
+//	if client.IsStatus(err, http.StatusNotFound) {
+//	  ...
+//	}
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hay-kot/httpkit/server"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+type opts struct {
+	baseURL         string
+	httpClient      *http.Client
+	headers         http.Header
+	timeout         time.Duration
+	retries         int
+	backoff         time.Duration
+	propagators     propagation.TextMapPropagator
+	requestIDHeader string
+	requestIDFunc   func(ctx context.Context) string
+}
+
+type OptFunc func(*opts)
+
+// WithBaseURL sets the URL the paths of requests are resolved against. Paths that
+// are absolute URLs are used as is.
+func WithBaseURL(baseURL string) OptFunc {
+	return func(o *opts) {
+		o.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the http.Client used to send requests.
+//
+// Defaults to a client using http.DefaultTransport
+func WithHTTPClient(c *http.Client) OptFunc {
+	return func(o *opts) {
+		o.httpClient = c
+	}
+}
+
+// WithHeader sets a header on every request. Headers set on a request take
+// precedence.
+func WithHeader(key, value string) OptFunc {
+	return func(o *opts) {
+		o.headers.Set(key, value)
+	}
+}
+
+// WithTimeout sets the default timeout of a request, including retries and
+// reading the response body. It can be overridden per request with
+// Request.Timeout.
+//
+// Defaults to no timeout
+func WithTimeout(d time.Duration) OptFunc {
+	return func(o *opts) {
+		o.timeout = d
+	}
+}
+
+// WithRetries retries idempotent requests up to n times when the server cannot
+// be reached or responds with 429, 502, 503 or 504. The delay before each retry
+// starts at backoff and doubles with every attempt, a Retry-After header sent by
+// the server takes precedence. Requests with the methods GET, HEAD, OPTIONS,
+// TRACE, PUT and DELETE and requests with an Idempotency-Key header are
+// considered idempotent.
+//
+// Defaults to no retries
+func WithRetries(n int, backoff time.Duration) OptFunc {
+	return func(o *opts) {
+		o.retries = n
+		o.backoff = backoff
+	}
+}
+
+// WithPropagators sets the propagators used to inject the trace context of the
+// request context into the request headers.
+//
+// Defaults to the global TextMapPropagator
+func WithPropagators(p propagation.TextMapPropagator) OptFunc {
+	return func(o *opts) {
+		o.propagators = p
+	}
+}
+
+// WithRequestID sets the header the request ID of the request context is sent in
+// and the function used to read it. An empty header disables propagation.
+//
+// Defaults to the X-Request-ID header and server.RequestID
+func WithRequestID(header string, fn func(ctx context.Context) string) OptFunc {
+	return func(o *opts) {
+		o.requestIDHeader = header
+		o.requestIDFunc = fn
+	}
+}
+
+// Client sends requests built with its request builder methods. A Client is safe
+// for concurrent use.
+type Client struct {
+	opts *opts
+}
+
+// New returns a new Client configured with the provided options.
+func New(fns ...OptFunc) *Client {
+	o := &opts{
+		httpClient:      &http.Client{},
+		headers:         http.Header{},
+		requestIDHeader: "X-Request-ID",
+		requestIDFunc:   server.RequestID,
+	}
+
+	for _, fn := range fns {
+		fn(o)
+	}
+
+	if o.propagators == nil {
+		o.propagators = otel.GetTextMapPropagator()
+	}
+
+	return &Client{opts: o}
+}
+
+// NewRequest returns a request builder for method and path. The path is formatted
+// with fmt.Sprintf if args are provided.
+func (c *Client) NewRequest(method, path string, args ...any) *Request {
+	if len(args) > 0 {
+		path = fmt.Sprintf(path, args...)
+	}
+
+	return &Request{
+		client:  c,
+		method:  method,
+		path:    path,
+		header:  c.opts.headers.Clone(),
+		timeout: c.opts.timeout,
+		retries: c.opts.retries,
+	}
+}
+
+// Get returns a GET request builder, see NewRequest.
+func (c *Client) Get(path string, args ...any) *Request {
+	return c.NewRequest(http.MethodGet, path, args...)
+}
+
+// Post returns a POST request builder, see NewRequest.
+func (c *Client) Post(path string, args ...any) *Request {
+	return c.NewRequest(http.MethodPost, path, args...)
+}
+
+// Put returns a PUT request builder, see NewRequest.
+func (c *Client) Put(path string, args ...any) *Request {
+	return c.NewRequest(http.MethodPut, path, args...)
+}
+
+// Patch returns a PATCH request builder, see NewRequest.
+func (c *Client) Patch(path string, args ...any) *Request {
+	return c.NewRequest(http.MethodPatch, path, args...)
+}
+
+// Delete returns a DELETE request builder, see NewRequest.
+func (c *Client) Delete(path string, args ...any) *Request {
+	return c.NewRequest(http.MethodDelete, path, args...)
+}
@@ -0,0 +1,191 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func Test_Request_JSON(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/users/1" || r.URL.Query().Get("notify") != "true" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+		}
+
+		if r.Header.Get("Authorization") != "token" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected headers %v", r.Header)
+		}
+
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{"name": body["name"]})
+	}))
+	defer srv.Close()
+
+	c := New(WithBaseURL(srv.URL+"/"), WithHeader("Authorization", "token"))
+
+	var got map[string]string
+	err := c.Post("/users/%d", 1).
+		Query("notify", "true").
+		JSON(map[string]string{"name": "gopher"}).
+		Decode(context.Background(), &got)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got["name"] != "gopher" {
+		t.Errorf("expected name gopher, got %v", got)
+	}
+}
+
+func Test_Request_StatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"message":"user not found"}`))
+	}))
+	defer srv.Close()
+
+	_, err := New(WithBaseURL(srv.URL)).Get("/users/1").Do(context.Background())
+
+	if !IsStatus(err, http.StatusNotFound) {
+		t.Fatalf("expected 404 status error, got %v", err)
+	}
+
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.HTTPStatus() != http.StatusNotFound {
+		t.Fatalf("expected *StatusError, got %v", err)
+	}
+
+	var body struct {
+		Message string `json:"message"`
+	}
+	if err := statusErr.Decode(&body); err != nil || body.Message != "user not found" {
+		t.Errorf("unexpected body %q: %v", statusErr.Body, err)
+	}
+}
+
+func Test_Request_Retries(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       func(c *Client) *Request
+		wantCalls int32
+		wantErr   bool
+	}{
+		{
+			name:      "idempotent",
+			req:       func(c *Client) *Request { return c.Put("/").JSON(map[string]int{"n": 1}) },
+			wantCalls: 3,
+		},
+		{
+			name:      "not idempotent",
+			req:       func(c *Client) *Request { return c.Post("/") },
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "idempotency key",
+			req:       func(c *Client) *Request { return c.Post("/").Header("Idempotency-Key", "abc") },
+			wantCalls: 3,
+		},
+		{
+			name:      "request override",
+			req:       func(c *Client) *Request { return c.Get("/").Retries(1) },
+			wantCalls: 2,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if calls.Add(1) < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer srv.Close()
+
+			c := New(WithBaseURL(srv.URL), WithRetries(3, time.Millisecond))
+
+			err := tt.req(c).Decode(context.Background(), nil)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+
+			if calls.Load() != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls.Load())
+			}
+		})
+	}
+}
+
+func Test_Request_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+
+	_, err := New(WithBaseURL(srv.URL), WithTimeout(time.Second)).
+		Get("/").
+		Timeout(10 * time.Millisecond).
+		Do(context.Background())
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+}
+
+type requestIDKey struct{}
+
+func Test_Request_Propagation(t *testing.T) {
+	var header http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+	}))
+	defer srv.Close()
+
+	c := New(
+		WithBaseURL(srv.URL),
+		WithPropagators(propagation.TraceContext{}),
+		WithRequestID("X-Request-ID", func(ctx context.Context) string {
+			id, _ := ctx.Value(requestIDKey{}).(string)
+			return id
+		}),
+	)
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1},
+		SpanID:     trace.SpanID{2},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	ctx = context.WithValue(ctx, requestIDKey{}, "req-1")
+
+	if err := c.Get("/").Decode(ctx, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := header.Get("X-Request-ID"); got != "req-1" {
+		t.Errorf("expected request ID req-1, got %q", got)
+	}
+
+	if got := header.Get("Traceparent"); got != "00-01000000000000000000000000000000-0200000000000000-01" {
+		t.Errorf("unexpected traceparent %q", got)
+	}
+}
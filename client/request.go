@@ -0,0 +1,253 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Request is a request builder returned by the methods of Client. A Request must
+// not be used concurrently.
+type Request struct {
+	client  *Client
+	method  string
+	path    string
+	header  http.Header
+	query   url.Values
+	body    []byte
+	reader  io.Reader
+	err     error
+	timeout time.Duration
+	retries int
+}
+
+// Header sets a header on the request.
+func (r *Request) Header(key, value string) *Request {
+	r.header.Set(key, value)
+	return r
+}
+
+// Query adds a query parameter to the request.
+func (r *Request) Query(key, value string) *Request {
+	if r.query == nil {
+		r.query = url.Values{}
+	}
+	r.query.Add(key, value)
+	return r
+}
+
+// JSON sets the request body to the JSON encoding of v and sets the Content-Type
+// header.
+func (r *Request) JSON(v any) *Request {
+	r.body, r.err = json.Marshal(v)
+	r.reader = nil
+	r.header.Set("Content-Type", "application/json")
+	return r
+}
+
+// Body sets the request body. Requests with a body set with Body are only
+// retried if the body is a *bytes.Buffer, *bytes.Reader or *strings.Reader,
+// which are read when Body is called.
+func (r *Request) Body(body io.Reader) *Request {
+	switch body.(type) {
+	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
+		r.body, r.err = io.ReadAll(body)
+		r.reader = nil
+	default:
+		r.body, r.reader = nil, body
+	}
+	return r
+}
+
+// Timeout sets the timeout of the request, including retries and reading the
+// response body. A zero duration disables the timeout.
+func (r *Request) Timeout(d time.Duration) *Request {
+	r.timeout = d
+	return r
+}
+
+// Retries overrides the number of retries set with WithRetries for this request.
+// Setting retries on a request that is not idempotent has no effect.
+func (r *Request) Retries(n int) *Request {
+	r.retries = n
+	return r
+}
+
+// Do sends the request and returns the response. Responses with a status of 400
+// or above are returned as a *StatusError, the body of the response is read and
+// closed in that case. Otherwise the caller must close the body of the response.
+func (r *Request) Do(ctx context.Context) (*Response, error) {
+	if r.err != nil {
+		return nil, fmt.Errorf("client: read request body: %w", r.err)
+	}
+
+	cancel := context.CancelFunc(func() {})
+	if r.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+	}
+
+	resp, err := r.send(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		defer cancel()
+		return nil, newStatusError(resp)
+	}
+
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return &Response{Response: resp}, nil
+}
+
+// Decode sends the request and decodes the JSON response body into dst. The body
+// is discarded if dst is nil.
+func (r *Request) Decode(ctx context.Context, dst any) error {
+	resp, err := r.Do(ctx)
+	if err != nil {
+		return err
+	}
+
+	return resp.Decode(dst)
+}
+
+func (r *Request) send(ctx context.Context) (*http.Response, error) {
+	o := r.client.opts
+
+	retries := 0
+	if r.idempotent() && r.reader == nil {
+		retries = r.retries
+	}
+
+	backoff := o.backoff
+	for attempt := 0; ; attempt++ {
+		req, err := r.build(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := o.httpClient.Do(req)
+		if attempt >= retries || !shouldRetry(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		wait := backoff
+		if resp != nil {
+			if d, ok := retryAfter(resp); ok {
+				wait = d
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			if err == nil {
+				err = ctx.Err()
+			}
+			return nil, err
+		case <-time.After(wait):
+		}
+
+		backoff *= 2
+	}
+}
+
+func (r *Request) build(ctx context.Context) (*http.Request, error) {
+	o := r.client.opts
+
+	target := r.path
+	if o.baseURL != "" && !strings.Contains(target, "://") {
+		target = strings.TrimSuffix(o.baseURL, "/") + "/" + strings.TrimPrefix(target, "/")
+	}
+
+	if len(r.query) > 0 {
+		sep := "?"
+		if strings.Contains(target, "?") {
+			sep = "&"
+		}
+		target += sep + r.query.Encode()
+	}
+
+	body := r.reader
+	if r.body != nil {
+		body = bytes.NewReader(r.body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, target, body)
+	if err != nil {
+		return nil, fmt.Errorf("client: %w", err)
+	}
+
+	req.Header = r.header.Clone()
+
+	if o.requestIDHeader != "" && o.requestIDFunc != nil && req.Header.Get(o.requestIDHeader) == "" {
+		if id := o.requestIDFunc(ctx); id != "" {
+			req.Header.Set(o.requestIDHeader, id)
+		}
+	}
+
+	o.propagators.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	return req, nil
+}
+
+func (r *Request) idempotent() bool {
+	switch r.method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	return r.header.Get("Idempotency-Key") != ""
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
+
+// cancelBody releases the timeout context of a request once its body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,93 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// maxErrorBody is the maximum number of bytes of a response body kept in a
+// StatusError.
+const maxErrorBody = 64 << 10
+
+// Response wraps the *http.Response of a successful request.
+type Response struct {
+	*http.Response
+}
+
+// Decode decodes the JSON response body into dst and closes the body. The body is
+// discarded if dst is nil.
+func (r *Response) Decode(dst any) error {
+	defer func() { _ = r.Body.Close() }()
+
+	if dst == nil {
+		_, err := io.Copy(io.Discard, r.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(dst); err != nil {
+		return fmt.Errorf("client: decode response: %w", err)
+	}
+
+	return nil
+}
+
+// StatusError is returned for responses with a status of 400 or above. It
+// implements server.StatusCoder, so returning it from a handler responds with
+// the status of the upstream response.
+type StatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	// Body holds up to the first 64 KiB of the response body.
+	Body []byte
+}
+
+func newStatusError(resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	return &StatusError{
+		Method:     resp.Request.Method,
+		URL:        resp.Request.URL.Redacted(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+func (e *StatusError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// Decode decodes the JSON body of the error response into dst.
+func (e *StatusError) Decode(dst any) error {
+	return json.Unmarshal(e.Body, dst)
+}
+
+// StatusCode returns the status of the response err was created for. ok is false
+// if err does not wrap a *StatusError.
+func StatusCode(err error) (code int, ok bool) {
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		return 0, false
+	}
+
+	return statusErr.StatusCode, true
+}
+
+// IsStatus reports whether err wraps a *StatusError with one of the provided
+// status codes.
+func IsStatus(err error, codes ...int) bool {
+	code, ok := StatusCode(err)
+	return ok && slices.Contains(codes, code)
+}
@@ -0,0 +1,174 @@
+// Package cookies provides helpers for writing and reading signed and encrypted
+// cookies. All helpers accept a list of keys to support key rotation: the first key
+// is used for writing new cookies while every key is tried when reading, so a new
+// key can be prepended without invalidating cookies written with older keys.
+package cookies
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrInvalidValue is returned when a cookie value cannot be verified or decrypted
+	// with any of the provided keys.
+	ErrInvalidValue = errors.New("cookies: invalid cookie value")
+	// ErrNoKeys is returned when no keys are provided.
+	ErrNoKeys = errors.New("cookies: no keys provided")
+	// ErrValueTooLong is returned when the encoded cookie exceeds the 4096 byte limit
+	// supported by browsers.
+	ErrValueTooLong = errors.New("cookies: cookie value too long")
+)
+
+const maxCookieSize = 4096
+
+// SetSigned writes the cookie to w with its value signed using HMAC-SHA256 with the
+// first key. The value is readable by the client but cannot be modified without
+// invalidating the signature.
+func SetSigned(w http.ResponseWriter, c *http.Cookie, keys ...[]byte) error {
+	if len(keys) == 0 {
+		return ErrNoKeys
+	}
+
+	value := base64.RawURLEncoding.EncodeToString([]byte(c.Value))
+	value += "." + base64.RawURLEncoding.EncodeToString(sign(keys[0], c.Name, value))
+
+	return write(w, c, value)
+}
+
+// GetSigned reads the cookie with the provided name from r and verifies its
+// signature against each of the keys. It returns http.ErrNoCookie if the cookie is
+// not present and ErrInvalidValue if the signature does not match any key.
+func GetSigned(r *http.Request, name string, keys ...[]byte) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrNoKeys
+	}
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	value, signature, ok := strings.Cut(c.Value, ".")
+	if !ok {
+		return "", ErrInvalidValue
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return "", ErrInvalidValue
+	}
+
+	for _, key := range keys {
+		if hmac.Equal(mac, sign(key, name, value)) {
+			decoded, err := base64.RawURLEncoding.DecodeString(value)
+			if err != nil {
+				return "", ErrInvalidValue
+			}
+
+			return string(decoded), nil
+		}
+	}
+
+	return "", ErrInvalidValue
+}
+
+// SetEncrypted writes the cookie to w with its value encrypted using AES-GCM with
+// the first key. The cookie name is authenticated along with the value so an
+// encrypted value cannot be moved to a different cookie.
+//
+// Keys may be of any length, they are stretched to 32 bytes using SHA-256.
+func SetEncrypted(w http.ResponseWriter, c *http.Cookie, keys ...[]byte) error {
+	if len(keys) == 0 {
+		return ErrNoKeys
+	}
+
+	gcm, err := newGCM(keys[0])
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	sealed := gcm.Seal(nonce, nonce, []byte(c.Value), []byte(c.Name))
+
+	return write(w, c, base64.RawURLEncoding.EncodeToString(sealed))
+}
+
+// GetEncrypted reads the cookie with the provided name from r and decrypts it with
+// the first key that succeeds. It returns http.ErrNoCookie if the cookie is not
+// present and ErrInvalidValue if the value cannot be decrypted with any key.
+func GetEncrypted(r *http.Request, name string, keys ...[]byte) (string, error) {
+	if len(keys) == 0 {
+		return "", ErrNoKeys
+	}
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(c.Value)
+	if err != nil {
+		return "", ErrInvalidValue
+	}
+
+	for _, key := range keys {
+		gcm, err := newGCM(key)
+		if err != nil {
+			return "", err
+		}
+
+		if len(sealed) < gcm.NonceSize() {
+			return "", ErrInvalidValue
+		}
+
+		nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+
+		plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(name))
+		if err == nil {
+			return string(plaintext), nil
+		}
+	}
+
+	return "", ErrInvalidValue
+}
+
+func sign(key []byte, name, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(name + "=" + value))
+	return mac.Sum(nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	sum := sha256.Sum256(key)
+
+	block, err := aes.NewCipher(sum[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func write(w http.ResponseWriter, c *http.Cookie, value string) error {
+	cookie := *c
+	cookie.Value = value
+
+	if len(cookie.String()) > maxCookieSize {
+		return ErrValueTooLong
+	}
+
+	http.SetCookie(w, &cookie)
+	return nil
+}
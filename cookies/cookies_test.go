@@ -0,0 +1,96 @@
+package cookies
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var (
+	oldKey = []byte("old-secret-key")
+	newKey = []byte("new-secret-key")
+)
+
+// roundTrip returns a request carrying the cookies written to the recorder.
+func roundTrip(rec *httptest.ResponseRecorder) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	return req
+}
+
+func Test_Signed(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	err := SetSigned(rec, &http.Cookie{Name: "flash", Value: "saved!"}, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// rotating in a new key must keep old cookies readable
+	got, err := GetSigned(roundTrip(rec), "flash", newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "saved!" {
+		t.Errorf("expected %q, got %q", "saved!", got)
+	}
+
+	_, err = GetSigned(roundTrip(rec), "flash", newKey)
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("expected ErrInvalidValue, got %v", err)
+	}
+
+	// tampered values are rejected
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	value := rec.Result().Cookies()[0].Value
+	req.AddCookie(&http.Cookie{Name: "flash", Value: "x" + value})
+
+	_, err = GetSigned(req, "flash", oldKey)
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("expected ErrInvalidValue, got %v", err)
+	}
+}
+
+func Test_Encrypted(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	err := SetEncrypted(rec, &http.Cookie{Name: "remember", Value: "user:42"}, newKey, oldKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(rec.Header().Get("Set-Cookie"), "user:42") {
+		t.Error("expected cookie value to be encrypted")
+	}
+
+	got, err := GetEncrypted(roundTrip(rec), "remember", oldKey, newKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got != "user:42" {
+		t.Errorf("expected %q, got %q", "user:42", got)
+	}
+
+	_, err = GetEncrypted(roundTrip(rec), "remember", oldKey)
+	if !errors.Is(err, ErrInvalidValue) {
+		t.Errorf("expected ErrInvalidValue, got %v", err)
+	}
+
+	_, err = GetEncrypted(roundTrip(rec), "missing", newKey)
+	if !errors.Is(err, http.ErrNoCookie) {
+		t.Errorf("expected http.ErrNoCookie, got %v", err)
+	}
+}
+
+func Test_ValueTooLong(t *testing.T) {
+	err := SetSigned(httptest.NewRecorder(), &http.Cookie{Name: "big", Value: strings.Repeat("a", 4096)}, newKey)
+	if !errors.Is(err, ErrValueTooLong) {
+		t.Errorf("expected ErrValueTooLong, got %v", err)
+	}
+}
@@ -0,0 +1,77 @@
+// Package ctxkit provides typed context keys, so values stored in a context are
+// read back with their type and keys of different packages can never collide.
+//
+// Example:
+//
+//	var UserKey = ctxkit.NewKey[*User]("user")
+//
+//	func Auth(h http.Handler) http.Handler {
+//	  return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	    h.ServeHTTP(w, UserKey.SetRequest(r, authenticate(r)))
+//	  })
+//	}
+//
+//	user, ok := UserKey.Get(ctx)
+package ctxkit
+
+import (
+	"context"
+	"net/http"
+)
+
+// Key is a typed context key. Every Key returned by NewKey is unique, even when
+// keys share a name and type. The zero value is not usable, create keys with
+// NewKey.
+type Key[T any] struct {
+	name string
+}
+
+// NewKey returns a new Key for values of type T. The name is only used in error
+// messages.
+func NewKey[T any](name string) *Key[T] {
+	return &Key[T]{name: name}
+}
+
+// String returns the name of the key.
+func (k *Key[T]) String() string {
+	return k.name
+}
+
+// Set returns a copy of ctx carrying v.
+func (k *Key[T]) Set(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k, v)
+}
+
+// SetRequest returns a shallow copy of r with a context carrying v.
+func (k *Key[T]) SetRequest(r *http.Request, v T) *http.Request {
+	return r.WithContext(k.Set(r.Context(), v))
+}
+
+// Get returns the value of the key in ctx. The second return value is false if ctx
+// does not carry the key.
+func (k *Key[T]) Get(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k).(T)
+	return v, ok
+}
+
+// GetOr returns the value of the key in ctx, or fallback if ctx does not carry the
+// key.
+func (k *Key[T]) GetOr(ctx context.Context, fallback T) T {
+	if v, ok := k.Get(ctx); ok {
+		return v
+	}
+
+	return fallback
+}
+
+// MustGet returns the value of the key in ctx, and panics if ctx does not carry
+// the key. Use it for values that are guaranteed by middleware, where a missing
+// value is a programming error.
+func (k *Key[T]) MustGet(ctx context.Context) T {
+	v, ok := k.Get(ctx)
+	if !ok {
+		panic("ctxkit: context does not carry " + k.name)
+	}
+
+	return v
+}
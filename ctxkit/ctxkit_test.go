@@ -0,0 +1,52 @@
+package ctxkit
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+)
+
+func Test_Key(t *testing.T) {
+	var (
+		ctx   = context.Background()
+		name  = NewKey[string]("name")
+		other = NewKey[string]("name")
+	)
+
+	if _, ok := name.Get(ctx); ok {
+		t.Fatal("expected empty context not to carry the key")
+	}
+
+	ctx = name.Set(ctx, "gopher")
+
+	if v, ok := name.Get(ctx); !ok || v != "gopher" {
+		t.Errorf("expected gopher, got %q", v)
+	}
+
+	if _, ok := other.Get(ctx); ok {
+		t.Error("expected keys with the same name not to collide")
+	}
+
+	if v := other.GetOr(ctx, "fallback"); v != "fallback" {
+		t.Errorf("expected fallback, got %q", v)
+	}
+
+	if v := name.MustGet(ctx); v != "gopher" {
+		t.Errorf("expected gopher, got %q", v)
+	}
+
+	r := name.SetRequest(httptest.NewRequest("GET", "/", nil), "ferris")
+	if v := name.MustGet(r.Context()); v != "ferris" {
+		t.Errorf("expected ferris, got %q", v)
+	}
+}
+
+func Test_Key_MustGet_Panics(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "ctxkit: context does not carry user" {
+			t.Errorf("unexpected panic %v", r)
+		}
+	}()
+
+	NewKey[int]("user").MustGet(context.Background())
+}
@@ -1,6 +1,11 @@
 package errchain
 
-import "net/http"
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hay-kot/httpkit/ctxkit"
+)
 
 // Router is an interface that defines the contract required for the internal implementation
 // of the Mux type. The Mux type is a wrapper around whatever implementation of the Router
@@ -42,6 +47,7 @@ type Mux struct {
 	mux    Router
 	prefix string
 	chain  *ErrChain
+	routes []Route
 
 	// Hook is a function that can be used to add hooks to the mux. This
 	// was implemented to allow for the otelhttp.WithRouteTag method to be
@@ -88,7 +94,61 @@ func (r *Mux) handle(path string, h Handler, mw ...Middleware) {
 		hdlr = r.Hook(path, hdlr)
 	}
 
-	r.mux.Handle(path, hdlr)
+	r.mux.Handle(path, withPattern(path, hdlr))
+	r.routes = append(r.routes, newRoute(path))
+}
+
+// Route is a route registered on a Mux.
+type Route struct {
+	// Method is the HTTP method of the route, empty if the route matches all
+	// methods.
+	Method string
+	// Path is the path of the route pattern including the prefix of the Mux, for
+	// example "/api/users/{id}".
+	Path string
+}
+
+func newRoute(pattern string) Route {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return Route{Path: pattern}
+	}
+
+	return Route{Method: method, Path: strings.TrimLeft(path, " \t")}
+}
+
+func (r Route) String() string {
+	if r.Method == "" {
+		return r.Path
+	}
+	return r.Method + " " + r.Path
+}
+
+// Routes returns the routes registered on the mux in the order they were added.
+// Handlers registered directly on the underlying Router are not included.
+func (r *Mux) Routes() []Route {
+	return append([]Route(nil), r.routes...)
+}
+
+var patternKey = ctxkit.NewKey[string]("route pattern")
+
+// withPattern stores the registered route pattern in the request context so
+// that it is available to all middleware registered on the mux.
+func withPattern(pattern string, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.ServeHTTP(w, patternKey.SetRequest(req, pattern))
+	})
+}
+
+// RoutePattern returns the pattern the request was matched against when it was
+// routed through a Mux, for example "GET /users/{id}". If the request was not
+// routed through a Mux, an empty string is returned.
+//
+// The pattern is available to middleware registered with Mux.Use, the ErrChain
+// and the handler itself.
+func RoutePattern(r *http.Request) string {
+	pattern, _ := patternKey.Get(r.Context())
+	return pattern
 }
 
 // UseRouter sets the internal router for the mux. This overrides the
@@ -228,3 +228,25 @@ func Test_Router_MiddlewareOrder(t *testing.T) {
 	}
 	_ = resp.Body.Close()
 }
+
+func Test_Router_RoutePattern(t *testing.T) {
+	var got string
+
+	r := NewMux(New(TestErrHandler)).UsePrefix("/api")
+	r.Use(func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			got = RoutePattern(req)
+			h.ServeHTTP(w, req)
+		})
+	})
+
+	r.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/users/1", nil))
+
+	if got != "GET /api/users/{id}" {
+		t.Errorf("expected pattern %q, got %q", "GET /api/users/{id}", got)
+	}
+}
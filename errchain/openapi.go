@@ -0,0 +1,199 @@
+package errchain
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// openAPIMethods are the operations of an OpenAPI path item.
+var openAPIMethods = []string{
+	http.MethodGet,
+	http.MethodPut,
+	http.MethodPost,
+	http.MethodDelete,
+	http.MethodOptions,
+	http.MethodHead,
+	http.MethodPatch,
+	http.MethodTrace,
+}
+
+type openAPIDoc struct {
+	Servers []struct {
+		URL string `yaml:"url"`
+	} `yaml:"servers"`
+	Paths map[string]map[string]yaml.Node `yaml:"paths"`
+}
+
+// RouteMismatch is a route registered on a Mux that matches an operation of an
+// OpenAPI document only when the names of the path parameters are ignored.
+type RouteMismatch struct {
+	Route    Route
+	SpecPath string
+}
+
+// OpenAPIError is returned by VerifyOpenAPI when the routes of a Mux do not
+// conform to an OpenAPI document.
+type OpenAPIError struct {
+	// Missing are the operations of the document without a route.
+	Missing []Route
+	// Extra are the routes without an operation in the document.
+	Extra []Route
+	// Mismatched are the routes whose path parameters are named differently than
+	// in the document.
+	Mismatched []RouteMismatch
+}
+
+func (e *OpenAPIError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("errchain: routes do not conform to the OpenAPI document")
+
+	for _, r := range e.Missing {
+		sb.WriteString("\n  missing route: " + r.String())
+	}
+
+	for _, r := range e.Extra {
+		sb.WriteString("\n  undocumented route: " + r.String())
+	}
+
+	for _, m := range e.Mismatched {
+		sb.WriteString("\n  mismatched path parameters: " + m.Route.String() + " documented as " + m.SpecPath)
+	}
+
+	return sb.String()
+}
+
+// VerifyOpenAPI compares the routes registered on mux with the operations of the
+// OpenAPI document spec, provided as JSON or YAML. It returns an *OpenAPIError
+// listing the operations without a route, the routes without an operation and
+// the routes whose path parameters are named differently than in the document.
+//
+// The path of the first server URL of the document is prepended to all paths.
+// Routes registered without a method match every operation of the path, and GET
+// routes match HEAD operations as they do in http.ServeMux.
+//
+// Example:
+//
+//	func Test_OpenAPI(t *testing.T) {
+//	  if err := errchain.VerifyOpenAPI(newMux(), openapiYAML); err != nil {
+//	    t.Fatal(err)
+//	  }
+//	}
+func VerifyOpenAPI(mux *Mux, spec []byte) error {
+	var doc openAPIDoc
+	if err := yaml.Unmarshal(spec, &doc); err != nil {
+		return fmt.Errorf("errchain: parse OpenAPI document: %w", err)
+	}
+
+	basePath := ""
+	if len(doc.Servers) > 0 {
+		if u, err := url.Parse(doc.Servers[0].URL); err == nil {
+			basePath = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+
+	var ops []Route
+	for path, item := range doc.Paths {
+		for _, method := range openAPIMethods {
+			if _, ok := item[strings.ToLower(method)]; ok {
+				ops = append(ops, Route{Method: method, Path: basePath + path})
+			}
+		}
+	}
+
+	routes := mux.Routes()
+	matched := make([]bool, len(routes))
+
+	report := &OpenAPIError{}
+
+	for _, op := range ops {
+		found, mismatch := -1, -1
+		for i, r := range routes {
+			if r.Method != "" && r.Method != op.Method && (r.Method != http.MethodGet || op.Method != http.MethodHead) {
+				continue
+			}
+
+			routePath := strings.TrimSuffix(r.Path, "{$}")
+			if routePath == op.Path {
+				found = i
+				break
+			}
+
+			if mismatch < 0 && normalizePath(routePath) == normalizePath(op.Path) {
+				mismatch = i
+			}
+		}
+
+		switch {
+		case found >= 0:
+			matched[found] = true
+		case mismatch >= 0:
+			m := RouteMismatch{Route: routes[mismatch], SpecPath: op.Path}
+			if !slices.Contains(report.Mismatched, m) {
+				report.Mismatched = append(report.Mismatched, m)
+			}
+			matched[mismatch] = true
+		default:
+			report.Missing = append(report.Missing, op)
+		}
+	}
+
+	for i, r := range routes {
+		if !matched[i] {
+			report.Extra = append(report.Extra, r)
+		}
+	}
+
+	if len(report.Missing) == 0 && len(report.Extra) == 0 && len(report.Mismatched) == 0 {
+		return nil
+	}
+
+	sortRoutes(report.Missing)
+	sortRoutes(report.Extra)
+	sort.Slice(report.Mismatched, func(i, j int) bool {
+		return lessRoute(report.Mismatched[i].Route, report.Mismatched[j].Route)
+	})
+
+	return report
+}
+
+// normalizePath replaces the names of path parameters with empty braces, so
+// "/users/{id}" and "/users/{userID}" compare equal.
+func normalizePath(path string) string {
+	var sb strings.Builder
+	for {
+		start := strings.IndexByte(path, '{')
+		if start < 0 {
+			break
+		}
+
+		end := strings.IndexByte(path[start:], '}')
+		if end < 0 {
+			break
+		}
+
+		sb.WriteString(path[:start+1])
+		path = path[start+end:]
+	}
+
+	sb.WriteString(path)
+	return sb.String()
+}
+
+func sortRoutes(routes []Route) {
+	sort.Slice(routes, func(i, j int) bool {
+		return lessRoute(routes[i], routes[j])
+	})
+}
+
+func lessRoute(a, b Route) bool {
+	if a.Path != b.Path {
+		return a.Path < b.Path
+	}
+	return a.Method < b.Method
+}
@@ -0,0 +1,73 @@
+package errchain
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+const testSpec = `
+openapi: 3.0.3
+servers:
+  - url: https://api.example.com/api
+paths:
+  /users:
+    get: {}
+    post: {}
+  /users/{userID}:
+    get: {}
+    head: {}
+    delete: {}
+  /health:
+    get: {}
+`
+
+func Test_VerifyOpenAPI(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) error { return nil }
+
+	mux := NewMux(New(TestErrHandler)).UsePrefix("/api")
+	mux.Get("/users", noop)
+	mux.Post("/users", noop)
+	mux.Get("/users/{id}", noop)
+	mux.Delete("/users/{userID}", noop)
+	mux.Put("/users/{userID}", noop)
+
+	err := VerifyOpenAPI(mux, []byte(testSpec))
+
+	var report *OpenAPIError
+	if !errors.As(err, &report) {
+		t.Fatalf("expected *OpenAPIError, got %v", err)
+	}
+
+	wantMissing := []Route{{Method: http.MethodGet, Path: "/api/health"}}
+	if !reflect.DeepEqual(report.Missing, wantMissing) {
+		t.Errorf("expected missing %v, got %v", wantMissing, report.Missing)
+	}
+
+	wantExtra := []Route{{Method: http.MethodPut, Path: "/api/users/{userID}"}}
+	if !reflect.DeepEqual(report.Extra, wantExtra) {
+		t.Errorf("expected extra %v, got %v", wantExtra, report.Extra)
+	}
+
+	wantMismatched := []RouteMismatch{
+		{Route: Route{Method: http.MethodGet, Path: "/api/users/{id}"}, SpecPath: "/api/users/{userID}"},
+	}
+	if !reflect.DeepEqual(report.Mismatched, wantMismatched) {
+		t.Errorf("expected mismatched %v, got %v", wantMismatched, report.Mismatched)
+	}
+}
+
+func Test_VerifyOpenAPI_Conforms(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) error { return nil }
+
+	mux := NewMux(New(TestErrHandler))
+	mux.Get("/users/{id}", noop)
+	mux.ErrHandle("/{$}", HandlerFunc(noop))
+
+	spec := `{"openapi":"3.1.0","paths":{"/users/{id}":{"get":{},"head":{}},"/":{"get":{},"post":{}}}}`
+
+	if err := VerifyOpenAPI(mux, []byte(spec)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
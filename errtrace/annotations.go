@@ -0,0 +1,159 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+)
+
+// annotation is implemented by wrappers that attach metadata to an error without
+// adding a frame to the trace. They are skipped when the frames of an error chain
+// are collected.
+type annotation interface {
+	error
+	Unwrap() error
+	annotation()
+}
+
+type codeError struct {
+	err  error
+	code string
+}
+
+func (e *codeError) Error() string     { return e.err.Error() }
+func (e *codeError) Unwrap() error     { return e.err }
+func (e *codeError) ErrorCode() string { return e.code }
+func (*codeError) annotation()         {}
+
+// WithCode attaches a machine-readable code to err. The code is preserved when the
+// error is wrapped further and can be retrieved with Code. The returned error
+// exposes the code through an ErrorCode() string method which is recognized by the
+// server package's ErrorBuilder. If err is nil, WithCode returns nil.
+//
+// Example:
+//
+//	return errtrace.WithCode(errtrace.Wrapf(err, "user %d not found", id), "user_not_found")
+func WithCode(err error, code string) error {
+	if err == nil {
+		return nil
+	}
+
+	return &codeError{err: err, code: code}
+}
+
+// Code returns the code attached to err with WithCode. If multiple codes exist in
+// the chain, the outermost code is returned.
+func Code(err error) (string, bool) {
+	var coder interface{ ErrorCode() string }
+	if !errors.As(err, &coder) {
+		return "", false
+	}
+
+	return coder.ErrorCode(), true
+}
+
+type statusError struct {
+	err    error
+	status int
+}
+
+func (e *statusError) Error() string   { return e.err.Error() }
+func (e *statusError) Unwrap() error   { return e.err }
+func (e *statusError) HTTPStatus() int { return e.status }
+func (*statusError) annotation()       {}
+
+// WrapStatus is the same as Wrapf, but also attaches an HTTP status code to the
+// error. The returned error exposes the status through an HTTPStatus() int method
+// which is recognized by the server package's ErrorBuilder, so a single wrap both
+// locates the failure and drives the response status. The status can be retrieved
+// with Status. If err is nil, WrapStatus returns nil.
+//
+// Example:
+//
+//	return errtrace.WrapStatus(err, http.StatusNotFound, "user %d not found", id)
+func WrapStatus(err error, status int, msg string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return &statusError{err: defaultTracer.newTraceable(0, err, msg, args...), status: status}
+}
+
+// Status returns the HTTP status attached to err with WrapStatus. If multiple
+// statuses exist in the chain, the outermost status is returned.
+func Status(err error) (int, bool) {
+	var statuser interface{ HTTPStatus() int }
+	if !errors.As(err, &statuser) {
+		return 0, false
+	}
+
+	return statuser.HTTPStatus(), true
+}
+
+type fieldsError struct {
+	err    error
+	fields []any
+}
+
+func (e *fieldsError) Error() string { return e.err.Error() }
+func (e *fieldsError) Unwrap() error { return e.err }
+func (*fieldsError) annotation()     {}
+
+// WrapFields attaches key/value pairs to err. Keys should be strings, other types
+// are converted using fmt.Sprint. A trailing key without a value is stored under
+// the "!BADKEY" key. If err is nil, WrapFields returns nil.
+//
+// Fields are carried through further wrapping and can be retrieved with Fields.
+//
+// Example:
+//
+//	return errtrace.WrapFields(err, "user_id", id, "order", n)
+func WrapFields(err error, kv ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return &fieldsError{err: err, fields: kv}
+}
+
+// Fields returns all fields attached to the chain of err with WrapFields. When the
+// same key is set at multiple levels of the chain, the outermost value wins. If no
+// fields are attached, Fields returns nil. Values are passed through the redactor set
+// with SetFieldRedactor.
+func Fields(err error) map[string]any {
+	return defaultTracer.Fields(err)
+}
+
+// collectFields returns the fields attached to the chain of err without redaction.
+func collectFields(err error) map[string]any {
+	var fields map[string]any
+
+	for err != nil {
+		if fe, ok := err.(*fieldsError); ok { //nolint:errorlint
+			if fields == nil {
+				fields = map[string]any{}
+			}
+
+			for i := 0; i < len(fe.fields); i += 2 {
+				if i+1 >= len(fe.fields) {
+					if _, exists := fields["!BADKEY"]; !exists {
+						fields["!BADKEY"] = fe.fields[i]
+					}
+					break
+				}
+
+				key, ok := fe.fields[i].(string)
+				if !ok {
+					key = fmt.Sprint(fe.fields[i])
+				}
+
+				if _, exists := fields[key]; !exists {
+					fields[key] = fe.fields[i+1]
+				}
+			}
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return fields
+}
@@ -0,0 +1,85 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	sentinel := errors.New("not found")
+
+	err := WithCode(New("user not found"), "user_not_found")
+	err = fmt.Errorf("service: %w", Wrapf(err, "wrapped"))
+
+	code, ok := Code(err)
+	if !ok || code != "user_not_found" {
+		t.Errorf("expected code user_not_found, got %q", code)
+	}
+
+	if _, ok := Code(sentinel); ok {
+		t.Error("expected no code for plain errors")
+	}
+
+	if WithCode(nil, "code") != nil {
+		t.Error("expected WithCode(nil) to return nil")
+	}
+
+	// annotations do not add frames to the trace
+	frames := chainFrames(Wrapf(WithCode(sentinel, "code"), "wrapped"))
+	if len(frames) != 2 {
+		t.Errorf("expected 2 frames, got %d", len(frames))
+	}
+}
+
+func TestFields(t *testing.T) {
+	err := WrapFields(errors.New("root"), "user_id", 1, "order", 7)
+	err = Wrapf(err, "wrapped")
+	err = WrapFields(fmt.Errorf("outer: %w", err), "user_id", 2, 3, "three", "dangling")
+
+	got := Fields(err)
+
+	want := map[string]any{
+		"user_id": 2,
+		"order":   7,
+		"3":       "three",
+		"!BADKEY": "dangling",
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("expected %s=%v, got %v", k, v, got[k])
+		}
+	}
+
+	if Fields(errors.New("plain")) != nil {
+		t.Error("expected nil fields for plain errors")
+	}
+}
+
+func TestWrapStatus(t *testing.T) {
+	if WrapStatus(nil, http.StatusNotFound, "noop") != nil {
+		t.Error("expected nil error")
+	}
+
+	err := Wrapf(WrapStatus(errors.New("no rows"), http.StatusNotFound, "user %d not found", 1), "handler")
+
+	status, ok := Status(err)
+	if !ok || status != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d %v", status, ok)
+	}
+
+	frames := chainFrames(err)
+	if len(frames) != 3 || frames[1].Error != "user 1 not found" || frames[1].Function != "github.com/hay-kot/httpkit/errtrace.TestWrapStatus" {
+		t.Errorf("expected status wrapper to record a trace frame, got %+v", frames)
+	}
+
+	if _, ok := Status(errors.New("plain")); ok {
+		t.Error("expected no status for plain errors")
+	}
+}
@@ -0,0 +1,64 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+var (
+	benchRoot = errors.New("root")
+	benchSink error
+)
+
+func BenchmarkFmtErrorf(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = fmt.Errorf("query user %d: %w", i, benchRoot)
+	}
+}
+
+func BenchmarkWrapf(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = Wrapf(benchRoot, "query user %d", i)
+	}
+}
+
+func BenchmarkWrapfError(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = Wrapf(benchRoot, "query user %d", i)
+		_ = benchSink.Error()
+	}
+}
+
+func BenchmarkWrapMsg(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = WrapMsg(benchRoot, "query user")
+	}
+}
+
+func BenchmarkWrapStack(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = WrapStack(benchRoot, "query user")
+	}
+}
+
+func BenchmarkTraceString(b *testing.B) {
+	err := Wrapf(Wrapf(benchRoot, "query user"), "handler")
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = TraceStringPlain(err)
+	}
+}
+
+func BenchmarkWrapLazy(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = WrapLazy(benchRoot, "query user %d", i)
+	}
+}
@@ -0,0 +1,92 @@
+package errtrace
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// BuildInfo identifies the binary that produced a trace. It is read from
+// debug.ReadBuildInfo.
+type BuildInfo struct {
+	Module   string `json:"module,omitempty"`   // path of the main module
+	Version  string `json:"version,omitempty"`  // version of the main module, "(devel)" for local builds
+	Revision string `json:"revision,omitempty"` // VCS revision the binary was built from
+	Time     string `json:"time,omitempty"`     // VCS commit time in RFC 3339 format
+	Modified bool   `json:"modified,omitempty"` // whether the working tree had uncommitted changes
+}
+
+// String returns a short description of the build, e.g.
+// "github.com/org/app v1.2.3 (rev 1a2b3c4, 2024-05-01T12:00:00Z)".
+func (b *BuildInfo) String() string {
+	s := b.Module
+	if b.Version != "" {
+		s += " " + b.Version
+	}
+
+	if b.Revision == "" {
+		return s
+	}
+
+	rev := b.Revision
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	if b.Modified {
+		rev += "-dirty"
+	}
+
+	s += " (rev " + rev
+	if b.Time != "" {
+		s += ", " + b.Time
+	}
+
+	return s + ")"
+}
+
+// EnableBuildInfo stamps the first frame of every trace rendered by TraceString,
+// MarshalStack and the logger integrations with the module version, VCS revision and
+// commit time of the binary, so traces aggregated across rolling deploys identify the
+// exact binary that produced them. The information is read once with
+// debug.ReadBuildInfo, VCS information is only available for binaries built with
+// -buildvcs, which is the default for go build within a repository.
+func EnableBuildInfo() {
+	defaultTracer.Configure(func(c *Config) {
+		c.BuildInfo = true
+	})
+}
+
+// DisableBuildInfo stops stamping traces with the build information.
+func DisableBuildInfo() {
+	defaultTracer.Configure(func(c *Config) {
+		c.BuildInfo = false
+	})
+}
+
+var currentBuildInfo = sync.OnceValue(func() *BuildInfo {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil
+	}
+
+	return newBuildInfo(info)
+})
+
+func newBuildInfo(info *debug.BuildInfo) *BuildInfo {
+	b := &BuildInfo{
+		Module:  info.Main.Path,
+		Version: info.Main.Version,
+	}
+
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			b.Revision = setting.Value
+		case "vcs.time":
+			b.Time = setting.Value
+		case "vcs.modified":
+			b.Modified = setting.Value == "true"
+		}
+	}
+
+	return b
+}
@@ -0,0 +1,48 @@
+package errtrace
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+func TestNewBuildInfo(t *testing.T) {
+	b := newBuildInfo(&debug.BuildInfo{
+		Main: debug.Module{Path: "github.com/org/app", Version: "v1.2.3"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "1a2b3c4d5e6f7a8b9c0d"},
+			{Key: "vcs.time", Value: "2024-05-01T12:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	})
+
+	want := "github.com/org/app v1.2.3 (rev 1a2b3c4d5e6f-dirty, 2024-05-01T12:00:00Z)"
+	if b.String() != want {
+		t.Errorf("String() = %q, want %q", b.String(), want)
+	}
+}
+
+func TestEnableBuildInfo(t *testing.T) {
+	err := Wrapf(New("root"), "wrap")
+
+	if chainFrames(err)[0].Build != nil {
+		t.Error("expected no build info by default")
+	}
+
+	EnableBuildInfo()
+	defer DisableBuildInfo()
+
+	frames := chainFrames(err)
+	if frames[0].Build == nil || frames[1].Build != nil {
+		t.Fatalf("expected build info on the first frame only, got %+v", frames)
+	}
+
+	if str := TraceStringPlain(err); !strings.Contains(str, "\nbuild: ") {
+		t.Errorf("expected build line in trace, got:\n%s", str)
+	}
+
+	b, _ := MarshalJSON(err)
+	if !strings.Contains(string(b), `"build":{`) {
+		t.Errorf("expected build in JSON, got %s", b)
+	}
+}
@@ -0,0 +1,44 @@
+package errtrace
+
+import (
+	"os"
+)
+
+// DisableColor disables ANSI color codes in the output of TraceString.
+func DisableColor() {
+	defaultTracer.Configure(func(c *Config) {
+		c.Color = false
+	})
+}
+
+// EnableColor enables ANSI color codes in the output of TraceString, regardless of
+// the environment.
+func EnableColor() {
+	defaultTracer.Configure(func(c *Config) {
+		c.Color = true
+	})
+}
+
+// detectColor reports whether the environment supports color output. Color is
+// disabled when the NO_COLOR environment variable is set (https://no-color.org),
+// when TERM is "dumb" or when stdout or stderr is not a terminal.
+func detectColor() bool {
+	if v, ok := os.LookupEnv("NO_COLOR"); ok && v != "" {
+		return false
+	}
+
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+
+	return isTerminal(os.Stdout) && isTerminal(os.Stderr)
+}
+
+func isTerminal(f *os.File) bool {
+	stat, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return stat.Mode()&os.ModeCharDevice != 0
+}
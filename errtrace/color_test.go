@@ -0,0 +1,37 @@
+package errtrace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDisableColor(t *testing.T) {
+	color := Default().Config().Color
+	defer Default().Configure(func(c *Config) { c.Color = color })
+
+	err := Wrapf(errors.New("root error"), "wrapped")
+
+	EnableColor()
+	if !strings.Contains(TraceString(err), "\033[") {
+		t.Error("expected color codes when color is enabled")
+	}
+
+	DisableColor()
+	if strings.Contains(TraceString(err), "\033[") {
+		t.Error("expected no color codes when color is disabled")
+	}
+
+	EnableColor()
+	if strings.Contains(TraceStringPlain(err), "\033[") {
+		t.Error("expected no color codes from TraceStringPlain")
+	}
+}
+
+func TestDetectColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	if detectColor() {
+		t.Error("expected color to be disabled when NO_COLOR is set")
+	}
+}
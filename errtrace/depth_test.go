@@ -0,0 +1,50 @@
+package errtrace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func deepChain(n int) error {
+	err := errors.New("root")
+	for i := 0; i < n; i++ {
+		err = Wrapf(err, "retry %d", i)
+	}
+
+	return err
+}
+
+func TestMaxDepth(t *testing.T) {
+	err := deepChain(100)
+
+	frames := MarshalStackDepth(err, 3).([]Frame)
+	if len(frames) != 4 {
+		t.Fatalf("expected 3 frames and a marker, got %d", len(frames))
+	}
+
+	if frames[3].Omitted != 98 || frames[3].Error != "… 98 more" {
+		t.Errorf("unexpected marker %+v", frames[3])
+	}
+
+	if len(MarshalStackDepth(err, 0).([]Frame)) != 101 {
+		t.Error("expected no truncation with depth 0")
+	}
+
+	SetMaxDepth(2)
+	defer SetMaxDepth(0)
+
+	str := TraceStringPlain(err)
+	if strings.Count(str, "trace error:") != 2 || !strings.HasSuffix(str, "… 99 more\n") {
+		t.Errorf("expected truncated trace, got:\n%s", str)
+	}
+
+	if got := TraceStringDepth(err, 1); strings.Count(got, "trace error:") != 1 {
+		t.Errorf("expected per call depth to override global depth, got:\n%s", got)
+	}
+
+	compact := TraceStringWith(err, CompactFormatter{})
+	if !strings.HasSuffix(compact, " <- … 99 more") {
+		t.Errorf("unexpected compact output %q", compact)
+	}
+}
@@ -0,0 +1,68 @@
+package errtrace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"regexp"
+)
+
+var volatilePatterns = []struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`"[^"]*"|'[^']*'|` + "`[^`]*`"), `"?"`},
+	{regexp.MustCompile(`[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`), "<uuid>"},
+	{regexp.MustCompile(`0x[0-9a-fA-F]+`), "<hex>"},
+	{regexp.MustCompile(`\b[0-9a-fA-F]*[0-9][0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*\b|\b[0-9a-fA-F]*[a-fA-F][0-9a-fA-F]*[0-9][0-9a-fA-F]*\b`), "<hex>"},
+	{regexp.MustCompile(`[0-9]+`), "<n>"},
+}
+
+// normalizeMessage replaces values that usually differ between occurrences of the
+// same failure, such as quoted strings, UUIDs, hex strings and numbers, with
+// placeholders.
+func normalizeMessage(msg string) string {
+	for _, p := range volatilePatterns {
+		msg = p.re.ReplaceAllString(msg, p.placeholder)
+	}
+
+	return msg
+}
+
+// Fingerprint returns a stable hash of err that can be used to group occurrences of
+// the same failure, for example in error aggregation dashboards. The hash is built
+// from the functions of the traceable errors in the chain and the message of the root
+// cause. Line numbers are ignored and quoted strings, UUIDs, hex strings and numbers
+// in the message are replaced with placeholders, so the fingerprint stays the same
+// across restarts, versions and different input values.
+//
+// Fingerprint returns an empty string if err is nil.
+func Fingerprint(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	h := sha256.New()
+	writeFingerprint(h, chainFramesDepth(err, 0))
+
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+func writeFingerprint(h hash.Hash, frames []Frame) {
+	for _, f := range frames {
+		if f.Function != "" {
+			_, _ = h.Write([]byte(f.Function))
+			_, _ = h.Write([]byte{0})
+		}
+
+		for _, branch := range f.Branches {
+			_, _ = h.Write([]byte{'['})
+			writeFingerprint(h, branch)
+			_, _ = h.Write([]byte{']'})
+		}
+	}
+
+	if len(frames) > 0 && len(frames[len(frames)-1].Branches) == 0 {
+		_, _ = h.Write([]byte(normalizeMessage(frames[len(frames)-1].Error)))
+	}
+}
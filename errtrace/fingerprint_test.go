@@ -0,0 +1,56 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func fingerprintErr(id int, name string) error {
+	root := fmt.Errorf("user %d with name %q not found", id, name)
+	return Wrapf(root, "lookup user %d", id)
+}
+
+func TestFingerprint(t *testing.T) {
+	if Fingerprint(nil) != "" {
+		t.Error("expected empty fingerprint for nil error")
+	}
+
+	a := Fingerprint(fingerprintErr(1, "alice"))
+	b := Fingerprint(fingerprintErr(42, "bob"))
+	if a != b {
+		t.Errorf("expected volatile values to be ignored, got %s and %s", a, b)
+	}
+
+	if len(a) != 16 {
+		t.Errorf("expected 16 character fingerprint, got %q", a)
+	}
+
+	if c := Fingerprint(Wrapf(errors.New("permission denied"), "lookup user")); c == a {
+		t.Error("expected different root causes to produce different fingerprints")
+	}
+
+	if c := Fingerprint(New("user 1 with name \"alice\" not found")); c == a {
+		t.Error("expected different functions to produce different fingerprints")
+	}
+}
+
+func TestNormalizeMessage(t *testing.T) {
+	tests := []struct {
+		msg  string
+		want string
+	}{
+		{`user 42 not found`, `user <n> not found`},
+		{`key "abc" missing`, `key "?" missing`},
+		{`request 3fa85f64-5717-4562-b3fc-2c963f66afa6 failed`, `request <uuid> failed`},
+		{`address 0xc000123 invalid`, `address <hex> invalid`},
+		{`commit 9fceb02d0ae5 missing`, `commit <hex> missing`},
+		{`connection refused`, `connection refused`},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeMessage(tt.msg); got != tt.want {
+			t.Errorf("normalizeMessage(%q) = %q, want %q", tt.msg, got, tt.want)
+		}
+	}
+}
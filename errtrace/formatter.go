@@ -0,0 +1,524 @@
+package errtrace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Formatter renders the frames of an error chain, as returned by MarshalStack, to a
+// string. Formatters are used by TraceString and TraceStringWith.
+type Formatter interface {
+	Format(frames []Frame) string
+}
+
+// SetFormatter sets the formatter used by TraceString. Passing nil restores the
+// default TextFormatter.
+//
+// Example:
+//
+//	errtrace.SetFormatter(errtrace.CompactFormatter{})
+func SetFormatter(f Formatter) {
+	defaultTracer.Configure(func(c *Config) {
+		c.Formatter = f
+	})
+}
+
+// TextFormatter is the default multi-line formatter.
+//
+// Example output:
+//
+//	trace error: error creating user in database
+//	    cmd/cli/main.go:22
+//	        main.ServiceNewUser() -> user repo: error writing to database
+//	error:
+//	    user repo: error writing to database
+type TextFormatter struct {
+	// Color enables ANSI color codes in the output.
+	Color bool
+
+	// Snippets includes the source code around the recorded line of every traceable
+	// frame. See EnableSnippets.
+	Snippets bool
+
+	// Opts controls the layout of the output.
+	Opts TraceStringOpts
+}
+
+// PathStyle controls how TextFormatter renders the file paths of frames.
+type PathStyle int
+
+const (
+	// PathAsRecorded renders paths as recorded, after applying OverrideCleaner.
+	PathAsRecorded PathStyle = iota
+	// PathRelative renders paths relative to the current working directory.
+	PathRelative
+	// PathBase renders only the file name.
+	PathBase
+)
+
+// TraceStringOpts controls the layout of the TextFormatter. The zero value produces
+// the default layout.
+type TraceStringOpts struct {
+	// Indent is the string used for each level of indentation. Defaults to four
+	// spaces.
+	Indent string
+
+	// HideFunctions omits the function names of frames.
+	HideFunctions bool
+
+	// Paths controls how file paths are rendered.
+	Paths PathStyle
+
+	// MaxMessageWidth truncates error messages longer than the given number of
+	// characters. 0 disables truncation.
+	MaxMessageWidth int
+}
+
+func (o TraceStringOpts) indent() string {
+	if o.Indent == "" {
+		return "    "
+	}
+
+	return o.Indent
+}
+
+func (o TraceStringOpts) path(p string) string {
+	switch o.Paths {
+	case PathRelative:
+		if !filepath.IsAbs(p) {
+			return p
+		}
+
+		if cwd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(cwd, p); err == nil {
+				return rel
+			}
+		}
+	case PathBase:
+		return filepath.Base(p)
+	}
+
+	return p
+}
+
+func (o TraceStringOpts) message(msg string) string {
+	if o.MaxMessageWidth <= 0 {
+		return msg
+	}
+
+	runes := []rune(msg)
+	if len(runes) <= o.MaxMessageWidth {
+		return msg
+	}
+
+	return string(runes[:o.MaxMessageWidth]) + "…"
+}
+
+// TraceStringWithOpts is the same as TraceString, but renders the trace with a
+// TextFormatter using the provided layout options. Color and snippets follow the
+// global settings.
+//
+// Example:
+//
+//	errtrace.TraceStringWithOpts(err, errtrace.TraceStringOpts{
+//	  Indent:          "  ",
+//	  Paths:           errtrace.PathBase,
+//	  MaxMessageWidth: 120,
+//	})
+func TraceStringWithOpts(err error, opts TraceStringOpts) string {
+	cfg := defaultTracer.Config()
+
+	return TraceStringWith(err, TextFormatter{
+		Color:    cfg.Color,
+		Snippets: cfg.Snippets,
+		Opts:     opts,
+	})
+}
+
+func (t TextFormatter) Format(frames []Frame) string {
+	red := func(s string) string { return s }
+	bold := func(s string) string { return s }
+
+	if t.Color {
+		red = func(s string) string { return "\033[31m" + s + "\033[0m" }
+		bold = func(s string) string { return "\033[1m" + s + "\033[0m" }
+	}
+
+	str := strings.Builder{}
+	lastWasTraceable := false
+	indent := t.Opts.indent()
+
+	for i, f := range frames {
+		if f.Omitted > 0 {
+			str.WriteString(f.Error)
+			str.WriteRune('\n')
+			continue
+		}
+
+		if !f.IsTraceable() {
+			if !lastWasTraceable {
+				str.WriteString(red(bold("error: ")))
+				str.WriteRune('\n')
+				str.WriteString(indent)
+				str.WriteString(t.Opts.message(f.Error))
+				str.WriteRune('\n')
+			}
+
+			lastWasTraceable = false
+			continue
+		}
+
+		lastWasTraceable = true
+
+		str.WriteString(red(bold(("trace error: "))))
+
+		if len(f.Branches) > 0 {
+			str.WriteString(joinedMessage(f))
+		} else {
+			str.WriteString(t.Opts.message(f.Error))
+		}
+		str.WriteRune('\n')
+
+		// File and line numbers
+		str.WriteString(indent)
+		str.WriteString(t.Opts.path(f.Source))
+		str.WriteRune(':')
+		str.WriteString(strconv.Itoa(f.Line))
+
+		if ctx := frameContext(f); ctx != "" {
+			str.WriteString(" (")
+			str.WriteString(ctx)
+			str.WriteRune(')')
+		}
+
+		// Function
+		hasCause := i+1 < len(frames)
+		if !t.Opts.HideFunctions || hasCause {
+			str.WriteRune('\n')
+			str.WriteString(indent)
+			str.WriteString(indent)
+		}
+
+		if !t.Opts.HideFunctions {
+			str.WriteString(f.Function)
+			str.WriteString("()")
+			if hasCause {
+				str.WriteRune(' ')
+			}
+		}
+
+		if hasCause {
+			str.WriteString("-> ")
+			if len(frames[i+1].Branches) > 0 {
+				str.WriteString(joinedMessage(frames[i+1]))
+			} else {
+				str.WriteString(t.Opts.message(frames[i+1].Error))
+			}
+		}
+
+		var snippet []snippetLine
+		if t.Snippets {
+			snippet = sourceSnippet(f.Source, f.Line)
+		}
+
+		width := 0
+		if len(snippet) > 0 {
+			width = len(strconv.Itoa(snippet[len(snippet)-1].Number))
+		}
+
+		for _, sl := range snippet {
+			marker := "  "
+			if sl.Highlight {
+				marker = "> "
+			}
+
+			line := marker + fmt.Sprintf("%*d", width, sl.Number) + " | " + sl.Text
+			if sl.Highlight {
+				line = red(line)
+			}
+
+			str.WriteRune('\n')
+			str.WriteString(indent)
+			str.WriteString(indent)
+			str.WriteString(line)
+		}
+
+		for _, sf := range f.Stack {
+			str.WriteRune('\n')
+			str.WriteString(indent)
+			str.WriteString(t.Opts.path(sf.Source))
+			str.WriteRune(':')
+			str.WriteString(strconv.Itoa(sf.Line))
+			if !t.Opts.HideFunctions {
+				str.WriteRune('\n')
+				str.WriteString(indent)
+				str.WriteString(indent)
+				str.WriteString(sf.Function)
+				str.WriteString("()")
+			}
+		}
+
+		if hasCause || len(snippet) > 0 || len(f.Stack) > 0 || len(f.Branches) > 0 {
+			str.WriteRune('\n')
+		}
+
+		// each branch is rendered as an indented trace of its own
+		for j, branch := range f.Branches {
+			str.WriteString(indent)
+			str.WriteString(bold("[" + strconv.Itoa(j+1) + "]"))
+			str.WriteRune('\n')
+
+			lines := strings.Split(strings.TrimSuffix(t.Format(branch), "\n"), "\n")
+			for _, line := range lines {
+				str.WriteString(indent)
+				str.WriteString(indent)
+				str.WriteString(line)
+				str.WriteRune('\n')
+			}
+		}
+	}
+
+	if len(frames) > 0 && frames[0].Build != nil {
+		if !strings.HasSuffix(str.String(), "\n") {
+			str.WriteRune('\n')
+		}
+		str.WriteString(bold("build: "))
+		str.WriteString(frames[0].Build.String())
+	}
+
+	return str.String()
+}
+
+// frameContext returns the goroutine and pprof labels of f, e.g.
+// "goroutine 18, worker=3", or an empty string if none were recorded.
+func frameContext(f Frame) string {
+	var parts []string
+	if f.Goroutine != 0 {
+		parts = append(parts, "goroutine "+strconv.FormatUint(f.Goroutine, 10))
+	}
+
+	keys := make([]string, 0, len(f.Labels))
+	for k := range f.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		parts = append(parts, k+"="+f.Labels[k])
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+func joinedMessage(f Frame) string {
+	return "joined " + strconv.Itoa(len(f.Branches)) + " errors"
+}
+
+// CompactFormatter renders the error chain on a single line, which is useful for
+// line based log formats. Like the TextFormatter, plain errors directly wrapped by a
+// traceable error are omitted as their message is already part of the chain.
+//
+// Example output:
+//
+//	error creating user [cmd/cli/main.go:22 main.ServiceNewUser] <- error writing to database [cmd/cli/main.go:12 main.CreateUser] <- user with id 1 already exists
+type CompactFormatter struct{}
+
+func (c CompactFormatter) Format(frames []Frame) string {
+	parts := make([]string, 0, len(frames))
+	lastWasTraceable := false
+
+	for _, f := range frames {
+		if f.isPlain() {
+			if !lastWasTraceable || f.Omitted > 0 {
+				parts = append(parts, f.Error)
+			}
+
+			lastWasTraceable = false
+			continue
+		}
+
+		lastWasTraceable = true
+
+		// errors created without a location because of sampling
+		if !f.IsTraceable() {
+			parts = append(parts, f.Error)
+			continue
+		}
+
+		if len(f.Branches) > 0 {
+			branches := make([]string, len(f.Branches))
+			for i, branch := range f.Branches {
+				branches[i] = c.Format(branch)
+			}
+
+			parts = append(parts, joinedMessage(f)+" ["+f.Source+":"+strconv.Itoa(f.Line)+" "+trimFuncName(f.Function)+"] ("+strings.Join(branches, " | ")+")")
+			continue
+		}
+
+		parts = append(parts, f.Error+" ["+f.Source+":"+strconv.Itoa(f.Line)+" "+trimFuncName(f.Function)+"]")
+	}
+
+	if len(frames) > 0 && frames[0].Build != nil {
+		return strings.Join(parts, " <- ") + " [build " + frames[0].Build.String() + "]"
+	}
+
+	return strings.Join(parts, " <- ")
+}
+
+// JSONFormatter renders the frames as a JSON array, the same output as MarshalJSON.
+type JSONFormatter struct{}
+
+func (JSONFormatter) Format(frames []Frame) string {
+	b, err := json.Marshal(frames)
+	if err != nil {
+		return "[]"
+	}
+
+	return string(b)
+}
+
+// CloudErrorFormatter renders the frames in the format Google Cloud Error Reporting
+// parses for Go: the error message followed by a goroutine style stack trace, as
+// printed by runtime.Stack. Logging the output as the message of an entry with
+// severity ERROR lets Error Reporting group errors from GKE and Cloud Run services
+// automatically.
+//
+// The stack is taken from the root traceable error. If it was created with NewStack
+// or WrapStack the full captured call stack is used, otherwise the locations of all
+// traceable errors in the chain are listed from the innermost to the outermost.
+//
+// Example output:
+//
+//	error creating user: error writing to database: user with id 1 already exists
+//
+//	goroutine 1 [running]:
+//	main.CreateUser()
+//		cmd/cli/main.go:12
+//	main.ServiceNewUser()
+//		cmd/cli/main.go:22
+type CloudErrorFormatter struct{}
+
+func (CloudErrorFormatter) Format(frames []Frame) string {
+	var traced []Frame
+	for _, f := range frames {
+		if f.IsTraceable() {
+			traced = append(traced, f)
+		}
+	}
+
+	str := strings.Builder{}
+	str.WriteString(chainMessage(frames))
+
+	if len(traced) == 0 {
+		return str.String()
+	}
+
+	var calls []Frame
+	if root := traced[len(traced)-1]; len(root.Stack) > 0 {
+		calls = append([]Frame{root}, root.Stack...)
+	} else {
+		for i := len(traced) - 1; i >= 0; i-- {
+			calls = append(calls, traced[i])
+		}
+	}
+
+	str.WriteString("\n\ngoroutine 1 [running]:\n")
+	for _, f := range calls {
+		str.WriteString(f.Function)
+		str.WriteString("()\n\t")
+		str.WriteString(f.Source)
+		str.WriteRune(':')
+		str.WriteString(strconv.Itoa(f.Line))
+		str.WriteRune('\n')
+	}
+
+	return str.String()
+}
+
+// chainMessage returns the message of the complete chain, the same as the Error
+// method of a fmt.Errorf chain, e.g. "creating user: writing to database: timeout".
+func chainMessage(frames []Frame) string {
+	messages := make([]string, 0, len(frames))
+	for _, f := range frames {
+		messages = append(messages, f.Error)
+
+		// the message of a plain error already contains the rest of the chain
+		if f.isPlain() {
+			break
+		}
+	}
+
+	return strings.Join(messages, ": ")
+}
+
+// LogfmtFormatter renders the error chain as logfmt key/value pairs on a single
+// line, for log pipelines that parse logfmt but not multi-line or JSON traces. The
+// message of the chain is written to msg and every error to its own frameN key.
+// Errors aggregated with Join are written to frameN.bM.frameK keys.
+//
+// Example output:
+//
+//	level=error msg="creating user: writing to database" frame0="creating user main.go:22 main.ServiceNewUser" frame1="writing to database main.go:12 main.CreateUser"
+type LogfmtFormatter struct{}
+
+func (LogfmtFormatter) Format(frames []Frame) string {
+	str := strings.Builder{}
+	str.WriteString("level=error msg=")
+	str.WriteString(logfmtValue(chainMessage(frames)))
+	writeLogfmtFrames(&str, "", frames)
+
+	if len(frames) > 0 && frames[0].Build != nil {
+		b := frames[0].Build
+		for _, kv := range [][2]string{{"build.module", b.Module}, {"build.version", b.Version}, {"build.revision", b.Revision}, {"build.time", b.Time}} {
+			if kv[1] != "" {
+				str.WriteString(" " + kv[0] + "=" + logfmtValue(kv[1]))
+			}
+		}
+	}
+
+	return str.String()
+}
+
+func writeLogfmtFrames(str *strings.Builder, prefix string, frames []Frame) {
+	for i, f := range frames {
+		key := prefix + "frame" + strconv.Itoa(i)
+
+		value := f.Error
+		if len(f.Branches) > 0 {
+			value = joinedMessage(f)
+		}
+		if f.IsTraceable() {
+			value += " " + f.Source + ":" + strconv.Itoa(f.Line) + " " + trimFuncName(f.Function)
+		}
+
+		str.WriteRune(' ')
+		str.WriteString(key)
+		str.WriteRune('=')
+		str.WriteString(logfmtValue(value))
+
+		for j, branch := range f.Branches {
+			writeLogfmtFrames(str, key+".b"+strconv.Itoa(j)+".", branch)
+		}
+	}
+}
+
+// logfmtValue quotes v if it contains characters that are not allowed in a bare
+// logfmt value.
+func logfmtValue(v string) string {
+	if v != "" && !strings.ContainsAny(v, " =\"\t\r\n\\") {
+		return v
+	}
+
+	return strconv.Quote(v)
+}
+
+// Logfmt returns the error chain of err as logfmt key/value pairs, see
+// LogfmtFormatter.
+func Logfmt(err error) string {
+	return TraceStringWith(err, LogfmtFormatter{})
+}
@@ -0,0 +1,162 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFormatters(t *testing.T) {
+	frames := []Frame{
+		{Error: "wrap 2", Source: "main.go", Line: 42, Function: "github.com/test/pkg.Handler"},
+		{Error: "wrap: root error"},
+		{Error: "root error", Source: "main.go", Line: 10, Function: "github.com/test/pkg.Repo"},
+	}
+
+	tests := []struct {
+		name      string
+		formatter Formatter
+		want      string
+	}{
+		{
+			name:      "text",
+			formatter: TextFormatter{},
+			want: "trace error: wrap 2\n" +
+				"    main.go:42\n" +
+				"        github.com/test/pkg.Handler() -> wrap: root error\n" +
+				"trace error: root error\n" +
+				"    main.go:10\n" +
+				"        github.com/test/pkg.Repo()",
+		},
+		{
+			name:      "compact",
+			formatter: CompactFormatter{},
+			want:      "wrap 2 [main.go:42 Handler] <- root error [main.go:10 Repo]",
+		},
+		{
+			name:      "cloud error",
+			formatter: CloudErrorFormatter{},
+			want: "wrap 2: wrap: root error\n\n" +
+				"goroutine 1 [running]:\n" +
+				"github.com/test/pkg.Repo()\n\tmain.go:10\n" +
+				"github.com/test/pkg.Handler()\n\tmain.go:42\n",
+		},
+		{
+			name:      "logfmt",
+			formatter: LogfmtFormatter{},
+			want:      `level=error msg="wrap 2: wrap: root error" frame0="wrap 2 main.go:42 Handler" frame1="wrap: root error" frame2="root error main.go:10 Repo"`,
+		},
+		{
+			name:      "json",
+			formatter: JSONFormatter{},
+			want:      `[{"error":"wrap 2","source":"main.go","line":42,"func":"github.com/test/pkg.Handler"},{"error":"wrap: root error"},{"error":"root error","source":"main.go","line":10,"func":"github.com/test/pkg.Repo"}]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.formatter.Format(frames)
+			if got != tt.want {
+				t.Errorf("Format() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFormatter(t *testing.T) {
+	defer SetFormatter(nil)
+
+	err := fmt.Errorf("outer: %w", Wrapf(errors.New("root"), "wrapped"))
+
+	SetFormatter(CompactFormatter{})
+	if strings.Contains(TraceString(err), "\n") {
+		t.Errorf("expected single line output, got %q", TraceString(err))
+	}
+
+	if !strings.HasPrefix(TraceStringWith(err, JSONFormatter{}), "[") {
+		t.Errorf("expected JSON output, got %q", TraceStringWith(err, JSONFormatter{}))
+	}
+}
+
+func TestFmtFormatter(t *testing.T) {
+	err := Wrapf(errors.New("root"), "wrapped")
+
+	if got := fmt.Sprintf("%v", err); got != "wrapped" {
+		t.Errorf("%%v = %q, want %q", got, "wrapped")
+	}
+
+	if got := fmt.Sprintf("%s", err); got != "wrapped" {
+		t.Errorf("%%s = %q, want %q", got, "wrapped")
+	}
+
+	if got := fmt.Sprintf("%q", err); got != `"wrapped"` {
+		t.Errorf("%%q = %q, want %q", got, `"wrapped"`)
+	}
+
+	if got := fmt.Sprintf("%+v", err); got != TraceString(err) {
+		t.Errorf("%%+v = %q, want the full trace", got)
+	}
+
+	joined := Join(New("a"), New("b"))
+	if got := fmt.Sprintf("%+v", joined); !strings.Contains(got, "joined 2 errors") {
+		t.Errorf("%%+v of joined error = %q, want the full trace", got)
+	}
+}
+
+func TestTraceStringOpts(t *testing.T) {
+	frames := []Frame{
+		{Error: "wrap 2", Source: "/src/app/main.go", Line: 42, Function: "github.com/test/pkg.Handler"},
+		{Error: "a very long root error message", Source: "/src/app/repo.go", Line: 10, Function: "github.com/test/pkg.Repo"},
+	}
+
+	tests := []struct {
+		name string
+		opts TraceStringOpts
+		want string
+	}{
+		{
+			name: "indent and base paths",
+			opts: TraceStringOpts{Indent: "  ", Paths: PathBase},
+			want: "trace error: wrap 2\n" +
+				"  main.go:42\n" +
+				"    github.com/test/pkg.Handler() -> a very long root error message\n" +
+				"trace error: a very long root error message\n" +
+				"  repo.go:10\n" +
+				"    github.com/test/pkg.Repo()",
+		},
+		{
+			name: "hidden functions and max width",
+			opts: TraceStringOpts{HideFunctions: true, MaxMessageWidth: 11},
+			want: "trace error: wrap 2\n" +
+				"    /src/app/main.go:42\n" +
+				"        -> a very long…\n" +
+				"trace error: a very long…\n" +
+				"    /src/app/repo.go:10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TextFormatter{Opts: tt.opts}.Format(frames)
+			if got != tt.want {
+				t.Errorf("Format() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if got := (TraceStringOpts{Paths: PathRelative}).path("relative/file.go"); got != "relative/file.go" {
+		t.Errorf("expected relative paths to be unchanged, got %q", got)
+	}
+}
+
+func TestLogfmt(t *testing.T) {
+	err := Join(errors.New("a"), errors.New("b=c"))
+
+	got := Logfmt(err)
+	for _, want := range []string{`level=error msg="a\nb=c"`, `frame0="joined 2 errors `, ` frame0.b0.frame0=a`, ` frame0.b1.frame0="b=c"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in %s", want, got)
+		}
+	}
+}
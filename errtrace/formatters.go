@@ -2,6 +2,9 @@ package errtrace
 
 import (
 	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
 	"strings"
 )
 
@@ -9,38 +12,182 @@ import (
 // for the Annotate and Traceable function. The default cleaner removes the current
 // working directory from the file path. Use this to override that behavior, for
 // example to use the full file path.
+//
+// It is only used when no Cleaner is set in the Config of the default Tracer, and is
+// read without synchronization whenever a path is cleaned, so it must only be set
+// during program initialization.
+//
+// Deprecated: Setting a variable is racy when done after errors were created, set
+// the Cleaner of the default Tracer with Default().Configure instead.
 var OverrideCleaner func(path string) string
 
+// cleanGoPath cleans path with the cleaner of the default tracer.
 func cleanGoPath(path string) string {
-	// clean full file path to relative path
-	if OverrideCleaner != nil {
-		return OverrideCleaner(path)
-	}
-
-	return path
+	return defaultTracer.clean(path)
 }
 
 // RelativeCleaner returns a function that can be used to clean the file path
 // to a relative path. The returned function will remove the current working
 // directory from the file path.
 //
-// If the current working directory cannot be determined, the returned function
-// will fall back to the default path cleaner.
+// If the current working directory cannot be determined, or the path is not within
+// it, the path is returned unchanged.
 //
 // Example:
 //
-//	errtrace.OverrideCleaner = errtrace.RelativeCleaner()
+//	errtrace.Default().Configure(func(c *errtrace.Config) {
+//	  c.Cleaner = errtrace.RelativeCleaner()
+//	})
 func RelativeCleaner() func(path string) string {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return cleanGoPath
+		return func(path string) string { return path }
 	}
 
 	return func(path string) string {
-		if strings.HasPrefix(path, cwd) {
+		if strings.HasPrefix(path, cwd+"/") {
 			return path[len(cwd)+1:]
 		}
-		return cleanGoPath(path)
+		return path
+	}
+}
+
+// TrimModuleCleaner returns a function that can be used to clean the file path to the
+// import path of the package the file belongs to, followed by the file name. It uses
+// debug.ReadBuildInfo and the Go environment to strip
+//
+//   - the directory of the main module, replaced with its module path
+//   - module cache prefixes and versions, e.g.
+//     /home/x/go/pkg/mod/github.com/org/lib@v1.2.3/pkg/file.go becomes
+//     github.com/org/lib/pkg/file.go
+//   - GOPATH and GOROOT source directories
+//   - vendor directories
+//
+// The directory of the main module is detected from the file of main.main, so the
+// cleaner should be created from within main. Paths that do not match any of the
+// rules are returned unchanged.
+//
+// Example:
+//
+//	func main() {
+//	  errtrace.Default().Configure(func(c *errtrace.Config) {
+//	    c.Cleaner = errtrace.TrimModuleCleaner()
+//	  })
+//	}
+func TrimModuleCleaner() func(path string) string {
+	var rules []trimRule
+
+	if root, module := mainModuleRoot(); root != "" {
+		rules = append(rules, trimRule{prefix: root + "/", replace: module + "/"})
+	}
+
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			gopath = filepath.Join(home, "go")
+		}
+	}
+
+	if modcache := os.Getenv("GOMODCACHE"); modcache != "" {
+		rules = append(rules, trimRule{prefix: filepath.ToSlash(modcache) + "/"})
+	}
+
+	for _, dir := range filepath.SplitList(gopath) {
+		dir = filepath.ToSlash(dir)
+		rules = append(rules, trimRule{prefix: dir + "/pkg/mod/"}, trimRule{prefix: dir + "/src/"})
+	}
+
+	if goroot := runtime.GOROOT(); goroot != "" {
+		rules = append(rules, trimRule{prefix: filepath.ToSlash(goroot) + "/src/"})
+	}
+
+	return trimModuleCleaner(rules)
+}
+
+// trimRule replaces prefix with replace in a file path.
+type trimRule struct {
+	prefix  string
+	replace string
+}
+
+func trimModuleCleaner(rules []trimRule) func(path string) string {
+	return func(path string) string {
+		if i := strings.LastIndex(path, "/vendor/"); i >= 0 {
+			return path[i+len("/vendor/"):]
+		}
+
+		for _, r := range rules {
+			if strings.HasPrefix(path, r.prefix) {
+				return trimModuleVersion(r.replace + path[len(r.prefix):])
+			}
+		}
+
+		// binaries built with -trimpath record module@version/file.go
+		if !filepath.IsAbs(path) {
+			return trimModuleVersion(path)
+		}
+
+		return path
+	}
+}
+
+// trimModuleVersion removes the version from a module cache path and decodes the
+// case encoding of the module cache, e.g. github.com/!org/lib@v1.2.3/file.go becomes
+// github.com/Org/lib/file.go.
+func trimModuleVersion(path string) string {
+	at := strings.IndexByte(path, '@')
+	if at < 0 {
+		return path
+	}
+
+	end := strings.IndexByte(path[at:], '/')
+	if end < 0 {
+		return path
+	}
+
+	module := path[:at]
+	if strings.IndexByte(module, '!') >= 0 {
+		var b strings.Builder
+		for i := 0; i < len(module); i++ {
+			if module[i] == '!' && i+1 < len(module) {
+				i++
+				b.WriteString(strings.ToUpper(module[i : i+1]))
+				continue
+			}
+			b.WriteByte(module[i])
+		}
+		module = b.String()
+	}
+
+	return module + path[at+end:]
+}
+
+// mainModuleRoot returns the directory and path of the main module. The directory is
+// derived from the file of main.main and the import path of the main package.
+func mainModuleRoot() (root, module string) {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Path == "" || !strings.HasPrefix(info.Path, info.Main.Path) {
+		return "", ""
+	}
+
+	pcs := make([]uintptr, maxStackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs)])
+	for {
+		f, more := frames.Next()
+		if f.Function == "main.main" {
+			// the directory of the main package relative to the module root
+			rel := strings.TrimPrefix(info.Path, info.Main.Path)
+			dir := filepath.ToSlash(filepath.Dir(f.File))
+			if !strings.HasSuffix(dir, rel) {
+				return "", ""
+			}
+
+			return strings.TrimSuffix(dir, rel), info.Main.Path
+		}
+
+		if !more {
+			return "", ""
+		}
 	}
 }
 
@@ -39,3 +39,61 @@ func Test_trimFuncName(t *testing.T) {
 		})
 	}
 }
+
+func Test_trimModuleCleaner(t *testing.T) {
+	clean := trimModuleCleaner([]trimRule{
+		{prefix: "/src/app/", replace: "github.com/org/app/"},
+		{prefix: "/home/x/go/pkg/mod/"},
+		{prefix: "/usr/local/go/src/"},
+	})
+
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{
+			name: "main module",
+			path: "/src/app/internal/user.go",
+			want: "github.com/org/app/internal/user.go",
+		},
+		{
+			name: "module cache",
+			path: "/home/x/go/pkg/mod/github.com/org/lib@v1.2.3/pkg/file.go",
+			want: "github.com/org/lib/pkg/file.go",
+		},
+		{
+			name: "module cache case encoding",
+			path: "/home/x/go/pkg/mod/github.com/!burnt!sushi/toml@v1.3.2/decode.go",
+			want: "github.com/BurntSushi/toml/decode.go",
+		},
+		{
+			name: "goroot",
+			path: "/usr/local/go/src/net/http/server.go",
+			want: "net/http/server.go",
+		},
+		{
+			name: "vendor",
+			path: "/src/app/vendor/github.com/org/lib/file.go",
+			want: "github.com/org/lib/file.go",
+		},
+		{
+			name: "trimpath",
+			path: "github.com/org/lib@v1.2.3/file.go",
+			want: "github.com/org/lib/file.go",
+		},
+		{
+			name: "unknown",
+			path: "/opt/other/file.go",
+			want: "/opt/other/file.go",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clean(tt.path); got != tt.want {
+				t.Errorf("clean(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
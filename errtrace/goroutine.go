@@ -0,0 +1,98 @@
+package errtrace
+
+import (
+	"bytes"
+	"context"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+)
+
+// EnableGoroutineCapture records the ID of the goroutine a traceable error is created
+// on, so traces from concurrent pipelines can be correlated with goroutine dumps and
+// with the worker that produced them.
+//
+// Reading the goroutine ID requires formatting the header of the current goroutine's
+// stack, which adds a small cost to every traceable error that is created.
+func EnableGoroutineCapture() {
+	defaultTracer.Configure(func(c *Config) {
+		c.GoroutineCapture = true
+	})
+}
+
+// DisableGoroutineCapture stops recording goroutine IDs on traceable errors.
+func DisableGoroutineCapture() {
+	defaultTracer.Configure(func(c *Config) {
+		c.GoroutineCapture = false
+	})
+}
+
+// NewCtx is the same as New, but also records the pprof labels of ctx, as set with
+// pprof.Do or pprof.WithLabels, so the error can be correlated with runtime profiles.
+func NewCtx(ctx context.Context, msg string, args ...any) error {
+	return withLabels(ctx, defaultTracer.newTraceable(0, nil, msg, args...))
+}
+
+// WrapCtx is the same as Wrapf, but also records the pprof labels of ctx, as set with
+// pprof.Do or pprof.WithLabels, so the error can be correlated with runtime profiles.
+// If the error is nil, WrapCtx returns nil.
+//
+// Example:
+//
+//	pprof.Do(ctx, pprof.Labels("worker", id), func(ctx context.Context) {
+//	  if err := process(ctx, job); err != nil {
+//	    errs <- errtrace.WrapCtx(ctx, err, "processing job %s", job.ID)
+//	  }
+//	})
+func WrapCtx(ctx context.Context, err error, msg string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return withLabels(ctx, defaultTracer.newTraceable(0, err, msg, args...))
+}
+
+func withLabels(ctx context.Context, err error) error {
+	st := err.(*stacktrace) //nolint:errorlint
+
+	pprof.ForLabels(ctx, func(key, value string) bool {
+		if st.labels == nil {
+			st.labels = make(map[string]string)
+		}
+		st.labels[key] = value
+		return true
+	})
+
+	return st
+}
+
+// capture records the ID of the current goroutine on st when goroutine capture is
+// enabled for the Tracer.
+func (t *Tracer) capture(st *stacktrace) *stacktrace {
+	if t.cfg.Load().GoroutineCapture {
+		st.goroutine = goroutineID()
+	}
+
+	return st
+}
+
+var goroutinePrefix = []byte("goroutine ")
+
+// goroutineID returns the ID of the current goroutine parsed from the header of its
+// stack, e.g. "goroutine 18 [running]:". It returns 0 if the ID cannot be parsed.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+
+	b = bytes.TrimPrefix(b, goroutinePrefix)
+	if i := bytes.IndexByte(b, ' '); i > 0 {
+		b = b[:i]
+	}
+
+	id, err := strconv.ParseUint(string(b), 10, 64)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
@@ -0,0 +1,47 @@
+package errtrace
+
+import (
+	"context"
+	"errors"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+func TestGoroutineCapture(t *testing.T) {
+	if frames := chainFrames(New("boom")); frames[0].Goroutine != 0 {
+		t.Error("expected no goroutine ID when capture is disabled")
+	}
+
+	EnableGoroutineCapture()
+	defer DisableGoroutineCapture()
+
+	frames := chainFrames(New("boom"))
+	if frames[0].Goroutine == 0 {
+		t.Fatal("expected goroutine ID to be recorded")
+	}
+
+	if frames[0].Goroutine != goroutineID() {
+		t.Errorf("expected goroutine %d, got %d", goroutineID(), frames[0].Goroutine)
+	}
+}
+
+func TestWrapCtx(t *testing.T) {
+	if WrapCtx(context.Background(), nil, "noop") != nil {
+		t.Error("expected nil error")
+	}
+
+	var err error
+	pprof.Do(context.Background(), pprof.Labels("worker", "3", "job", "sync"), func(ctx context.Context) {
+		err = WrapCtx(ctx, errors.New("root"), "processing")
+	})
+
+	frames := chainFrames(err)
+	if frames[0].Labels["worker"] != "3" || frames[0].Labels["job"] != "sync" {
+		t.Errorf("expected pprof labels to be recorded, got %v", frames[0].Labels)
+	}
+
+	if str := TraceStringPlain(err); !strings.Contains(str, "(job=sync, worker=3)") {
+		t.Errorf("expected labels in trace, got:\n%s", str)
+	}
+}
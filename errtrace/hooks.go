@@ -0,0 +1,111 @@
+package errtrace
+
+type createHook struct {
+	fn func(*StackTraceData)
+}
+
+// OnCreate registers fn with the default Tracer to be called whenever a traceable
+// error is created, for example by New, Wrapf, NewStack or Recover. Use it to collect metrics on error
+// creation rates per package, to sample full stacks or to forward errors to an error
+// pipeline without changing the call sites. It returns a function that removes the
+// hook.
+//
+// Hooks are called synchronously on the goroutine creating the error, so they must be
+// fast and safe for concurrent use. While at least one hook is registered, the
+// message and location of every error are resolved when it is created instead of
+// lazily.
+//
+// Example:
+//
+//	errtrace.OnCreate(func(d *errtrace.StackTraceData) {
+//	  errorsCreated.WithLabelValues(d.Function).Inc()
+//	})
+func OnCreate(fn func(*StackTraceData)) (remove func()) {
+	return defaultTracer.OnCreate(fn)
+}
+
+// OnCreate registers fn to be called whenever a traceable error is created by the
+// Tracer, see the package level OnCreate. It returns a function that removes the
+// hook.
+func (t *Tracer) OnCreate(fn func(*StackTraceData)) (remove func()) {
+	hook := &createHook{fn: fn}
+
+	t.hooksMu.Lock()
+	defer t.hooksMu.Unlock()
+
+	var current []*createHook
+	if p := t.hooks.Load(); p != nil {
+		current = *p
+	}
+
+	next := append(append([]*createHook(nil), current...), hook)
+	t.hooks.Store(&next)
+
+	return func() {
+		t.hooksMu.Lock()
+		defer t.hooksMu.Unlock()
+
+		current := *t.hooks.Load()
+		next := make([]*createHook, 0, len(current))
+		for _, h := range current {
+			if h != hook {
+				next = append(next, h)
+			}
+		}
+		t.hooks.Store(&next)
+	}
+}
+
+// notifyCreate calls the hooks registered with OnCreate for st and returns st.
+func (t *Tracer) notifyCreate(st *stacktrace) *stacktrace {
+	hooks := t.createHooks()
+	if len(hooks) == 0 {
+		return st
+	}
+
+	file, function, line := st.location()
+	runHooks(hooks, &StackTraceData{
+		Message:   st.Error(),
+		File:      t.clean(file),
+		Function:  function,
+		Line:      line,
+		Cause:     st.cause,
+		Traceable: true,
+	})
+
+	return st
+}
+
+// notifyJoin is the same as notifyCreate for errors created by Join and Append.
+func (t *Tracer) notifyJoin(je *joinError) *joinError {
+	hooks := t.createHooks()
+	if len(hooks) == 0 {
+		return je
+	}
+
+	runHooks(hooks, &StackTraceData{
+		Message:   je.Error(),
+		File:      t.clean(je.file),
+		Function:  je.function,
+		Line:      je.line,
+		Traceable: true,
+	})
+
+	return je
+}
+
+// createHooks returns the registered OnCreate hooks.
+func (t *Tracer) createHooks() []*createHook {
+	p := t.hooks.Load()
+	if p == nil {
+		return nil
+	}
+
+	return *p
+}
+
+func runHooks(hooks []*createHook, data *StackTraceData) {
+	for _, h := range hooks {
+		h.fn(data)
+	}
+}
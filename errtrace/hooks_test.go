@@ -0,0 +1,37 @@
+package errtrace
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestOnCreate(t *testing.T) {
+	var created []*StackTraceData
+	remove := OnCreate(func(d *StackTraceData) {
+		created = append(created, d)
+	})
+
+	root := errors.New("root")
+	_ = Wrapf(root, "wrap %d", 1)
+	_ = NewStack("stack")
+	_ = Join(root, errors.New("other"))
+
+	if len(created) != 3 {
+		t.Fatalf("expected 3 hook calls, got %d", len(created))
+	}
+
+	if created[2].Message != "root\nother" || created[2].Function != "github.com/hay-kot/httpkit/errtrace.TestOnCreate" {
+		t.Errorf("unexpected join data %+v", created[2])
+	}
+
+	if created[0].Message != "wrap 1" || created[0].Cause != root || created[0].Function != "github.com/hay-kot/httpkit/errtrace.TestOnCreate" {
+		t.Errorf("unexpected data %+v", created[0])
+	}
+
+	remove()
+	_ = New("after remove")
+
+	if len(created) != 3 {
+		t.Errorf("expected hook to be removed, got %d calls", len(created))
+	}
+}
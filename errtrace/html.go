@@ -0,0 +1,209 @@
+package errtrace
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/server"
+)
+
+// htmlFrame is the view model of a Frame used by the HTML template.
+type htmlFrame struct {
+	Frame
+	Snippet  []snippetLine
+	Stack    []htmlFrame
+	Branches [][]htmlFrame
+}
+
+func toHTMLFrames(frames []Frame, snippets bool) []htmlFrame {
+	out := make([]htmlFrame, len(frames))
+	for i, f := range frames {
+		out[i] = htmlFrame{Frame: f, Stack: toHTMLFrames(f.Stack, false)}
+
+		if snippets {
+			out[i].Snippet = sourceSnippet(f.Source, f.Line)
+		}
+
+		for _, branch := range f.Branches {
+			out[i].Branches = append(out[i].Branches, toHTMLFrames(branch, snippets))
+		}
+	}
+
+	return out
+}
+
+var htmlTemplate = template.Must(template.New("errtrace").Funcs(template.FuncMap{
+	"itoa": strconv.Itoa,
+	"inc":  func(i int) int { return i + 1 },
+}).Parse(`
+{{- define "frames" -}}
+<ol class="errtrace-chain">
+{{- range . }}
+  <li class="errtrace-frame{{ if not .IsTraceable }} errtrace-plain{{ end }}">
+    <div class="errtrace-message">{{ .Error }}</div>
+    {{- if .IsTraceable }}
+    <div class="errtrace-location"><code>{{ .Source }}:{{ itoa .Line }}</code> <code class="errtrace-func">{{ .Function }}()</code></div>
+    {{- end }}
+    {{- if .Snippet }}
+    <pre class="errtrace-snippet">
+      {{- range .Snippet }}<span class="{{ if .Highlight }}errtrace-highlight{{ end }}">{{ printf "%4d" .Number }} | {{ .Text }}</span>
+{{ end -}}
+    </pre>
+    {{- end }}
+    {{- if .Stack }}
+    <details class="errtrace-stack"><summary>call stack</summary>
+      <ul>
+      {{- range .Stack }}
+        <li><code>{{ .Source }}:{{ itoa .Line }}</code> <code class="errtrace-func">{{ .Function }}()</code></li>
+      {{- end }}
+      </ul>
+    </details>
+    {{- end }}
+    {{- range $i, $branch := .Branches }}
+    <div class="errtrace-branch">
+      <div class="errtrace-branch-title">branch {{ itoa (inc $i) }}</div>
+      {{ template "frames" $branch }}
+    </div>
+    {{- end }}
+  </li>
+{{- end }}
+</ol>
+{{- end -}}
+
+{{- define "fragment" -}}
+<div class="errtrace">
+<style>
+.errtrace { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; color: #1f2328; }
+.errtrace code, .errtrace pre { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 13px; }
+.errtrace-chain { list-style: none; padding-left: 0; }
+.errtrace-frame { border-left: 4px solid #cf222e; background: #fff; margin: 0 0 12px; padding: 8px 12px; }
+.errtrace-plain { border-left-color: #8c959f; }
+.errtrace-message { font-weight: 600; margin-bottom: 4px; }
+.errtrace-location { color: #57606a; }
+.errtrace-func { color: #0550ae; }
+.errtrace-snippet { background: #f6f8fa; padding: 8px; overflow-x: auto; }
+.errtrace-highlight { display: block; background: #ffebe9; color: #cf222e; }
+.errtrace-branch { margin-left: 16px; }
+.errtrace-branch-title { color: #57606a; font-size: 12px; text-transform: uppercase; }
+</style>
+{{ template "frames" . }}
+</div>
+{{- end -}}
+
+{{- define "page" -}}
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{ .Title }}</title>
+</head>
+<body style="background: #f6f8fa; margin: 0; padding: 24px;">
+<h1 style="font-family: sans-serif; color: #cf222e;">{{ .Title }}</h1>
+{{ .Body }}
+</body>
+</html>
+{{- end -}}
+`))
+
+// HTML renders the error chain of err as a styled HTML fragment with the message,
+// location and captured call stack of every error. Source code snippets are included
+// when they are enabled with EnableSnippets.
+//
+// The output exposes file paths and source code and is intended for local development
+// only. Never serve it to users in production.
+func HTML(err error) template.HTML {
+	if err == nil {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if e := htmlTemplate.ExecuteTemplate(&buf, "fragment", toHTMLFrames(chainFrames(err), defaultTracer.Config().Snippets)); e != nil {
+		return template.HTML(template.HTMLEscapeString(TraceStringPlain(err))) //nolint:gosec
+	}
+
+	return template.HTML(buf.String()) //nolint:gosec
+}
+
+// WriteHTML writes a complete developer error page for err with the given status
+// code to w. See HTML for the contents of the page.
+//
+// Example:
+//
+//	if isDev {
+//	  errtrace.WriteHTML(w, http.StatusInternalServerError, err)
+//	  return
+//	}
+func WriteHTML(w http.ResponseWriter, status int, err error) {
+	var buf bytes.Buffer
+	e := htmlTemplate.ExecuteTemplate(&buf, "page", struct {
+		Title string
+		Body  template.HTML
+	}{
+		Title: strconv.Itoa(status) + " " + http.StatusText(status),
+		Body:  HTML(err),
+	})
+	if e != nil {
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// HTMLMiddleware returns an errchain.Middleware that serves the developer error page
+// of WriteHTML to browsers. Panics in the next handler are converted into errors with
+// Recover. When the request accepts text/html, errors are written with WriteHTML using
+// the status from Status, or 500, and are not passed on. Otherwise errors are
+// returned to the error chain unchanged.
+//
+// Errors returned by server.ErrorBuilder.Write have already been written to the
+// response and are always returned unchanged.
+//
+// Like WriteHTML, the middleware is intended for local development only.
+//
+// Example:
+//
+//	if isDev {
+//	  chain.Use(errtrace.HTMLMiddleware())
+//	}
+func HTMLMiddleware() errchain.Middleware {
+	return func(next errchain.Handler) errchain.Handler {
+		return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) (err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					err = newPanicError(v)
+				}
+
+				if err == nil || server.IsResponseError(err) || !acceptsHTML(r) {
+					return
+				}
+
+				status, ok := Status(err)
+				if !ok {
+					status = http.StatusInternalServerError
+				}
+
+				WriteHTML(w, status, err)
+				err = nil
+			}()
+
+			return next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func acceptsHTML(r *http.Request) bool {
+	for _, v := range r.Header.Values("Accept") {
+		if strings.Contains(v, "text/html") {
+			return true
+		}
+	}
+
+	return false
+}
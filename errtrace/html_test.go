@@ -0,0 +1,93 @@
+package errtrace
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+func TestHTML(t *testing.T) {
+	if HTML(nil) != "" {
+		t.Error("expected empty output for nil error")
+	}
+
+	err := Wrapf(errors.New("<script>alert(1)</script>"), "query failed")
+
+	got := string(HTML(err))
+	for _, want := range []string{"query failed", "html_test.go:", "errtrace.TestHTML()", "&lt;script&gt;"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, "<script>alert") {
+		t.Error("expected error messages to be escaped")
+	}
+
+	EnableSnippets()
+	defer DisableSnippets()
+
+	if !strings.Contains(string(HTML(err)), "errtrace-highlight") {
+		t.Error("expected snippet in output when snippets are enabled")
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	rec := httptest.NewRecorder()
+	WriteHTML(rec, http.StatusInternalServerError, New("boom"))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected status 500, got %d", rec.Code)
+	}
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, "<title>500 Internal Server Error</title>") || !strings.Contains(body, "boom") {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+}
+
+func TestHTMLMiddleware(t *testing.T) {
+	h := HTMLMiddleware()(errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		switch r.URL.Path {
+		case "/panic":
+			panic("boom")
+		case "/missing":
+			return WrapStatus(errors.New("no rows"), http.StatusNotFound, "user not found")
+		default:
+			return nil
+		}
+	}))
+
+	serve := func(path, accept string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept", accept)
+
+		rec := httptest.NewRecorder()
+		return rec, h.ServeHTTP(rec, req)
+	}
+
+	rec, err := serve("/panic", "text/html,application/xhtml+xml")
+	if err != nil || rec.Code != http.StatusInternalServerError || !strings.Contains(rec.Body.String(), "recovered from panic") {
+		t.Errorf("expected panic page, got %d %v:\n%s", rec.Code, err, rec.Body.String())
+	}
+
+	rec, err = serve("/missing", "text/html")
+	if err != nil || rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "user not found") {
+		t.Errorf("expected 404 page, got %d %v", rec.Code, err)
+	}
+
+	// non browser requests are passed to the error chain
+	_, err = serve("/panic", "application/json")
+	var pe *PanicError
+	if !errors.As(err, &pe) {
+		t.Errorf("expected panic error, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package errtrace
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// joinError is a traceable error that aggregates multiple errors. Each branch keeps
+// its own trace.
+type joinError struct {
+	errs     []error
+	file     string
+	function string
+	line     int
+}
+
+// Error returns the messages of all branches separated by newlines, the same as
+// errors.Join.
+func (e *joinError) Error() string {
+	msgs := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		msgs[i] = err.Error()
+	}
+
+	return strings.Join(msgs, "\n")
+}
+
+func (e *joinError) Unwrap() []error {
+	return e.errs
+}
+
+// Format implements fmt.Formatter, see the Format method of traceable errors.
+func (e *joinError) Format(s fmt.State, verb rune) {
+	formatError(e, s, verb)
+}
+
+// Join returns an error that wraps the provided errors and records the location of
+// the caller. Nil errors are discarded and Join returns nil if every error is nil.
+// The result is compatible with errors.Join: errors.Is and errors.As inspect every
+// branch and Error returns the messages separated by newlines.
+//
+// TraceString and MarshalStack render each branch with its own trace. The location
+// is subject to SetSampling and the error is passed to the OnCreate hooks, the same
+// as errors created by New.
+//
+// Example:
+//
+//	var errs []error
+//	for _, item := range items {
+//	  errs = append(errs, process(item))
+//	}
+//	return errtrace.Join(errs...)
+func Join(errs ...error) error {
+	return defaultTracer.newJoin(0, nil, errs)
+}
+
+// Append appends errs to err. If err was created by Join or Append, the errors are
+// added to its branches and the original location is kept, otherwise a new joined
+// error is created at the location of the caller. Nil errors are discarded.
+//
+// Example:
+//
+//	var err error
+//	for _, item := range items {
+//	  err = errtrace.Append(err, process(item))
+//	}
+//	return err
+func Append(err error, errs ...error) error {
+	if je, ok := err.(*joinError); ok { //nolint:errorlint
+		return defaultTracer.newJoin(0, je, errs)
+	}
+
+	return defaultTracer.newJoin(0, nil, append([]error{err}, errs...))
+}
+
+// newJoin creates a joined error from base and errs. New joined errors record the
+// location of the caller skip frames above the caller of newJoin according to the
+// sample rate and are passed to the OnCreate hooks of the Tracer, the same as the
+// errors created by New.
+func (t *Tracer) newJoin(skip int, base *joinError, errs []error) error {
+	je := &joinError{}
+	if base != nil {
+		*je = *base
+		je.errs = append([]error(nil), base.errs...)
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			je.errs = append(je.errs, err)
+		}
+	}
+
+	if len(je.errs) == 0 {
+		return nil
+	}
+
+	if base != nil {
+		return je
+	}
+
+	if !sample(t.sampleRate()) {
+		return t.notifyJoin(je)
+	}
+
+	pc, file, line, ok := runtime.Caller(skip + 2)
+	if !ok {
+		return t.notifyJoin(je)
+	}
+
+	je.file = file
+	je.line = line
+
+	if fn := runtime.FuncForPC(pc); fn != nil {
+		je.function = fn.Name()
+	}
+
+	return t.notifyJoin(je)
+}
@@ -0,0 +1,62 @@
+package errtrace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestJoin(t *testing.T) {
+	sentinel := errors.New("sentinel")
+
+	if Join(nil, nil) != nil {
+		t.Error("expected Join of nil errors to be nil")
+	}
+
+	err := Join(New("first"), nil, Wrapf(sentinel, "second"))
+
+	if !errors.Is(err, sentinel) {
+		t.Error("expected errors.Is to find the sentinel in a branch")
+	}
+
+	if err.Error() != "first\nsecond" {
+		t.Errorf("expected errors.Join compatible message, got %q", err.Error())
+	}
+
+	frames := chainFrames(Wrapf(err, "batch failed"))
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(frames))
+	}
+
+	joined := frames[1]
+	if len(joined.Branches) != 2 || len(joined.Branches[1]) != 2 {
+		t.Fatalf("expected branches to keep their own frames, got %v", joined.Branches)
+	}
+
+	if joined.Function != "github.com/hay-kot/httpkit/errtrace.TestJoin" {
+		t.Errorf("expected join location to be recorded, got %s", joined.Function)
+	}
+
+	str := TraceStringWith(Wrapf(err, "batch failed"), TextFormatter{})
+	for _, want := range []string{"joined 2 errors", "    [1]\n", "        trace error: first", "        trace error: second"} {
+		if !strings.Contains(str, want) {
+			t.Errorf("expected trace to contain %q, got:\n%s", want, str)
+		}
+	}
+}
+
+func TestAppend(t *testing.T) {
+	var err error
+	for _, msg := range []string{"a", "b", "c"} {
+		err = Append(err, New(msg))
+	}
+
+	frames := chainFrames(err)
+	if len(frames) != 1 || len(frames[0].Branches) != 3 {
+		t.Fatalf("expected a single joined frame with 3 branches, got %v", frames)
+	}
+
+	if Append(nil, nil) != nil {
+		t.Error("expected Append of nil errors to be nil")
+	}
+}
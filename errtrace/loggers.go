@@ -0,0 +1,107 @@
+package errtrace
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// ZerologMarshalFunc is an error stack marshaler for github.com/rs/zerolog. It
+// returns the frames of err, or nil when the chain does not contain a traceable error
+// so that the stack field is omitted for plain errors.
+//
+// Example:
+//
+//	zerolog.ErrorStackMarshaler = errtrace.ZerologMarshalFunc
+//	log.Error().Stack().Err(err).Msg("request failed")
+func ZerologMarshalFunc(err error) interface{} {
+	if !IsTraceable(err) {
+		return nil
+	}
+
+	return chainFrames(err)
+}
+
+// ZapField returns a go.uber.org/zap field with the key "stack" containing the frames
+// of err. The frames are encoded without reflection using zap's array and object
+// marshalers.
+//
+// Example:
+//
+//	logger.Error("request failed", zap.Error(err), errtrace.ZapField(err))
+func ZapField(err error) zap.Field {
+	if err == nil {
+		return zap.Skip()
+	}
+
+	return zap.Array("stack", zapFrames(chainFrames(err)))
+}
+
+type zapFrames []Frame
+
+func (frames zapFrames) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for i := range frames {
+		if err := enc.AppendObject(zapFrame(frames[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type zapFrame Frame
+
+func (f zapFrame) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if f.Error != "" {
+		enc.AddString("error", f.Error)
+	}
+	if f.Source != "" {
+		enc.AddString("source", f.Source)
+	}
+	if f.Line != 0 {
+		enc.AddInt("line", f.Line)
+	}
+	if f.Function != "" {
+		enc.AddString("func", f.Function)
+	}
+	if f.Goroutine != 0 {
+		enc.AddUint64("goroutine", f.Goroutine)
+	}
+	if len(f.Labels) > 0 {
+		if err := enc.AddObject("labels", zapLabels(f.Labels)); err != nil {
+			return err
+		}
+	}
+	if len(f.Stack) > 0 {
+		if err := enc.AddArray("stack", zapFrames(f.Stack)); err != nil {
+			return err
+		}
+	}
+	if len(f.Branches) > 0 {
+		if err := enc.AddArray("branches", zapBranches(f.Branches)); err != nil {
+			return err
+		}
+	}
+	if f.Build != nil {
+		return enc.AddReflected("build", f.Build)
+	}
+	return nil
+}
+
+type zapBranches [][]Frame
+
+func (branches zapBranches) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, branch := range branches {
+		if err := enc.AppendArray(zapFrames(branch)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type zapLabels map[string]string
+
+func (labels zapLabels) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range labels {
+		enc.AddString(k, v)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package errtrace
+
+import (
+	"errors"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestZerologMarshalFunc(t *testing.T) {
+	if got := ZerologMarshalFunc(errors.New("plain")); got != nil {
+		t.Errorf("expected nil for non-traceable errors, got %v", got)
+	}
+
+	frames, ok := ZerologMarshalFunc(Wrapf(errors.New("plain"), "wrapped")).([]Frame)
+	if !ok || len(frames) != 2 {
+		t.Errorf("expected 2 frames, got %v", frames)
+	}
+}
+
+func TestZapField(t *testing.T) {
+	core, logs := observer.New(zapcore.ErrorLevel)
+	logger := zap.New(core)
+
+	err := Wrapf(errors.New("root error"), "wrapped")
+	logger.Error("failed", ZapField(err))
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+
+	stack, ok := entries[0].ContextMap()["stack"].([]interface{})
+	if !ok || len(stack) != 2 {
+		t.Fatalf("expected stack with 2 frames, got %v", entries[0].ContextMap()["stack"])
+	}
+
+	first := stack[0].(map[string]interface{})
+	if first["error"] != "wrapped" || first["func"] != "github.com/hay-kot/httpkit/errtrace.TestZapField" {
+		t.Errorf("unexpected first frame %v", first)
+	}
+}
@@ -0,0 +1,69 @@
+package errtrace
+
+import (
+	"net/http"
+	"reflect"
+	"runtime"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+// Middleware returns an errchain.Middleware that wraps every non-nil error returned by
+// the next handler in a traceable error. The message contains the route pattern of
+// the request and the location is the function of the next handler, so errors created
+// with errors.New or fmt.Errorf can be located in logs without changing the handlers.
+//
+// If skipAlreadyTraceable is true, errors that already contain a traceable error are
+// returned unchanged.
+//
+// The location can only be resolved when next is an errchain.HandlerFunc. Register
+// the middleware as the last middleware of a route so next is the route handler,
+// otherwise the error is wrapped without a location.
+//
+// Example:
+//
+//	mux.Get("/users/{id}", getUser, errtrace.Middleware(true))
+func Middleware(skipAlreadyTraceable bool) errchain.Middleware {
+	return func(next errchain.Handler) errchain.Handler {
+		pc := handlerPC(next)
+
+		return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			err := next.ServeHTTP(w, r)
+			if err == nil {
+				return nil
+			}
+
+			if skipAlreadyTraceable && IsTraceable(err) {
+				return err
+			}
+
+			route := errchain.RoutePattern(r)
+			if route == "" {
+				route = r.Method + " " + r.URL.Path
+			}
+
+			st := defaultTracer.capture(&stacktrace{cause: err, msg: "handler " + route})
+			st.pc = pc
+
+			return defaultTracer.notifyCreate(st)
+		})
+	}
+}
+
+// handlerPC returns a program counter within the function of h, or 0 if h is not a
+// function handler.
+func handlerPC(h errchain.Handler) uintptr {
+	fn, ok := h.(errchain.HandlerFunc)
+	if !ok {
+		return 0
+	}
+
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return 0
+	}
+
+	// CallersFrames expects return addresses and resolves pc-1, so point one byte
+	// past the entry of the function to resolve to its first line.
+	return f.Entry() + 1
+}
@@ -0,0 +1,61 @@
+package errtrace
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+func getUserHandler(w http.ResponseWriter, r *http.Request) error {
+	return errors.New("user not found")
+}
+
+func TestMiddleware(t *testing.T) {
+	var got error
+
+	mux := errchain.NewMux(errchain.New(func(h errchain.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = h.ServeHTTP(w, r)
+		})
+	}))
+
+	mux.Get("/users/{id}", getUserHandler, Middleware(true))
+	mux.Get("/traced", func(w http.ResponseWriter, r *http.Request) error {
+		return New("already traced")
+	}, Middleware(true))
+	mux.Get("/ok", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}, Middleware(false))
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+
+	data, err := TraceData(got)
+	if err != nil {
+		t.Fatalf("expected traceable error, got %v", got)
+	}
+
+	if data.Message != "handler GET /users/{id}" {
+		t.Errorf("unexpected message %q", data.Message)
+	}
+
+	if data.Function != "github.com/hay-kot/httpkit/errtrace.getUserHandler" {
+		t.Errorf("expected handler location, got %q", data.Function)
+	}
+
+	if got.Error() != "handler GET /users/{id}" || errors.Unwrap(got).Error() != "user not found" {
+		t.Errorf("expected original error to be wrapped, got %v", got)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/traced", nil))
+	if data, _ := TraceData(got); data == nil || data.Message != "already traced" {
+		t.Errorf("expected traceable error to be returned unchanged, got %v", got)
+	}
+
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/ok", nil))
+	if got != nil {
+		t.Errorf("expected nil error, got %v", got)
+	}
+}
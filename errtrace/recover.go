@@ -0,0 +1,74 @@
+package errtrace
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// PanicError is the cause of the errors returned by Recover and holds the value the
+// goroutine panicked with. If the value is an error, it is returned by Unwrap so
+// errors.Is and errors.As work through the panic.
+type PanicError struct {
+	Value any
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", p.Value)
+}
+
+func (p *PanicError) Unwrap() error {
+	err, _ := p.Value.(error)
+	return err
+}
+
+// Recover converts a panic into a traceable error and stores it in *errp. It must be
+// called directly with defer. The error records the location of the panic and the
+// full call stack at the time of the panic, and wraps a *PanicError containing the
+// panic value. Any error already stored in *errp is replaced.
+//
+// If the goroutine is not panicking, Recover does nothing.
+//
+// Example:
+//
+//	func process(job Job) (err error) {
+//	  defer errtrace.Recover(&err)
+//	  return job.Run()
+//	}
+func Recover(errp *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	*errp = newPanicError(v)
+}
+
+// newPanicError returns a traceable error for the panic value v. It must be called
+// from the deferred function that recovered the panic.
+func newPanicError(v any) error {
+	err := defaultTracer.capture(&stacktrace{cause: &PanicError{Value: v}, msg: "recovered from panic"})
+
+	buf := pcPool.Get().(*[maxStackDepth]uintptr)
+	defer pcPool.Put(buf)
+
+	n := runtime.Callers(3, buf[:])
+
+	// skip the runtime frames of the panic so the stack starts at the function that
+	// panicked
+	start := 0
+	for start < n {
+		f, _ := runtime.CallersFrames(buf[start : start+1]).Next()
+		if !strings.HasPrefix(f.Function, "runtime.") {
+			break
+		}
+		start++
+	}
+
+	if start < n {
+		err.callers = append([]uintptr(nil), buf[start:n]...)
+		err.pc = err.callers[0]
+	}
+
+	return defaultTracer.notifyCreate(err)
+}
@@ -0,0 +1,71 @@
+package errtrace
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func panicky(v any) (err error) {
+	defer Recover(&err)
+	panic(v)
+}
+
+func nilDeref() (err error) {
+	defer Recover(&err)
+	var m *PanicError
+	_ = m.Value
+	return nil
+}
+
+func noPanic() (err error) {
+	defer Recover(&err)
+	return io.EOF
+}
+
+func TestRecover(t *testing.T) {
+	err := panicky("boom")
+
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "boom" {
+		t.Fatalf("expected PanicError with value, got %v", err)
+	}
+
+	data, terr := TraceData(err)
+	if terr != nil {
+		t.Fatal(terr)
+	}
+
+	if data.Function != "github.com/hay-kot/httpkit/errtrace.panicky" {
+		t.Errorf("expected panic location in panicky, got %s", data.Function)
+	}
+
+	frames := chainFrames(err)
+	found := false
+	for _, f := range frames[0].Stack {
+		if f.Function == "github.com/hay-kot/httpkit/errtrace.TestRecover" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected full stack to contain the test function, got %v", frames[0].Stack)
+	}
+
+	if !strings.Contains(TraceStringPlain(err), "panic: boom") {
+		t.Errorf("expected panic value in trace, got:\n%s", TraceStringPlain(err))
+	}
+
+	if err := panicky(io.ErrUnexpectedEOF); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected error panic value to be unwrapped, got %v", err)
+	}
+
+	data, _ = TraceData(nilDeref())
+	if data == nil || data.Function != "github.com/hay-kot/httpkit/errtrace.nilDeref" {
+		t.Errorf("expected runtime panic location in nilDeref, got %+v", data)
+	}
+
+	if err := noPanic(); err != io.EOF { //nolint:errorlint
+		t.Errorf("expected error to be untouched without panic, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package errtrace
+
+import (
+	"strings"
+)
+
+// Redacted is the value RedactKeys replaces sensitive fields with.
+const Redacted = "[REDACTED]"
+
+// SetRedactor sets the Redactor of the default Tracer, a function that is applied to
+// every error message rendered by TraceString, MarshalStack, MarshalJSON, the logger
+// integrations, HTML and ToSentryEvent. Use it to remove secrets and PII that were
+// accidentally interpolated into error messages. Passing nil removes the redactor.
+//
+// The Error method of errors is not affected, as it is used by program logic such as
+// comparisons.
+//
+// Example:
+//
+//	token := regexp.MustCompile(`Bearer [A-Za-z0-9._-]+`)
+//	errtrace.SetRedactor(func(msg string) string {
+//	  return token.ReplaceAllString(msg, "Bearer "+errtrace.Redacted)
+//	})
+func SetRedactor(fn func(msg string) string) {
+	defaultTracer.Configure(func(c *Config) {
+		c.Redactor = fn
+	})
+}
+
+// SetFieldRedactor sets the FieldRedactor of the default Tracer, a function that is
+// applied to every field returned by Fields. It receives the key and value of the
+// field and returns the value to use instead. Passing nil removes the redactor.
+//
+// Example:
+//
+//	errtrace.SetFieldRedactor(errtrace.RedactKeys("password", "token"))
+func SetFieldRedactor(fn func(key string, value any) any) {
+	defaultTracer.Configure(func(c *Config) {
+		c.FieldRedactor = fn
+	})
+}
+
+// RedactKeys returns a field redactor for SetFieldRedactor that replaces the values of
+// the given keys with Redacted. Keys are matched case-insensitively.
+func RedactKeys(keys ...string) func(key string, value any) any {
+	set := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		set[strings.ToLower(k)] = struct{}{}
+	}
+
+	return func(key string, value any) any {
+		if _, ok := set[strings.ToLower(key)]; ok {
+			return Redacted
+		}
+
+		return value
+	}
+}
@@ -0,0 +1,58 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRedactor(t *testing.T) {
+	token := regexp.MustCompile(`token=\S+`)
+	SetRedactor(func(msg string) string {
+		return token.ReplaceAllString(msg, "token="+Redacted)
+	})
+	defer SetRedactor(nil)
+
+	err := Wrapf(fmt.Errorf("auth: token=%s", "s3cr3t"), "calling api with token=%s", "s3cr3t")
+
+	outputs := map[string]string{
+		"trace": TraceStringPlain(err),
+		"json":  func() string { b, _ := MarshalJSON(err); return string(b) }(),
+		"html":  string(HTML(err)),
+	}
+
+	for name, out := range outputs {
+		if strings.Contains(out, "s3cr3t") {
+			t.Errorf("%s: expected secret to be redacted, got:\n%s", name, out)
+		}
+
+		if !strings.Contains(out, Redacted) {
+			t.Errorf("%s: expected redaction marker, got:\n%s", name, out)
+		}
+	}
+
+	if event := ToSentryEvent(err); strings.Contains(event.Message, "s3cr3t") || strings.Contains(event.Exception[0].Value, "s3cr3t") {
+		t.Error("expected sentry event to be redacted")
+	}
+
+	if !strings.Contains(err.Error(), "s3cr3t") {
+		t.Error("expected Error to be unaffected by the redactor")
+	}
+}
+
+func TestFieldRedactor(t *testing.T) {
+	SetFieldRedactor(RedactKeys("Password"))
+	defer SetFieldRedactor(nil)
+
+	fields := Fields(WrapFields(errors.New("login failed"), "user", "alice", "password", "hunter2"))
+
+	if fields["password"] != Redacted {
+		t.Errorf("expected password to be redacted, got %v", fields["password"])
+	}
+
+	if fields["user"] != "alice" {
+		t.Errorf("expected user to be kept, got %v", fields["user"])
+	}
+}
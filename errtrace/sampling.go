@@ -0,0 +1,83 @@
+package errtrace
+
+import (
+	"math"
+	"math/rand/v2"
+)
+
+// SetSampling sets the SampleRate of the default Tracer, the fraction of traceable
+// errors, between 0 and 1, that record the location of the caller. The remaining
+// errors skip the runtime.Caller cost and only carry their message. The default rate
+// of 1 records the location of every error.
+//
+// Use WithSampling to sample a single hot path instead of every error.
+func SetSampling(rate float64) {
+	// a rate of 0 in Config is treated as 1
+	if rate <= 0 {
+		rate = -1
+	}
+
+	defaultTracer.Configure(func(c *Config) {
+		c.SampleRate = rate
+	})
+}
+
+func clampRate(rate float64) float64 {
+	return math.Max(0, math.Min(1, rate))
+}
+
+// sample reports whether an error should record its location for the given rate.
+func sample(rate float64) bool {
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// Sampler creates traceable errors that only record the location of the caller for
+// a fraction of the errors, see WithSampling.
+type Sampler struct {
+	rate float64
+}
+
+// WithSampling returns a Sampler that records the location of the caller for the
+// given fraction of errors, between 0 and 1, regardless of the rate set with
+// SetSampling. Use it in extremely hot error paths to keep the overhead predictable
+// while the remaining errors still wrap cheaply with their message.
+//
+// Example:
+//
+//	var sampled = errtrace.WithSampling(1.0 / 100)
+//
+//	func (c *Cache) Get(key string) (Item, error) {
+//	  item, ok := c.items[key]
+//	  if !ok {
+//	    return Item{}, sampled.Wrapf(ErrMiss, "cache miss for %s", key)
+//	  }
+//	  return item, nil
+//	}
+func WithSampling(rate float64) Sampler {
+	return Sampler{rate: clampRate(rate)}
+}
+
+// New is the same as the package level New, but samples the location.
+func (s Sampler) New(msg string, args ...any) error {
+	return defaultTracer.newSampled(s.rate, 0, nil, formatMessage(msg, args))
+}
+
+// Wrapf is the same as the package level Wrapf, but samples the location. If the
+// error is nil, Wrapf returns nil.
+func (s Sampler) Wrapf(err error, msg string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return defaultTracer.newSampled(s.rate, 0, err, formatMessage(msg, args))
+}
+
+// WrapMsg is the same as the package level WrapMsg, but samples the location. If the
+// error is nil, WrapMsg returns nil.
+func (s Sampler) WrapMsg(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+
+	return defaultTracer.newSampled(s.rate, 0, err, msg)
+}
@@ -0,0 +1,76 @@
+package errtrace
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSampling(t *testing.T) {
+	root := errors.New("root")
+
+	never := WithSampling(0)
+	if err := never.Wrapf(root, "wrap"); chainFrames(err)[0].IsTraceable() {
+		t.Error("expected no location with a rate of 0")
+	}
+
+	if err := never.Wrapf(root, "wrap %d", 1); err.Error() != "wrap 1" || !errors.Is(err, root) {
+		t.Errorf("expected unsampled errors to keep message and cause, got %v", err)
+	}
+
+	always := WithSampling(2)
+	data, err := TraceData(always.New("boom"))
+	if err != nil || data.Function != "github.com/hay-kot/httpkit/errtrace.TestSampling" {
+		t.Errorf("expected location of the caller with a rate of 1, got %+v", data)
+	}
+
+	if never.Wrapf(nil, "noop") != nil || never.WrapMsg(nil, "noop") != nil {
+		t.Error("expected nil errors")
+	}
+
+	SetSampling(0)
+	defer SetSampling(1)
+
+	if chainFrames(Wrapf(root, "wrap"))[0].IsTraceable() {
+		t.Error("expected global rate to apply to Wrapf")
+	}
+
+	if chainFrames(NewStack("stack"))[0].IsTraceable() {
+		t.Error("expected global rate to apply to NewStack")
+	}
+
+	if chainFrames(Join(root))[0].IsTraceable() {
+		t.Error("expected global rate to apply to Join")
+	}
+
+	if !chainFrames(always.Wrapf(root, "wrap"))[0].IsTraceable() {
+		t.Error("expected sampler rate to override the global rate")
+	}
+}
+
+func TestSamplingChainMessage(t *testing.T) {
+	err := Wrapf(WithSampling(0).Wrapf(errors.New("timeout"), "writing to database"), "creating user")
+	frames := chainFrames(err)
+
+	if !frames[1].Unsampled || frames[2].Unsampled {
+		t.Fatalf("expected only the middle frame to be unsampled, got %+v", frames)
+	}
+
+	want := "creating user: writing to database: timeout"
+	if got := chainMessage(frames); got != want {
+		t.Errorf("chainMessage() = %q, want %q", got, want)
+	}
+
+	if got := (CompactFormatter{}).Format(frames); !strings.Contains(got, " <- writing to database") {
+		t.Errorf("expected unsampled frame in compact output, got %q", got)
+	}
+}
+
+func BenchmarkWrapfSampled(b *testing.B) {
+	sampled := WithSampling(1.0 / 100)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = sampled.Wrapf(benchRoot, "query user %d", i)
+	}
+}
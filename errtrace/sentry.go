@@ -0,0 +1,125 @@
+package errtrace
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/getsentry/sentry-go"
+)
+
+// ToSentryEvent converts err into a sentry.Event. Every error in the chain becomes an
+// exception ordered from the root cause to the outermost error, as Sentry expects,
+// and traceable errors carry their location and captured call stack as stack frames.
+//
+// The fingerprint is built from the functions the traceable errors were created in,
+// so the same failure path is grouped together even when line numbers or messages
+// change. Error codes set with WithCode are added as the "code" tag and fields set
+// with WrapFields are added as extra data. If EnableBuildInfo was called, the
+// release is set to the VCS revision or the module version of the binary.
+//
+// Example:
+//
+//	sentry.CaptureEvent(errtrace.ToSentryEvent(err))
+func ToSentryEvent(err error) *sentry.Event {
+	event := sentry.NewEvent()
+	event.Level = sentry.LevelError
+
+	if err == nil {
+		return event
+	}
+
+	event.Message = defaultTracer.redact(err.Error())
+
+	if code, ok := Code(err); ok {
+		event.Tags["code"] = code
+	}
+
+	for k, v := range Fields(err) {
+		event.Extra[k] = v
+	}
+
+	if b := currentBuildInfo(); b != nil && defaultTracer.Config().BuildInfo {
+		event.Release = b.Version
+		if b.Revision != "" {
+			event.Release = b.Revision
+		}
+	}
+
+	var fingerprint []string
+
+	for err != nil {
+		if a, ok := err.(annotation); ok { //nolint:errorlint
+			err = a.Unwrap()
+			continue
+		}
+
+		exception := sentry.Exception{
+			Type:  reflect.TypeOf(err).String(),
+			Value: defaultTracer.redact(err.Error()),
+		}
+
+		var frames []Frame
+		switch e := err.(type) { //nolint:errorlint
+		case *stacktrace:
+			frames = chainFrames(e)[:1]
+			exception.Value = defaultTracer.redact(e.Error())
+		case *joinError:
+			frames = chainFrames(e)[:1]
+		}
+
+		if len(frames) == 1 && frames[0].IsTraceable() {
+			fingerprint = append(fingerprint, frames[0].Function)
+			exception.Stacktrace = sentryStacktrace(frames[0])
+		}
+
+		event.Exception = append(event.Exception, exception)
+
+		err = errors.Unwrap(err)
+	}
+
+	// Sentry expects the root cause first and the outermost error last
+	for i, j := 0, len(event.Exception)-1; i < j; i, j = i+1, j-1 {
+		event.Exception[i], event.Exception[j] = event.Exception[j], event.Exception[i]
+	}
+
+	if len(fingerprint) > 0 {
+		event.Fingerprint = fingerprint
+	}
+
+	return event
+}
+
+// sentryStacktrace returns the location of f followed by its captured stack, ordered
+// from the oldest call to the most recent one.
+func sentryStacktrace(f Frame) *sentry.Stacktrace {
+	calls := append([]Frame{f}, f.Stack...)
+
+	frames := make([]sentry.Frame, len(calls))
+	for i, call := range calls {
+		module, function := splitFuncName(call.Function)
+		frames[len(calls)-1-i] = sentry.Frame{
+			Function: function,
+			Module:   module,
+			Filename: call.Source,
+			AbsPath:  call.Source,
+			Lineno:   call.Line,
+			InApp:    true,
+		}
+	}
+
+	return &sentry.Stacktrace{Frames: frames}
+}
+
+// splitFuncName splits a fully qualified function name such as
+// "github.com/org/pkg.(*T).Method" into its package path and function name.
+func splitFuncName(name string) (string, string) {
+	slash := strings.LastIndex(name, "/")
+	dot := strings.Index(name[slash+1:], ".")
+	if dot < 0 {
+		return "", name
+	}
+
+	dot += slash + 1
+	return name[:dot], name[dot+1:]
+}
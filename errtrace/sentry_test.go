@@ -0,0 +1,52 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestToSentryEvent(t *testing.T) {
+	root := errors.New("connection refused")
+	err := fmt.Errorf("handler: %w", WithCode(Wrapf(root, "query users"), "db_unavailable"))
+
+	event := ToSentryEvent(err)
+
+	if len(event.Exception) != 3 {
+		t.Fatalf("expected 3 exceptions, got %d", len(event.Exception))
+	}
+
+	if event.Exception[0].Value != "connection refused" {
+		t.Errorf("expected root cause first, got %q", event.Exception[0].Value)
+	}
+
+	traced := event.Exception[1]
+	if traced.Value != "query users" || traced.Stacktrace == nil {
+		t.Fatalf("expected traceable exception with stacktrace, got %+v", traced)
+	}
+
+	frames := traced.Stacktrace.Frames
+	last := frames[len(frames)-1]
+	if last.Function != "TestToSentryEvent" || last.Module != "github.com/hay-kot/httpkit/errtrace" {
+		t.Errorf("expected most recent frame last, got %s %s", last.Module, last.Function)
+	}
+
+	if event.Exception[2].Value != err.Error() {
+		t.Errorf("expected outermost error last, got %q", event.Exception[2].Value)
+	}
+
+	if len(event.Fingerprint) != 1 || event.Fingerprint[0] != "github.com/hay-kot/httpkit/errtrace.TestToSentryEvent" {
+		t.Errorf("unexpected fingerprint %v", event.Fingerprint)
+	}
+
+	if event.Tags["code"] != "db_unavailable" {
+		t.Errorf("expected code tag, got %q", event.Tags["code"])
+	}
+}
+
+func TestSplitFuncName(t *testing.T) {
+	module, function := splitFuncName("github.com/org/pkg.(*T).Method")
+	if module != "github.com/org/pkg" || function != "(*T).Method" {
+		t.Errorf("got %q %q", module, function)
+	}
+}
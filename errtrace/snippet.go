@@ -0,0 +1,67 @@
+package errtrace
+
+import (
+	"os"
+	"strings"
+)
+
+// snippetContext is the number of lines shown before and after the recorded line.
+const snippetContext = 3
+
+// EnableSnippets enables source code snippets in the output of TraceString by setting
+// Snippets in the Config of the default Tracer. Each traceable frame shows the lines
+// around the recorded line, with the failing line highlighted.
+//
+// Snippets read the source files from disk when the trace is rendered, so they are
+// only useful during local development where the source is available at the paths
+// recorded in the frames. Do not enable them in production.
+func EnableSnippets() {
+	defaultTracer.Configure(func(c *Config) {
+		c.Snippets = true
+	})
+}
+
+// DisableSnippets disables source code snippets in the output of TraceString.
+func DisableSnippets() {
+	defaultTracer.Configure(func(c *Config) {
+		c.Snippets = false
+	})
+}
+
+type snippetLine struct {
+	Number    int
+	Text      string
+	Highlight bool
+}
+
+// sourceSnippet returns the lines around line in file. It returns nil if the file
+// cannot be read or does not contain the line.
+func sourceSnippet(file string, line int) []snippetLine {
+	if file == "" || line <= 0 {
+		return nil
+	}
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+
+	lines := strings.Split(string(b), "\n")
+	if line > len(lines) {
+		return nil
+	}
+
+	start := max(line-snippetContext, 1)
+	end := min(line+snippetContext, len(lines))
+
+	snippet := make([]snippetLine, 0, end-start+1)
+	for n := start; n <= end; n++ {
+		snippet = append(snippet, snippetLine{
+			Number:    n,
+			Text:      strings.TrimRight(lines[n-1], "\r"),
+			Highlight: n == line,
+		})
+	}
+
+	return snippet
+}
@@ -0,0 +1,42 @@
+package errtrace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSourceSnippet(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "main.go")
+	src := "line 1\nline 2\nline 3\nline 4\nline 5\nline 6\nline 7\nline 8\n"
+	if err := os.WriteFile(file, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	snippet := sourceSnippet(file, 2)
+	if len(snippet) != 5 || snippet[0].Number != 1 || snippet[4].Number != 5 {
+		t.Fatalf("expected lines 1 to 5, got %+v", snippet)
+	}
+
+	if !snippet[1].Highlight || snippet[1].Text != "line 2" {
+		t.Errorf("expected line 2 to be highlighted, got %+v", snippet[1])
+	}
+
+	if sourceSnippet(file, 100) != nil || sourceSnippet("missing.go", 1) != nil {
+		t.Error("expected nil snippet for unknown lines and files")
+	}
+
+	frames := []Frame{{Error: "boom", Source: file, Line: 5, Function: "main.main"}}
+
+	got := TextFormatter{Snippets: true}.Format(frames)
+	for _, want := range []string{"          2 | line 2", "        > 5 | line 5", "          8 | line 8"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(TextFormatter{}.Format(frames), "line 5") {
+		t.Error("expected no snippet when disabled")
+	}
+}
@@ -1,95 +1,143 @@
 package errtrace
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"runtime"
+	"strconv"
 	"strings"
+	"sync"
 )
 
+// SetMaxDepth limits the number of errors in a chain rendered by TraceString,
+// MarshalStack and the logger integrations. Longer chains, for example built by
+// recursive retry logic, are truncated and end with a "… N more" marker. A depth of 0
+// or less disables truncation, which is the default.
+//
+// Use TraceStringDepth and MarshalStackDepth to set the depth for a single call.
+func SetMaxDepth(depth int) {
+	defaultTracer.Configure(func(c *Config) {
+		c.MaxDepth = max(depth, 0)
+	})
+}
+
 type stacktrace struct {
-	message  string
-	cause    error
+	cause error
+
+	// msg is the message of the error. Errors created with WrapLazy keep args and
+	// format msg into message on first use, every other error has a final msg and
+	// format is false.
+	msg     string
+	args    []any
+	format  bool
+	msgOnce sync.Once
+	message string
+
+	// pc is the program counter of the caller. It is resolved into file, function and
+	// line on first use, which is considerably more expensive than capturing it.
+	pc       uintptr
+	locOnce  sync.Once
 	file     string
 	function string
 	line     int
+
+	// callers holds the program counters of the full call stack when the error was
+	// created with NewStack or WrapStack. The first entry is the caller recorded in
+	// pc.
+	callers []uintptr
+
+	// goroutine and labels are recorded with EnableGoroutineCapture and WrapCtx.
+	goroutine uint64
+	labels    map[string]string
 }
 
 func (st *stacktrace) Error() string {
+	st.msgOnce.Do(func() {
+		st.message = st.msg
+		if st.format && (len(st.args) > 0 || strings.IndexByte(st.msg, '%') >= 0) {
+			st.message = fmt.Sprintf(st.msg, st.args...)
+		}
+		st.args = nil
+	})
+
 	return st.message
 }
 
+// location returns the file, function and line of the caller that created the error.
+// The file is returned as recorded, without applying a cleaner.
+func (st *stacktrace) location() (file, function string, line int) {
+	st.locOnce.Do(func() {
+		if st.pc == 0 {
+			return
+		}
+
+		frame, _ := runtime.CallersFrames([]uintptr{st.pc}).Next()
+		st.file = frame.File
+		st.function = frame.Function
+		st.line = frame.Line
+	})
+
+	return st.file, st.function, st.line
+}
+
 func (st *stacktrace) Unwrap() error {
 	return st.cause
 }
 
-func red(s string) string  { return "\033[31m" + s + "\033[0m" }
-func bold(s string) string { return "\033[1m" + s + "\033[0m" }
+// stackFrames returns the frames of the call stack recorded by NewStack and WrapStack,
+// excluding the first frame and frames within the runtime package.
+func (st *stacktrace) stackFrames(clean func(string) string) []Frame {
+	if len(st.callers) < 2 {
+		return nil
+	}
 
-func stringer(err error) string {
-	str := strings.Builder{}
-	lastWasTraceable := false
-	const indent = "    "
+	var frames []Frame
 
+	iter := runtime.CallersFrames(st.callers[1:])
 	for {
-		if err == nil {
-			break
+		f, more := iter.Next()
+		if f.Function != "" && !strings.HasPrefix(f.Function, "runtime.") {
+			frames = append(frames, Frame{
+				Source:   clean(f.File),
+				Line:     f.Line,
+				Function: f.Function,
+			})
 		}
 
-		traceable, ok := err.(*stacktrace) //nolint:errorlint
-		if !ok {
-			if !lastWasTraceable {
-				str.WriteString(red(bold("error: ")))
-				str.WriteRune('\n')
-				str.WriteString(indent)
-				str.WriteString(err.Error())
-				str.WriteRune('\n')
-			}
-
-			maybeErr := errors.Unwrap(err)
-			if maybeErr == nil {
-				break
-			}
-
-			lastWasTraceable = false
-			err = maybeErr
-
-			continue
+		if !more {
+			break
 		}
+	}
 
-		lastWasTraceable = true
-
-		str.WriteString(red(bold(("trace error: "))))
-
-		str.WriteString(traceable.message)
-		str.WriteRune('\n')
+	return frames
+}
 
-		// File and line numbers
-		str.WriteString(indent)
-		str.WriteString(traceable.file)
-		str.WriteRune(':')
-		str.WriteString(fmt.Sprintf("%d", traceable.line))
+func (st *stacktrace) String() string {
+	return TraceString(st)
+}
 
-		// Function
-		str.WriteRune('\n')
-		str.WriteString(indent)
-		str.WriteString(indent)
-		str.WriteString(traceable.function)
-		str.WriteString("()")
+// Format implements fmt.Formatter. The %s and %v verbs print the message of the
+// error, %q prints the quoted message and %+v prints the full trace as returned by
+// TraceString, the same as github.com/pkg/errors.
+func (st *stacktrace) Format(s fmt.State, verb rune) {
+	formatError(st, s, verb)
+}
 
-		if traceable.cause != nil {
-			str.WriteString(" -> ")
-			str.WriteString(traceable.cause.Error())
-			str.WriteRune('\n')
+func formatError(err error, s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			_, _ = io.WriteString(s, TraceString(err))
+			return
 		}
-
-		err = traceable.cause
+		_, _ = io.WriteString(s, err.Error())
+	case 's':
+		_, _ = io.WriteString(s, err.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", err.Error())
 	}
-
-	return str.String()
-}
-
-func (st *stacktrace) String() string {
-	return stringer(st)
 }
 
 // StackTraceData contains the data of a stacktrace. It is returned by TraceData.
@@ -100,6 +148,10 @@ type StackTraceData struct {
 	Function string // The function of the caller of the Traceable function
 	Line     int    // The line number of the caller of the Traceable function
 	Cause    error  // The underlying error wrapped by the Traceable function
+
+	// Traceable is false for the errors in the chain returned by TraceDataAll that
+	// are not traceable. Only Message and Cause are set for those errors.
+	Traceable bool
 }
 
 // Loc returns a formatted string that contains the file, function and line number of the caller of the Traceable function.
@@ -121,46 +173,231 @@ func TraceData(err error) (*StackTraceData, error) {
 		return nil, errors.New("error is not traceable")
 	}
 
+	file, function, line := trace.location()
+
 	return &StackTraceData{
-		Message:  trace.message,
-		File:     trace.file,
-		Function: trace.function,
-		Line:     trace.line,
-		Cause:    trace.cause,
+		Message:   trace.Error(),
+		File:      cleanGoPath(file),
+		Function:  function,
+		Line:      line,
+		Cause:     trace.cause,
+		Traceable: true,
 	}, nil
 }
 
-// TraceString returns a string representation of the error and all its causes.
+// TraceDataAll returns the data of every error in the chain of err, from the
+// outermost to the innermost error. Errors that are not traceable are included with
+// Traceable set to false, so the result mirrors the complete chain.
+//
+// Errors created with Join or Append are returned as a single traceable entry without
+// a cause, the branches can be inspected with errors.Unwrap on the original error.
+func TraceDataAll(err error) []*StackTraceData {
+	var data []*StackTraceData
+
+	for err != nil {
+		if a, ok := err.(annotation); ok { //nolint:errorlint
+			err = a.Unwrap()
+			continue
+		}
+
+		switch e := err.(type) { //nolint:errorlint
+		case *stacktrace:
+			file, function, line := e.location()
+			data = append(data, &StackTraceData{
+				Message:   e.Error(),
+				File:      cleanGoPath(file),
+				Function:  function,
+				Line:      line,
+				Cause:     e.cause,
+				Traceable: true,
+			})
+		case *joinError:
+			data = append(data, &StackTraceData{
+				Message:   e.Error(),
+				File:      cleanGoPath(e.file),
+				Function:  e.function,
+				Line:      e.line,
+				Traceable: true,
+			})
+		default:
+			data = append(data, &StackTraceData{
+				Message: err.Error(),
+				Cause:   errors.Unwrap(err),
+			})
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return data
+}
+
+// TraceString returns a string representation of the error and all its causes using
+// the formatter set with SetFormatter.
+//
+// The default formatter is a TextFormatter that emits ANSI color codes unless color
+// was disabled with DisableColor, the NO_COLOR environment variable is set or the
+// output is not a terminal.
 func TraceString(err error) string {
-	return stringer(err)
+	return defaultTracer.TraceString(err)
 }
 
-// frame is used purely for marshalling the stacktrace to JSON.
-// for the MarshalStack function.
-type frame struct {
-	Error    string `json:"error,omitempty"`
-	Source   string `json:"source,omitempty"`
-	Line     int    `json:"line,omitempty"`
-	Function string `json:"func,omitempty"`
+// TraceStringPlain is the same as TraceString with the default formatter, but never
+// emits ANSI color codes. Use this when writing traces to files or other
+// non-terminal outputs.
+func TraceStringPlain(err error) string {
+	return TraceStringWith(err, TextFormatter{})
+}
+
+// TraceStringWith returns a string representation of the error and all its causes
+// using the provided formatter.
+func TraceStringWith(err error, f Formatter) string {
+	return f.Format(chainFrames(err))
+}
+
+// Frame is a single error in the chain of an error. Frames of traceable errors
+// contain the location the error was created at, frames of other errors in the
+// chain only contain the error message.
+type Frame struct {
+	Error    string  `json:"error,omitempty"`
+	Source   string  `json:"source,omitempty"`
+	Line     int     `json:"line,omitempty"`
+	Function string  `json:"func,omitempty"`
+	Stack    []Frame `json:"stack,omitempty"` // remaining call stack for NewStack and WrapStack errors
+
+	// Goroutine is the ID of the goroutine the error was created on, see
+	// EnableGoroutineCapture. Labels are the pprof labels recorded with WrapCtx.
+	Goroutine uint64            `json:"goroutine,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+
+	// Branches contains the frames of each error aggregated with Join or Append. A
+	// frame with branches is always the last frame of its chain.
+	Branches [][]Frame `json:"branches,omitempty"`
+
+	// Omitted is set on the marker frame appended when the chain was truncated to
+	// the maximum depth and contains the number of errors that were left out.
+	Omitted int `json:"omitted,omitempty"`
+
+	// Build identifies the binary that produced the trace. It is only set on the
+	// first frame of a chain, see EnableBuildInfo.
+	Build *BuildInfo `json:"build,omitempty"`
+
+	// Unsampled is set for traceable errors created without a location because of
+	// sampling, see SetSampling. Unlike plain errors, their message does not contain
+	// the rest of the chain.
+	Unsampled bool `json:"unsampled,omitempty"`
+}
+
+// IsTraceable reports whether the frame was created from a traceable error and
+// carries location information.
+func (f Frame) IsTraceable() bool {
+	return f.Source != "" || f.Function != ""
+}
+
+// isPlain reports whether the frame was created from a plain error, whose message
+// already contains the messages of the rest of the chain.
+func (f Frame) isPlain() bool {
+	return !f.Unsampled && !f.IsTraceable()
 }
 
 // MarshalStack implements a custom JSON marshaller for errors that are traceable.
 // This was implemented for use within github.com/rs/zerolog package.
+//
+// The returned value is a []Frame.
 func MarshalStack(err error) any {
-	var frames []frame
+	return chainFrames(err)
+}
+
+// MarshalJSON returns the JSON encoding of the frames of err.
+//
+// Example output:
+//
+//	[{"error":"wrap 1","source":"main.go","line":12,"func":"main.main"},{"error":"root error"}]
+func MarshalJSON(err error) ([]byte, error) {
+	return json.Marshal(chainFrames(err))
+}
+
+// TraceStringDepth is the same as TraceString, but truncates the chain after depth
+// errors instead of using the depth set with SetMaxDepth. A depth of 0 or less
+// disables truncation.
+func TraceStringDepth(err error, depth int) string {
+	return defaultTracer.formatter().Format(chainFramesDepth(err, depth))
+}
+
+// MarshalStackDepth is the same as MarshalStack, but truncates the chain after depth
+// errors instead of using the depth set with SetMaxDepth. A depth of 0 or less
+// disables truncation.
+func MarshalStackDepth(err error, depth int) any {
+	return chainFramesDepth(err, depth)
+}
+
+// chainFrames returns a Frame for every error in the chain of err, truncated to the
+// depth set with SetMaxDepth.
+func chainFrames(err error) []Frame {
+	return defaultTracer.frames(err, defaultTracer.Config().MaxDepth)
+}
+
+// chainFramesDepth returns a Frame for every error in the chain of err using the
+// default tracer. If depth is greater than 0, only the first depth errors are
+// returned followed by a marker frame with the number of omitted errors.
+func chainFramesDepth(err error, depth int) []Frame {
+	return defaultTracer.frames(err, depth)
+}
+
+// collectFrames returns a Frame for every error in the chain of err, truncated to
+// depth, with the cleaner and redactor of the Tracer applied.
+func (t *Tracer) collectFrames(err error, depth int) []Frame {
+	var frames []Frame
 
 	for err != nil {
+		if a, ok := err.(annotation); ok { //nolint:errorlint
+			err = a.Unwrap()
+			continue
+		}
+
+		if depth > 0 && len(frames) == depth {
+			omitted := countChain(err)
+			frames = append(frames, Frame{
+				Error:   "… " + strconv.Itoa(omitted) + " more",
+				Omitted: omitted,
+			})
+
+			break
+		}
+
+		if je, ok := err.(*joinError); ok { //nolint:errorlint
+			branches := make([][]Frame, len(je.errs))
+			for i, branch := range je.errs {
+				branches[i] = t.collectFrames(branch, depth)
+			}
+
+			frames = append(frames, Frame{
+				Error:    t.redact(je.Error()),
+				Source:   t.clean(je.file),
+				Line:     je.line,
+				Function: je.function,
+				Branches: branches,
+			})
+
+			break
+		}
+
 		traceable, ok := err.(*stacktrace) //nolint:errorlint
 		if ok {
-			frames = append(frames, frame{
-				Error:    traceable.message,
-				Source:   traceable.file,
-				Line:     traceable.line,
-				Function: traceable.function,
+			file, function, line := traceable.location()
+			frames = append(frames, Frame{
+				Error:     t.redact(traceable.Error()),
+				Source:    t.clean(file),
+				Line:      line,
+				Function:  function,
+				Stack:     traceable.stackFrames(t.clean),
+				Goroutine: traceable.goroutine,
+				Labels:    traceable.labels,
+				Unsampled: traceable.pc == 0,
 			})
 		} else {
 			// append the error for context
-			frames = append(frames, frame{Error: err.Error()})
+			frames = append(frames, Frame{Error: t.redact(err.Error())})
 		}
 
 		unwrapped := errors.Unwrap(err)
@@ -174,3 +411,18 @@ func MarshalStack(err error) any {
 
 	return frames
 }
+
+// countChain returns the number of errors in the chain of err, excluding annotations.
+// Joined errors count as a single error.
+func countChain(err error) int {
+	n := 0
+	for err != nil {
+		if _, ok := err.(annotation); !ok { //nolint:errorlint
+			n++
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	return n
+}
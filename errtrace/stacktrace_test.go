@@ -20,7 +20,7 @@ func TestMarshalStack(t *testing.T) {
 	tests := []struct {
 		name  string
 		error func() error
-		want  []frame
+		want  []Frame
 	}{
 		{
 			name: "generic wrapped errors",
@@ -29,7 +29,7 @@ func TestMarshalStack(t *testing.T) {
 				err = fmt.Errorf("wrap: %w", err)
 				return err
 			},
-			want: []frame{
+			want: []Frame{
 				{Error: "wrap: user with id 1 already exists"},
 				{Error: "user with id 1 already exists"},
 			},
@@ -43,7 +43,7 @@ func TestMarshalStack(t *testing.T) {
 
 				return err
 			},
-			want: []frame{
+			want: []Frame{
 				{Error: "wrap 2", Source: filepath, Line: 42, Function: funcname + "2"},
 				{Error: "wrap 1", Source: filepath, Line: 41, Function: funcname + "2"},
 				{Error: "root error", Source: filepath, Line: 40, Function: funcname + "2"},
@@ -57,7 +57,7 @@ func TestMarshalStack(t *testing.T) {
 				err = Wrapf(err, "wrap 1")
 				return err
 			},
-			want: []frame{
+			want: []Frame{
 				{Error: "wrap 1", Source: filepath, Line: 57, Function: funcname + "3"},
 				{Error: "wrap: user with id 1 already exists"},
 				{Error: "user with id 1 already exists", Source: filepath, Line: 55, Function: funcname + "3"},
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 	"runtime"
+	"strings"
+	"sync"
 )
 
 func IsTraceable(err error) bool {
@@ -17,13 +19,13 @@ func IsTraceable(err error) bool {
 //
 // Deprecated: Use New instead.
 func Trace(msg string, args ...any) error {
-	return newTraceable(nil, msg, args...)
+	return defaultTracer.newTraceable(0, nil, msg, args...)
 }
 
 // New creates a new error with a stacktrace and returns the new error.
 // Use this like you would fmt.Errorf.
 func New(msg string, args ...any) error {
-	return newTraceable(nil, msg, args...)
+	return defaultTracer.newTraceable(0, nil, msg, args...)
 }
 
 // TraceWrap is the same as Trace, but it wraps an existing error.
@@ -33,7 +35,7 @@ func TraceWrap(err error, msg string, args ...any) error {
 		return nil
 	}
 
-	return newTraceable(err, msg, args...)
+	return defaultTracer.newTraceable(0, err, msg, args...)
 }
 
 // Wrap wraps an error within a stacktrace and returns the new error.
@@ -42,7 +44,7 @@ func Wrap(err error) error {
 		return nil
 	}
 
-	return newTraceable(err, err.Error())
+	return defaultTracer.newTraceableMsg(0, err, err.Error())
 }
 
 // Wrapf wraps an error within a stacktrace and returns the new error.
@@ -73,29 +75,191 @@ func Wrapf(err error, msg string, args ...any) error {
 		return nil
 	}
 
-	return newTraceable(err, msg, args...)
+	return defaultTracer.newTraceable(0, err, msg, args...)
 }
 
-func newTraceable(cause error, msg string, args ...any) error {
-	err := &stacktrace{
-		message: fmt.Sprintf(msg, args...),
-		cause:   cause,
+// NewSkip is the same as New, but skips the given number of additional stack frames
+// when recording the location of the error. A skip of 0 records the caller of
+// NewSkip, a skip of 1 the caller of the function that called NewSkip and so on.
+//
+// Use this in helper functions that wrap errtrace so the recorded location points at
+// the real call site instead of the helper.
+func NewSkip(skip int, msg string, args ...any) error {
+	return defaultTracer.newTraceable(skip, nil, msg, args...)
+}
+
+// WrapSkip is the same as Wrapf, but skips the given number of additional stack
+// frames when recording the location of the error. If the error is nil, WrapSkip
+// returns nil.
+//
+// Example:
+//
+//	func wrapDB(err error, op string) error {
+//	  // record the location of the caller of wrapDB
+//	  return errtrace.WrapSkip(err, 1, "database: %s", op)
+//	}
+func WrapSkip(err error, skip int, msg string, args ...any) error {
+	if err == nil {
+		return nil
 	}
 
-	pc, file, line, ok := runtime.Caller(2)
-	if !ok {
-		return err
+	return defaultTracer.newTraceable(skip, err, msg, args...)
+}
+
+// WrapLazy is the same as Wrapf, but formats the message when it is first used
+// instead of when the error is created, so errors that are handled without being
+// printed never pay for fmt.Sprintf. The args are retained by the error and must not
+// be modified after the call. If the error is nil, WrapLazy returns nil.
+//
+// Example:
+//
+//	if err := c.fetch(key); err != nil {
+//	  // most misses are retried and never logged
+//	  return errtrace.WrapLazy(err, "fetching %s", key)
+//	}
+func WrapLazy(err error, msg string, args ...any) error {
+	if err == nil {
+		return nil
 	}
 
-	err.file = cleanGoPath(file)
-	err.line = line
+	st := defaultTracer.capture(&stacktrace{cause: err, msg: msg, args: args, format: true})
+	if sample(defaultTracer.sampleRate()) {
+		st.pc = callerPC(0)
+	}
+	return defaultTracer.notifyCreate(st)
+}
 
-	fn := runtime.FuncForPC(pc)
-	if fn == nil {
-		return err
+// WrapMsg is the same as Wrapf, but uses msg verbatim instead of formatting it. This
+// is the cheapest way to wrap an error and is intended for hot paths where the
+// message is a constant. If the error is nil, WrapMsg returns nil.
+func WrapMsg(err error, msg string) error {
+	if err == nil {
+		return nil
 	}
 
-	err.function = fn.Name()
+	return defaultTracer.newTraceableMsg(0, err, msg)
+}
+
+// maxStackDepth is the maximum number of frames recorded by NewStack and WrapStack.
+const maxStackDepth = 64
+
+// NewStack is the same as New, but records the full call stack instead of only the
+// caller. TraceString and MarshalStack render every frame of the stack.
+//
+// Capturing the full stack is more expensive than a single frame, prefer New unless
+// the additional context is required.
+func NewStack(msg string, args ...any) error {
+	return defaultTracer.newTraceableStack(0, nil, msg, args...)
+}
+
+// WrapStack is the same as Wrapf, but records the full call stack instead of only the
+// caller. If the error is nil, WrapStack returns nil.
+func WrapStack(err error, msg string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return defaultTracer.newTraceableStack(0, err, msg, args...)
+}
+
+// WrapPCs wraps err within a stacktrace using the call stack in pcs instead of the
+// location of the call, for example program counters captured with runtime.Callers
+// by a panic handler or by a worker pool when a job was submitted. The first program
+// counter is used as the location of the error and the remaining ones are rendered
+// like the stack of WrapStack. msg is used verbatim.
+//
+// If pcs is empty, the location of the caller is recorded instead. If the error is
+// nil, WrapPCs returns nil.
+//
+// Example:
+//
+//	pcs := make([]uintptr, 32)
+//	job.pcs = pcs[:runtime.Callers(2, pcs)]
+//	// later, on a worker goroutine
+//	return errtrace.WrapPCs(err, job.pcs, "job failed")
+func WrapPCs(err error, pcs []uintptr, msg string) error {
+	if err == nil {
+		return nil
+	}
+
+	if len(pcs) == 0 {
+		return defaultTracer.newTraceableMsg(0, err, msg)
+	}
+
+	st := defaultTracer.capture(&stacktrace{cause: err, msg: msg})
+	st.callers = append([]uintptr(nil), pcs...)
+	st.pc = st.callers[0]
+
+	return defaultTracer.notifyCreate(st)
+}
+
+// pcPool holds scratch buffers for the program counters captured by NewStack and
+// WrapStack, only the captured part is copied into the error.
+var pcPool = sync.Pool{
+	New: func() any { return new([maxStackDepth]uintptr) },
+}
+
+func (t *Tracer) newTraceableStack(skip int, cause error, msg string, args ...any) error {
+	err := t.capture(&stacktrace{cause: cause, msg: formatMessage(msg, args)})
+	if !sample(t.sampleRate()) {
+		return t.notifyCreate(err)
+	}
+
+	buf := pcPool.Get().(*[maxStackDepth]uintptr)
+	defer pcPool.Put(buf)
+
+	n := runtime.Callers(3+skip, buf[:])
+	if n > 0 {
+		err.callers = append([]uintptr(nil), buf[:n]...)
+		err.pc = err.callers[0]
+	}
+
+	return t.notifyCreate(err)
+}
+
+func (t *Tracer) newTraceable(skip int, cause error, msg string, args ...any) error {
+	err := t.capture(&stacktrace{cause: cause, msg: formatMessage(msg, args)})
+	if sample(t.sampleRate()) {
+		err.pc = callerPC(skip + 1)
+	}
+	return t.notifyCreate(err)
+}
+
+func (t *Tracer) newTraceableMsg(skip int, cause error, msg string) error {
+	err := t.capture(&stacktrace{cause: cause, msg: msg})
+	if sample(t.sampleRate()) {
+		err.pc = callerPC(skip + 1)
+	}
+	return t.notifyCreate(err)
+}
+
+// newSampled is the same as newTraceableMsg, but records the location for the given
+// fraction of errors instead of the configured rate.
+func (t *Tracer) newSampled(rate float64, skip int, cause error, msg string) *stacktrace {
+	err := t.capture(&stacktrace{cause: cause, msg: msg})
+	if sample(rate) {
+		err.pc = callerPC(skip + 1)
+	}
+	return t.notifyCreate(err)
+}
+
+// formatMessage formats msg with args like fmt.Sprintf, skipping the call for
+// messages without arguments or verbs.
+func formatMessage(msg string, args []any) string {
+	if len(args) == 0 && strings.IndexByte(msg, '%') < 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
+
+// callerPC returns the program counter of the caller skip frames above the caller
+// of callerPC, or 0 if it cannot be determined.
+func callerPC(skip int) uintptr {
+	var pcs [1]uintptr
+	if runtime.Callers(skip+3, pcs[:]) == 0 {
+		return 0
+	}
 
-	return err
+	return pcs[0]
 }
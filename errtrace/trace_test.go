@@ -0,0 +1,184 @@
+package errtrace
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func stackHelper() error {
+	return NewStack("root error")
+}
+
+func TestNewStack(t *testing.T) {
+	err := WrapStack(stackHelper(), "wrapped")
+
+	frames := MarshalStack(err).([]Frame)
+	if len(frames) != 2 {
+		t.Fatalf("expected 2 frames, got %d", len(frames))
+	}
+
+	root := frames[1]
+	if root.Function != "github.com/hay-kot/httpkit/errtrace.stackHelper" {
+		t.Errorf("expected root frame to be stackHelper, got %s", root.Function)
+	}
+
+	if len(root.Stack) == 0 || root.Stack[0].Function != "github.com/hay-kot/httpkit/errtrace.TestNewStack" {
+		t.Errorf("expected stack to contain TestNewStack, got %v", root.Stack)
+	}
+
+	str := TraceString(err)
+	if strings.Count(str, "TestNewStack()") != 2 {
+		t.Errorf("expected both traces to render the calling test, got:\n%s", str)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	err := Wrapf(errors.New("root error"), "wrapped")
+
+	b, jsonErr := MarshalJSON(err)
+	if jsonErr != nil {
+		t.Fatal(jsonErr)
+	}
+
+	var frames []Frame
+	if err := json.Unmarshal(b, &frames); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(frames) != 2 || frames[0].Error != "wrapped" || frames[1].Error != "root error" {
+		t.Errorf("unexpected frames %s", b)
+	}
+
+	if frames[0].Function != "github.com/hay-kot/httpkit/errtrace.TestMarshalJSON" {
+		t.Errorf("expected function to be TestMarshalJSON, got %s", frames[0].Function)
+	}
+}
+
+func wrapHelper(err error) error {
+	return WrapSkip(err, 1, "helper")
+}
+
+func newHelper() error {
+	return NewSkip(1, "helper")
+}
+
+func TestSkip(t *testing.T) {
+	for name, err := range map[string]error{
+		"wrap": wrapHelper(errors.New("root")),
+		"new":  newHelper(),
+	} {
+		data, terr := TraceData(err)
+		if terr != nil {
+			t.Fatal(terr)
+		}
+
+		if data.Function != "github.com/hay-kot/httpkit/errtrace.TestSkip" {
+			t.Errorf("%s: expected caller of helper to be recorded, got %s", name, data.Function)
+		}
+	}
+
+	if WrapSkip(nil, 1, "helper") != nil {
+		t.Error("expected nil error")
+	}
+}
+
+func TestTraceDataAll(t *testing.T) {
+	root := errors.New("root")
+	err := Wrapf(WithCode(fmt.Errorf("plain: %w", Wrapf(root, "inner")), "code"), "outer")
+
+	data := TraceDataAll(err)
+	if len(data) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(data))
+	}
+
+	expect := []struct {
+		message   string
+		traceable bool
+	}{
+		{"outer", true},
+		{"plain: inner", false},
+		{"inner", true},
+		{"root", false},
+	}
+
+	for i, e := range expect {
+		if data[i].Message != e.message || data[i].Traceable != e.traceable {
+			t.Errorf("entry %d: expected %q traceable=%v, got %q traceable=%v", i, e.message, e.traceable, data[i].Message, data[i].Traceable)
+		}
+	}
+
+	if data[2].Function != "github.com/hay-kot/httpkit/errtrace.TestTraceDataAll" || data[2].Cause != root {
+		t.Errorf("unexpected traceable entry %+v", data[2])
+	}
+
+	if data[1].File != "" {
+		t.Errorf("expected no location for plain errors, got %q", data[1].File)
+	}
+
+	if TraceDataAll(nil) != nil {
+		t.Error("expected nil for nil error")
+	}
+}
+
+func submitSite() []uintptr {
+	pcs := make([]uintptr, 32)
+	return pcs[:runtime.Callers(1, pcs)]
+}
+
+func TestWrapPCs(t *testing.T) {
+	pcs := submitSite()
+
+	err := WrapPCs(errors.New("root"), pcs, "job failed")
+
+	frames := chainFrames(err)
+	if frames[0].Function != "github.com/hay-kot/httpkit/errtrace.submitSite" {
+		t.Errorf("expected location from pcs, got %s", frames[0].Function)
+	}
+
+	if len(frames[0].Stack) == 0 || frames[0].Stack[0].Function != "github.com/hay-kot/httpkit/errtrace.TestWrapPCs" {
+		t.Errorf("expected remaining pcs as stack, got %+v", frames[0].Stack)
+	}
+
+	data, _ := TraceData(WrapPCs(errors.New("root"), nil, "fallback"))
+	if data == nil || data.Function != "github.com/hay-kot/httpkit/errtrace.TestWrapPCs" {
+		t.Errorf("expected caller location without pcs, got %+v", data)
+	}
+
+	if WrapPCs(nil, pcs, "noop") != nil {
+		t.Error("expected nil error")
+	}
+}
+
+func TestFormatIsEager(t *testing.T) {
+	ids := []int{1, 2}
+	err := New("bad ids %v", ids)
+	wrapped := Wrapf(err, "batch %v", ids)
+	ids[0] = 99
+
+	if got := wrapped.Error(); got != "batch [1 2]" {
+		t.Errorf("expected message formatted at creation, got %q", got)
+	}
+	if got := err.Error(); got != "bad ids [1 2]" {
+		t.Errorf("expected message formatted at creation, got %q", got)
+	}
+}
+
+func TestWrapLazy(t *testing.T) {
+	if WrapLazy(nil, "nil") != nil {
+		t.Error("expected nil for nil error")
+	}
+
+	err := WrapLazy(errors.New("root"), "query user %d", 42)
+	if err.Error() != "query user 42" {
+		t.Errorf("unexpected message %q", err.Error())
+	}
+
+	data, _ := TraceData(err)
+	if data == nil || data.Function != "github.com/hay-kot/httpkit/errtrace.TestWrapLazy" {
+		t.Errorf("expected location of the caller, got %+v", data)
+	}
+}
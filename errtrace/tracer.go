@@ -0,0 +1,262 @@
+package errtrace
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+)
+
+// Config holds the settings a Tracer uses to create and render traces.
+type Config struct {
+	// Cleaner is applied to the file paths of all frames, for example
+	// RelativeCleaner or TrimModuleCleaner. If nil, paths are rendered as recorded.
+	Cleaner func(path string) string
+
+	// Formatter is used by TraceString. If nil, a TextFormatter using Color is used.
+	Formatter Formatter
+
+	// MaxDepth limits the number of errors of a chain that are rendered, see
+	// SetMaxDepth. 0 disables truncation.
+	MaxDepth int
+
+	// Color enables ANSI color codes in the output of the default TextFormatter.
+	Color bool
+
+	// Snippets adds source code snippets to the output of the default
+	// TextFormatter, see EnableSnippets.
+	Snippets bool
+
+	// BuildInfo stamps the first frame of every trace with the build information of
+	// the binary, see EnableBuildInfo.
+	BuildInfo bool
+
+	// Redactor is applied to every rendered error message, see SetRedactor.
+	Redactor func(msg string) string
+
+	// FieldRedactor is applied to every field returned by Fields, see
+	// SetFieldRedactor.
+	FieldRedactor func(key string, value any) any
+
+	// SampleRate is the fraction of errors created by the Tracer, between 0 and 1,
+	// that record the location of the caller, see SetSampling. A rate of 0 is treated
+	// as 1 so the zero Config records every location, use a negative rate to record
+	// none.
+	SampleRate float64
+
+	// GoroutineCapture records the ID of the goroutine errors created by the Tracer
+	// are created on, see EnableGoroutineCapture.
+	GoroutineCapture bool
+}
+
+// Tracer creates and renders traces of errors using its own Config and OnCreate
+// hooks. Tracers are safe for concurrent use and can be reconfigured at any time,
+// which makes it possible to vary the configuration per test or per subsystem without
+// touching the package globals.
+//
+// Errors are not bound to a Tracer. The sampling rate, goroutine capture and hooks of
+// the Tracer that created an error apply when it is created, the Tracer used to
+// render an error decides what the output looks like. The package level functions
+// such as New, TraceString, SetFormatter and SetSampling delegate to the Tracer
+// returned by Default.
+type Tracer struct {
+	cfg atomic.Pointer[Config]
+
+	hooksMu sync.Mutex
+	hooks   atomic.Pointer[[]*createHook]
+
+	// legacy makes the tracer fall back to the OverrideCleaner variable when no
+	// cleaner is configured. It is only set for the default tracer.
+	legacy bool
+}
+
+// NewTracer returns a Tracer with the provided configuration.
+//
+// Example:
+//
+//	tracer := errtrace.NewTracer(errtrace.Config{
+//	  Cleaner:   errtrace.TrimModuleCleaner(),
+//	  Formatter: errtrace.CompactFormatter{},
+//	  MaxDepth:  20,
+//	})
+//	log.Print(tracer.TraceString(err))
+func NewTracer(cfg Config) *Tracer {
+	t := &Tracer{}
+	t.cfg.Store(&cfg)
+	return t
+}
+
+var defaultTracer = func() *Tracer {
+	t := NewTracer(Config{Color: detectColor()})
+	t.legacy = true
+	return t
+}()
+
+// Default returns the Tracer used by the package level functions. Its color setting
+// is detected from the environment, see EnableColor.
+func Default() *Tracer {
+	return defaultTracer
+}
+
+// Config returns a copy of the configuration of the Tracer.
+func (t *Tracer) Config() Config {
+	return *t.cfg.Load()
+}
+
+// Configure atomically updates the configuration of the Tracer. fn receives a copy of
+// the current configuration which replaces it when fn returns.
+//
+// Example:
+//
+//	errtrace.Default().Configure(func(c *errtrace.Config) {
+//	  c.MaxDepth = 50
+//	})
+func (t *Tracer) Configure(fn func(*Config)) {
+	for {
+		old := t.cfg.Load()
+		cfg := *old
+		fn(&cfg)
+
+		if t.cfg.CompareAndSwap(old, &cfg) {
+			return
+		}
+	}
+}
+
+// New is the same as the package level New, but uses the sample rate, goroutine
+// capture and OnCreate hooks of the Tracer.
+func (t *Tracer) New(msg string, args ...any) error {
+	return t.newTraceable(0, nil, msg, args...)
+}
+
+// Wrapf is the same as the package level Wrapf, but uses the sample rate, goroutine
+// capture and OnCreate hooks of the Tracer. If the error is nil, Wrapf returns nil.
+func (t *Tracer) Wrapf(err error, msg string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return t.newTraceable(0, err, msg, args...)
+}
+
+// WrapMsg is the same as the package level WrapMsg, but uses the sample rate,
+// goroutine capture and OnCreate hooks of the Tracer. If the error is nil, WrapMsg
+// returns nil.
+func (t *Tracer) WrapMsg(err error, msg string) error {
+	if err == nil {
+		return nil
+	}
+
+	return t.newTraceableMsg(0, err, msg)
+}
+
+// NewStack is the same as the package level NewStack, but uses the sample rate,
+// goroutine capture and OnCreate hooks of the Tracer.
+func (t *Tracer) NewStack(msg string, args ...any) error {
+	return t.newTraceableStack(0, nil, msg, args...)
+}
+
+// WrapStack is the same as the package level WrapStack, but uses the sample rate,
+// goroutine capture and OnCreate hooks of the Tracer. If the error is nil, WrapStack
+// returns nil.
+func (t *Tracer) WrapStack(err error, msg string, args ...any) error {
+	if err == nil {
+		return nil
+	}
+
+	return t.newTraceableStack(0, err, msg, args...)
+}
+
+// TraceString returns a string representation of err using the configured
+// Formatter.
+func (t *Tracer) TraceString(err error) string {
+	return t.formatter().Format(t.frames(err, t.Config().MaxDepth))
+}
+
+// TraceStringWith returns a string representation of err using the provided
+// formatter.
+func (t *Tracer) TraceStringWith(err error, f Formatter) string {
+	return f.Format(t.frames(err, t.Config().MaxDepth))
+}
+
+// MarshalStack returns the frames of err, see the package level MarshalStack.
+func (t *Tracer) MarshalStack(err error) any {
+	return t.frames(err, t.Config().MaxDepth)
+}
+
+// JSON returns the JSON encoding of the frames of err, see the package level
+// MarshalJSON.
+func (t *Tracer) JSON(err error) ([]byte, error) {
+	return json.Marshal(t.frames(err, t.Config().MaxDepth))
+}
+
+// Fields returns the fields of err, see the package level Fields, with the
+// FieldRedactor of the Tracer applied.
+func (t *Tracer) Fields(err error) map[string]any {
+	return t.redactFields(collectFields(err))
+}
+
+func (t *Tracer) formatter() Formatter {
+	cfg := t.cfg.Load()
+	if cfg.Formatter == nil {
+		return TextFormatter{Color: cfg.Color, Snippets: cfg.Snippets}
+	}
+
+	return cfg.Formatter
+}
+
+// sampleRate returns the configured sample rate clamped to [0, 1].
+func (t *Tracer) sampleRate() float64 {
+	rate := t.cfg.Load().SampleRate
+	if rate == 0 {
+		return 1
+	}
+
+	return clampRate(rate)
+}
+
+// clean applies the configured cleaner to path.
+func (t *Tracer) clean(path string) string {
+	if cleaner := t.cfg.Load().Cleaner; cleaner != nil {
+		return cleaner(path)
+	}
+
+	if t.legacy && OverrideCleaner != nil {
+		return OverrideCleaner(path)
+	}
+
+	return path
+}
+
+// redact applies the configured redactor to msg.
+func (t *Tracer) redact(msg string) string {
+	if fn := t.cfg.Load().Redactor; fn != nil {
+		return fn(msg)
+	}
+
+	return msg
+}
+
+// redactFields applies the configured field redactor to fields.
+func (t *Tracer) redactFields(fields map[string]any) map[string]any {
+	fn := t.cfg.Load().FieldRedactor
+	if fn == nil {
+		return fields
+	}
+
+	for k, v := range fields {
+		fields[k] = fn(k, v)
+	}
+
+	return fields
+}
+
+// frames returns the frames of the chain of err truncated to depth.
+func (t *Tracer) frames(err error, depth int) []Frame {
+	frames := t.collectFrames(err, depth)
+
+	if len(frames) > 0 && t.cfg.Load().BuildInfo {
+		frames[0].Build = currentBuildInfo()
+	}
+
+	return frames
+}
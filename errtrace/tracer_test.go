@@ -0,0 +1,132 @@
+package errtrace
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestTracer(t *testing.T) {
+	err := Wrapf(Wrapf(Wrapf(errors.New("root"), "one"), "two"), "three")
+
+	tracer := NewTracer(Config{
+		Cleaner:   filepath.Base,
+		Formatter: CompactFormatter{},
+		MaxDepth:  2,
+	})
+
+	got := tracer.TraceString(err)
+	if !strings.HasPrefix(got, "three [tracer_test.go:") || !strings.HasSuffix(got, "TestTracer] <- … 2 more") {
+		t.Errorf("expected compact output truncated to 2 errors, got %q", got)
+	}
+
+	frames := tracer.MarshalStack(err).([]Frame)
+	if frames[0].Source != "tracer_test.go" {
+		t.Errorf("expected cleaner to be applied, got %q", frames[0].Source)
+	}
+
+	if strings.Contains(TraceStringPlain(err), "… 2 more") || chainFrames(err)[0].Source == "tracer_test.go" {
+		t.Error("expected the default tracer to be unaffected")
+	}
+
+	b, jerr := tracer.JSON(err)
+	if jerr != nil || !strings.Contains(string(b), `"source":"tracer_test.go"`) {
+		t.Errorf("unexpected JSON %s %v", b, jerr)
+	}
+
+	tracer.Configure(func(c *Config) {
+		c.Formatter = nil
+		c.MaxDepth = 0
+	})
+
+	if got := tracer.TraceString(err); strings.Count(got, "trace error:") != 3 {
+		t.Errorf("expected reconfigured tracer to render the full chain, got:\n%s", got)
+	}
+}
+
+func TestTracerConfigureConcurrent(t *testing.T) {
+	tracer := NewTracer(Config{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tracer.Configure(func(c *Config) { c.MaxDepth++ })
+			_ = tracer.TraceString(New("boom"))
+		}()
+	}
+	wg.Wait()
+
+	if tracer.Config().MaxDepth != 50 {
+		t.Errorf("expected all updates to be applied, got %d", tracer.Config().MaxDepth)
+	}
+}
+
+func TestDefaultTracerCleaner(t *testing.T) {
+	defer Default().Configure(func(c *Config) { c.Cleaner = nil })
+
+	Default().Configure(func(c *Config) { c.Cleaner = filepath.Base })
+
+	data, _ := TraceData(New("boom"))
+	if data.File != "tracer_test.go" {
+		t.Errorf("expected default cleaner to apply to TraceData, got %q", data.File)
+	}
+}
+
+func TestTracerSettings(t *testing.T) {
+	tracer := NewTracer(Config{
+		Redactor:         func(msg string) string { return strings.ReplaceAll(msg, "secret", Redacted) },
+		FieldRedactor:    RedactKeys("token"),
+		Snippets:         true,
+		BuildInfo:        true,
+		SampleRate:       -1,
+		GoroutineCapture: true,
+	})
+
+	var created []string
+	remove := tracer.OnCreate(func(d *StackTraceData) {
+		created = append(created, d.Message)
+	})
+	defer remove()
+
+	err := tracer.Wrapf(WrapFields(New("password secret"), "token", "abc"), "login")
+
+	frames := tracer.MarshalStack(err).([]Frame)
+	if frames[0].IsTraceable() || !frames[0].Unsampled {
+		t.Error("expected the sample rate of the tracer to apply")
+	}
+	if frames[0].Goroutine == 0 {
+		t.Error("expected the goroutine of the tracer to be captured")
+	}
+	if frames[0].Build == nil {
+		t.Error("expected build info on the first frame")
+	}
+	if frames[1].Error != "password "+Redacted {
+		t.Errorf("expected message to be redacted, got %q", frames[1].Error)
+	}
+	if got := tracer.Fields(err)["token"]; got != Redacted {
+		t.Errorf("expected field to be redacted, got %v", got)
+	}
+	if !strings.Contains(NewTracer(Config{Snippets: true}).TraceString(New("boom")), "> ") {
+		t.Error("expected snippets in the output of the tracer")
+	}
+	if len(created) != 1 || created[0] != "login" {
+		t.Errorf("expected only errors created by the tracer to run its hooks, got %v", created)
+	}
+
+	if New("plain").(*stacktrace).goroutine != 0 {
+		t.Error("expected the default tracer not to capture goroutines")
+	}
+
+	// rendering with the default tracer is unaffected
+	def := chainFrames(err)
+	if def[0].Build != nil || def[1].Error != "password secret" {
+		t.Errorf("expected the default tracer to be unaffected, got %+v", def)
+	}
+	if Fields(err)["token"] != "abc" {
+		t.Error("expected default fields to be unredacted")
+	}
+}
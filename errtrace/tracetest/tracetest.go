@@ -0,0 +1,105 @@
+// Package tracetest provides test helpers for asserting on errtrace errors without
+// depending on hard-coded line numbers.
+package tracetest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errtrace"
+)
+
+var update = flag.Bool("tracetest.update", false, "update golden files compared with tracetest.Golden")
+
+// Frames returns the frames of err, as returned by errtrace.MarshalStack without any
+// depth limit. It fails the test if err is nil.
+func Frames(t testing.TB, err error) []errtrace.Frame {
+	t.Helper()
+
+	if err == nil {
+		t.Fatal("tracetest: expected an error, got nil")
+	}
+
+	return errtrace.MarshalStackDepth(err, 0).([]errtrace.Frame)
+}
+
+// RequireTraceContains fails the test if none of the traceable frames of err,
+// including captured call stacks and joined branches, was recorded in a function
+// whose fully qualified name contains fn, for example "user.(*Service).Create".
+func RequireTraceContains(t testing.TB, err error, fn string) {
+	t.Helper()
+
+	frames := Frames(t, err)
+	if containsFunc(frames, fn) {
+		return
+	}
+
+	t.Fatalf("tracetest: expected trace to contain function %q, got:\n%s", fn, errtrace.TraceStringPlain(err))
+}
+
+func containsFunc(frames []errtrace.Frame, fn string) bool {
+	for _, f := range frames {
+		if f.Function != "" && strings.Contains(f.Function, fn) {
+			return true
+		}
+
+		if containsFunc(f.Stack, fn) {
+			return true
+		}
+
+		for _, branch := range f.Branches {
+			if containsFunc(branch, fn) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+var locationPattern = regexp.MustCompile(`(?:[^\s:]*/)?([^/\s:]+\.go):\d+`)
+
+// Normalize returns the plain trace of err with every file location reduced to the
+// file name and the line number replaced with "N", e.g. "/src/app/user.go:42"
+// becomes "user.go:N". The result is stable across edits that move code around.
+func Normalize(err error) string {
+	return locationPattern.ReplaceAllString(errtrace.TraceStringPlain(err), "$1:N")
+}
+
+// Golden compares the normalized trace of err, see Normalize, with the contents of
+// the golden file at path and fails the test if they differ. Run the tests with the
+// -tracetest.update flag to write the current trace to the golden file instead.
+//
+// Example:
+//
+//	tracetest.Golden(t, err, "testdata/create_user.golden")
+func Golden(t testing.TB, err error, path string) {
+	t.Helper()
+
+	got := Normalize(err)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("tracetest: creating golden file directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil { //nolint:gosec
+			t.Fatalf("tracetest: writing golden file: %v", err)
+		}
+
+		return
+	}
+
+	want, rerr := os.ReadFile(path)
+	if rerr != nil {
+		t.Fatalf("tracetest: reading golden file, run with -tracetest.update to create it: %v", rerr)
+	}
+
+	if got != string(want) {
+		t.Fatalf("tracetest: trace does not match golden file %s\ngot:\n%s\nwant:\n%s", path, got, want)
+	}
+}
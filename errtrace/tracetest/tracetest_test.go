@@ -0,0 +1,27 @@
+package tracetest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errtrace"
+)
+
+func createUser() error {
+	return errtrace.Wrapf(errors.New("duplicate key"), "inserting user")
+}
+
+func TestRequireTraceContains(t *testing.T) {
+	err := errtrace.Join(createUser(), errtrace.NewStack("stack"))
+
+	RequireTraceContains(t, err, "tracetest.createUser")
+	RequireTraceContains(t, err, "tracetest.TestRequireTraceContains")
+
+	if containsFunc(Frames(t, err), "missing.Func") {
+		t.Error("expected unknown function not to be found")
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	Golden(t, errtrace.Wrapf(createUser(), "service"), "testdata/create_user.golden")
+}
@@ -0,0 +1,53 @@
+package errtrace
+
+import "errors"
+
+// As finds the first error in the chain of err that matches T and returns it. It is a
+// generic shorthand for errors.As. Traceable errors and the wrappers of this package
+// are transparent, so sentinel and typed errors are found at any depth.
+//
+// Example:
+//
+//	if pgErr, ok := errtrace.As[*pgconn.PgError](err); ok {
+//	  // handle the database error
+//	}
+func As[T error](err error) (T, bool) {
+	var target T
+	if err == nil {
+		return target, false
+	}
+
+	ok := errors.As(err, &target)
+	return target, ok
+}
+
+// RootCause returns the innermost error in the chain of err that was not created by
+// this package, for example the error returned by a database driver that was wrapped
+// with Wrapf. If the chain only contains errors of this package, the innermost error
+// is returned. Errors created with Join or Append have multiple causes, so the chain
+// ends at the joined error.
+//
+// RootCause returns nil if err is nil.
+func RootCause(err error) error {
+	var cause, last error
+
+	for err != nil {
+		last = err
+
+		switch err.(type) { //nolint:errorlint
+		case *stacktrace, annotation:
+		case *joinError:
+			return err
+		default:
+			cause = err
+		}
+
+		err = errors.Unwrap(err)
+	}
+
+	if cause == nil {
+		return last
+	}
+
+	return cause
+}
@@ -0,0 +1,84 @@
+package errtrace
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+)
+
+type typedError struct {
+	op string
+}
+
+func (e *typedError) Error() string { return "typed " + e.op }
+
+func TestAs(t *testing.T) {
+	typed := &typedError{op: "read"}
+	sentinel := errors.New("sentinel")
+
+	chains := map[string]error{
+		"wrapf":     Wrapf(typed, "wrap"),
+		"stack":     WrapStack(typed, "wrap"),
+		"code":      WithCode(Wrapf(typed, "wrap"), "code"),
+		"fields":    WrapFields(Wrapf(typed, "wrap"), "k", "v"),
+		"status":    WrapStatus(typed, 404, "wrap"),
+		"join":      Join(New("other"), Wrapf(typed, "wrap")),
+		"mixed":     Wrapf(fmt.Errorf("plain: %w", WrapMsg(typed, "msg")), "outer"),
+		"recovered": func() (err error) { defer Recover(&err); panic(typed) }(),
+	}
+
+	for name, err := range chains {
+		got, ok := As[*typedError](err)
+		if !ok || got != typed {
+			t.Errorf("%s: expected As to find the typed error", name)
+		}
+
+		if !errors.Is(Wrapf(fmt.Errorf("%w: %w", sentinel, err), "outer"), sentinel) {
+			t.Errorf("%s: expected errors.Is to find the sentinel", name)
+		}
+	}
+
+	if _, ok := As[*fs.PathError](Wrapf(typed, "wrap")); ok {
+		t.Error("expected As to report false for missing types")
+	}
+
+	if _, ok := As[*typedError](nil); ok {
+		t.Error("expected As to report false for nil")
+	}
+}
+
+func TestRootCause(t *testing.T) {
+	root := errors.New("connection refused")
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"nil", nil, nil},
+		{"plain", root, root},
+		{"wrapped", Wrapf(WithCode(Wrapf(root, "inner"), "code"), "outer"), root},
+	}
+
+	for _, tt := range tests {
+		if got := RootCause(tt.err); got != tt.want { //nolint:errorlint
+			t.Errorf("%s: RootCause() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	mixed := fmt.Errorf("query: %w", Wrapf(root, "inner"))
+	if got := RootCause(Wrapf(mixed, "outer")); got != root { //nolint:errorlint
+		t.Errorf("expected innermost non-traceable error, got %v", got)
+	}
+
+	traced := New("traced root")
+	if got := RootCause(Wrapf(traced, "outer")); got != traced { //nolint:errorlint
+		t.Errorf("expected innermost error when the chain is fully traceable, got %v", got)
+	}
+
+	joined := Join(root, New("other"))
+	if got := RootCause(Wrapf(joined, "outer")); got != joined { //nolint:errorlint
+		t.Errorf("expected chain to end at joined error, got %v", got)
+	}
+}
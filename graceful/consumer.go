@@ -0,0 +1,176 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// UnfinishedMessagesError is returned by Consumer.Start when messages were still
+// being handled once the drain timeout expired.
+type UnfinishedMessagesError struct {
+	Unfinished int
+}
+
+func (e *UnfinishedMessagesError) Error() string {
+	return fmt.Sprintf("consumer stopped with %d unfinished messages", e.Unfinished)
+}
+
+// Consumer is a Plugin that fetches messages from a message queue such as NATS,
+// Kafka or SQS and handles them concurrently under the lifecycle of the Runner.
+// Create one with ConsumerPlugin.
+//
+// When the Runner shuts down, the consumer stops fetching and waits for the
+// messages being handled for up to the drain timeout. Once the drain timeout
+// expires, the context of the handlers is cancelled and the number of unfinished
+// messages is reported. Panics in handlers are recovered and reported as errors.
+type Consumer[T any] struct {
+	name         string
+	fetch        func(ctx context.Context) (T, error)
+	handle       func(ctx context.Context, msg T) error
+	concurrency  int
+	drainTimeout time.Duration
+	onError      func(err error)
+
+	inFlight atomic.Int64
+}
+
+// ConsumerPlugin returns a new Consumer with the given name. fetch is called with
+// a context that is cancelled when the Runner shuts down and blocks until the next
+// message is available. handle is called with every fetched message and is
+// responsible for acknowledging it. A fetch error that is not caused by the
+// shutdown stops the consumer and is returned from Start once the messages being
+// handled are drained.
+//
+// Messages are handled one at a time and the drain timeout defaults to 5 seconds.
+//
+// Example:
+//
+//	consumer := graceful.ConsumerPlugin("orders",
+//	  func(ctx context.Context) (*nats.Msg, error) {
+//	    return sub.NextMsgWithContext(ctx)
+//	  },
+//	  func(ctx context.Context, msg *nats.Msg) error {
+//	    if err := orders.Process(ctx, msg.Data); err != nil {
+//	      return errors.Join(err, msg.Nak())
+//	    }
+//	    return msg.Ack()
+//	  },
+//	).Concurrency(8)
+func ConsumerPlugin[T any](
+	name string,
+	fetch func(ctx context.Context) (T, error),
+	handle func(ctx context.Context, msg T) error,
+) *Consumer[T] {
+	return &Consumer[T]{
+		name:         name,
+		fetch:        fetch,
+		handle:       handle,
+		concurrency:  1,
+		drainTimeout: 5 * time.Second,
+		onError:      func(error) {}, // NOOP
+	}
+}
+
+func (c *Consumer[T]) Name() string {
+	return c.name
+}
+
+// Concurrency sets the number of messages handled at the same time. No message is
+// fetched while every handler is busy. It returns the Consumer for chaining.
+func (c *Consumer[T]) Concurrency(n int) *Consumer[T] {
+	c.concurrency = max(n, 1)
+	return c
+}
+
+// DrainTimeout sets the time the consumer waits for the messages being handled
+// after shutdown begins. It should be lower than the shutdown timeout of the plugin
+// in the Runner. It returns the Consumer for chaining.
+func (c *Consumer[T]) DrainTimeout(d time.Duration) *Consumer[T] {
+	c.drainTimeout = d
+	return c
+}
+
+// OnError sets the function called with the error when a handler returns an error
+// or panics. It returns the Consumer for chaining.
+func (c *Consumer[T]) OnError(fn func(err error)) *Consumer[T] {
+	c.onError = fn
+	return c
+}
+
+// InFlight returns the number of messages being handled.
+func (c *Consumer[T]) InFlight() int {
+	return int(c.inFlight.Load())
+}
+
+// Start fetches and handles messages until ctx is cancelled or fetching fails, then
+// waits for the messages being handled. It returns an *UnfinishedMessagesError if
+// messages were still being handled once the drain timeout expired.
+func (c *Consumer[T]) Start(ctx context.Context) error {
+	// handlers keep their context while the consumer is drained
+	handleCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	var (
+		wg       sync.WaitGroup
+		slots    = make(chan struct{}, c.concurrency)
+		fetchErr error
+	)
+
+fetch:
+	for {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			break fetch
+		}
+
+		msg, err := c.fetch(ctx)
+		if err != nil {
+			<-slots
+			if ctx.Err() == nil {
+				fetchErr = fmt.Errorf("fetch: %w", err)
+			}
+			break
+		}
+
+		c.inFlight.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			defer c.inFlight.Add(-1)
+
+			err := runJob(handleCtx, func(ctx context.Context) error {
+				return c.handle(ctx, msg)
+			})
+			if err != nil {
+				c.onError(err)
+			}
+		}()
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(c.drainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		return fetchErr
+	case <-timer.C:
+	}
+
+	unfinished := c.InFlight()
+	cancelHandlers()
+
+	<-drained
+	return errors.Join(fetchErr, &UnfinishedMessagesError{Unfinished: unfinished})
+}
@@ -0,0 +1,115 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/errtrace"
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+// queue returns a fetch func that returns the messages of ch until it is closed,
+// and then fails with errClosed.
+func queue(ch <-chan int, errClosed error) func(ctx context.Context) (int, error) {
+	return func(ctx context.Context) (int, error) {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return 0, errClosed
+			}
+			return msg, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+func Test_Consumer_Drain(t *testing.T) {
+	msgs := make(chan int, 4)
+	for i := range 4 {
+		msgs <- i
+	}
+
+	var (
+		handled atomic.Int32
+		fetched = make(chan struct{}, 4)
+	)
+
+	consumer := graceful.ConsumerPlugin("orders", queue(msgs, nil), func(ctx context.Context, msg int) error {
+		fetched <- struct{}{}
+		time.Sleep(5 * time.Millisecond)
+		handled.Add(1)
+		return nil
+	}).Concurrency(4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- consumer.Start(ctx) }()
+
+	for range 4 {
+		<-fetched
+	}
+	cancel()
+
+	assert(t, <-errCh, nil)
+	assert(t, handled.Load(), int32(4))
+	assert(t, consumer.InFlight(), 0)
+}
+
+func Test_Consumer_UnfinishedAfterDrainTimeout(t *testing.T) {
+	msgs := make(chan int, 2)
+	msgs <- 1
+	msgs <- 2
+
+	fetched := make(chan struct{}, 2)
+	consumer := graceful.ConsumerPlugin("orders", queue(msgs, nil), func(ctx context.Context, msg int) error {
+		fetched <- struct{}{}
+		<-ctx.Done()
+		return ctx.Err()
+	}).Concurrency(2).DrainTimeout(5 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- consumer.Start(ctx) }()
+
+	<-fetched
+	<-fetched
+	cancel()
+
+	var unfinished *graceful.UnfinishedMessagesError
+	if err := <-errCh; !errors.As(err, &unfinished) {
+		t.Fatalf("expected UnfinishedMessagesError, got %v", err)
+	}
+
+	assert(t, unfinished.Unfinished, 2)
+}
+
+func Test_Consumer_Errors(t *testing.T) {
+	var (
+		errClosed = errors.New("subscription closed")
+		errs      = make(chan error, 2)
+		msgs      = make(chan int, 2)
+	)
+
+	msgs <- 1
+	msgs <- 2
+	close(msgs)
+
+	consumer := graceful.ConsumerPlugin("orders", queue(msgs, errClosed), func(ctx context.Context, msg int) error {
+		if msg == 1 {
+			return errors.New("invalid order")
+		}
+		panic("boom")
+	}).OnError(func(err error) { errs <- err })
+
+	err := consumer.Start(context.Background())
+
+	assert(t, errors.Is(err, errClosed), true)
+	assert(t, (<-errs).Error(), "invalid order")
+
+	var panicErr *errtrace.PanicError
+	assert(t, errors.As(<-errs, &panicErr), true)
+}
@@ -0,0 +1,106 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// ErrDBNotOpen is returned by DB.Healthy when the pool is not open.
+var ErrDBNotOpen = errors.New("database pool not open")
+
+// DB is a ReadyPlugin that owns a database pool for the lifecycle of the Runner.
+// Create one with DBPlugin.
+type DB struct {
+	name string
+	open func(ctx context.Context) (io.Closer, error)
+	ping func(ctx context.Context) error
+
+	mu   sync.RWMutex
+	pool io.Closer
+}
+
+// DBPlugin returns a new DB with the given name. When started, it opens the pool
+// with open and checks it with ping, failing the startup of the Runner if either
+// returns an error, and only then reports that it is ready. The pool is closed
+// once the context of the plugin is cancelled, so plugins in later phases, see
+// AddPhase, are stopped before their database is closed.
+//
+// ping is also used as the health check of the plugin, see HealthChecker.
+//
+// Example:
+//
+//	var db *sql.DB
+//	dbPlugin := graceful.DBPlugin("db",
+//	  func(ctx context.Context) (io.Closer, error) {
+//	    var err error
+//	    db, err = sql.Open("pgx", dsn)
+//	    return db, err
+//	  },
+//	  func(ctx context.Context) error { return db.PingContext(ctx) },
+//	)
+//
+//	runner.AddPhase("infra", dbPlugin)
+//	runner.AddPhase("servers", httpPlugin)
+func DBPlugin(name string, open func(ctx context.Context) (io.Closer, error), ping func(ctx context.Context) error) *DB {
+	return &DB{name: name, open: open, ping: ping}
+}
+
+func (d *DB) Name() string {
+	return d.name
+}
+
+// Pool returns the open pool, or nil if it is not open.
+func (d *DB) Pool() io.Closer {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.pool
+}
+
+func (d *DB) Start(ctx context.Context) error {
+	return d.StartReady(ctx, func() {})
+}
+
+// StartReady opens and pings the pool, calls ready, and closes the pool once ctx is
+// done.
+func (d *DB) StartReady(ctx context.Context, ready func()) error {
+	pool, err := d.open(ctx)
+	if err != nil {
+		return fmt.Errorf("open: %w", err)
+	}
+
+	d.mu.Lock()
+	d.pool = pool
+	d.mu.Unlock()
+
+	defer func() {
+		d.mu.Lock()
+		d.pool = nil
+		d.mu.Unlock()
+	}()
+
+	if err := d.ping(ctx); err != nil {
+		return errors.Join(fmt.Errorf("ping: %w", err), pool.Close())
+	}
+
+	ready()
+	<-ctx.Done()
+
+	if err := pool.Close(); err != nil {
+		return fmt.Errorf("close: %w", err)
+	}
+
+	return nil
+}
+
+// Healthy implements HealthChecker by pinging the pool. It returns ErrDBNotOpen if
+// the pool is not open.
+func (d *DB) Healthy(ctx context.Context) error {
+	if d.Pool() == nil {
+		return ErrDBNotOpen
+	}
+
+	return d.ping(ctx)
+}
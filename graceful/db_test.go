@@ -0,0 +1,71 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+type testPool struct {
+	closed atomic.Bool
+}
+
+func (p *testPool) Close() error {
+	p.closed.Store(true)
+	return nil
+}
+
+func Test_DBPlugin(t *testing.T) {
+	pool := &testPool{}
+	db := graceful.DBPlugin("db",
+		func(ctx context.Context) (io.Closer, error) { return pool, nil },
+		func(ctx context.Context) error { return nil },
+	)
+
+	assert(t, db.Healthy(context.Background()), graceful.ErrDBNotOpen)
+
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+	runner.AddPlugin(db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	assert(t, db.Pool(), io.Closer(pool))
+	assert(t, db.Healthy(ctx), nil)
+
+	cancel()
+	assert(t, <-errCh, nil)
+	assert(t, pool.closed.Load(), true)
+	assert(t, db.Pool(), nil)
+}
+
+func Test_DBPlugin_PingFailure(t *testing.T) {
+	var (
+		pool    = &testPool{}
+		errPing = errors.New("connection refused")
+	)
+
+	db := graceful.DBPlugin("db",
+		func(ctx context.Context) (io.Closer, error) { return pool, nil },
+		func(ctx context.Context) error { return errPing },
+	)
+
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+	runner.AddPlugin(db)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(context.Background()) }()
+
+	assert(t, runner.WaitReady(context.Background()), graceful.ErrRunnerStopped)
+
+	err := <-errCh
+	assert(t, errors.Is(err, errPing), true)
+	assert(t, pool.closed.Load(), true)
+}
@@ -0,0 +1,60 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hay-kot/httpkit/errtrace"
+)
+
+// Defer registers a hook that is run every time Start returns, after every plugin
+// has stopped or timed out, including when a plugin failed during startup. Hooks
+// run in reverse registration order with a context that is cancelled after the
+// shutdown timeout of the Runner, see WithTimeout.
+//
+// Errors returned by hooks, and recovered panics, are joined with the error
+// returned by Start.
+//
+// Example:
+//
+//	runner.Defer(func(ctx context.Context) error {
+//	  return tracerProvider.Shutdown(ctx)
+//	})
+func (svr *Runner) Defer(fn func(ctx context.Context) error) {
+	svr.mu.Lock()
+	defer svr.mu.Unlock()
+	svr.deferred = append(svr.deferred, fn)
+}
+
+// runDeferred runs the hooks registered with Defer in reverse registration order
+// and returns their errors joined with errors.Join.
+func (svr *Runner) runDeferred(ctx context.Context) error {
+	svr.mu.Lock()
+	hooks := svr.deferred
+	svr.mu.Unlock()
+
+	if len(hooks) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), svr.opts.timeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		began := time.Now()
+
+		if err := runHook(ctx, hooks[i]); err != nil {
+			svr.log.Error("hook_failed", "duration", time.Since(began), "error", err)
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func runHook(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	defer errtrace.Recover(&err)
+	return fn(ctx)
+}
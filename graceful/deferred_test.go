@@ -0,0 +1,51 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/errtrace"
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Runner_Defer(t *testing.T) {
+	var (
+		log      eventLog
+		errStart = errors.New("failed to start")
+		errHook  = errors.New("failed to flush")
+	)
+
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+	runner.AddFunc("db", func(ctx context.Context) error {
+		<-ctx.Done()
+		log.add("db stopped")
+		return nil
+	})
+	runner.AddFunc("http", func(ctx context.Context) error {
+		return errStart
+	})
+
+	runner.Defer(func(ctx context.Context) error {
+		log.add("first hook")
+		return nil
+	})
+	runner.Defer(func(ctx context.Context) error {
+		log.add("second hook")
+		return errHook
+	})
+	runner.Defer(func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	err := runner.Start(context.Background())
+
+	assert(t, errors.Is(err, errStart), true)
+	assert(t, errors.Is(err, errHook), true)
+
+	var panicErr *errtrace.PanicError
+	assert(t, errors.As(err, &panicErr), true)
+
+	assert(t, log.String(), "db stopped,second hook,first hook")
+}
@@ -0,0 +1,79 @@
+package graceful
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// WithDiagnosticsSignal provides a list of signals that make the Runner write a
+// diagnostics dump with the state of every plugin, the in-flight counts of plugins
+// implementing InFlighter and the stacks of all goroutines, without shutting down.
+// This is useful to inspect a hung service in production.
+//
+// Registering syscall.SIGQUIT replaces the default behavior of the Go runtime,
+// which dumps the goroutine stacks and exits.
+//
+// Example:
+//
+//	graceful.WithDiagnosticsSignal(syscall.SIGQUIT, syscall.SIGUSR2)
+func WithDiagnosticsSignal(signals ...os.Signal) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		o.diagnosticsSignals = signals
+	}
+}
+
+// WithDiagnosticsWriter provides the writer diagnostics dumps are written to, see
+// WithDiagnosticsSignal.
+//
+// Defaults to os.Stderr
+func WithDiagnosticsWriter(w io.Writer) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		o.diagnosticsWriter = w
+	}
+}
+
+// WriteDiagnostics writes a diagnostics dump to w with the state of the Runner and
+// every plugin, the in-flight counts of plugins implementing InFlighter and the
+// stacks of all goroutines.
+func (svr *Runner) WriteDiagnostics(w io.Writer) error {
+	status := svr.Status()
+
+	inFlight := make(map[string]int)
+	for _, p := range svr.currentPlugins() {
+		if f, ok := p.(InFlighter); ok {
+			inFlight[p.Name()] = f.InFlight()
+		}
+	}
+
+	fmt.Fprintf(w, "=== graceful diagnostics %s ===\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(w, "runner: %s (uptime %s)\n\n", status.State, status.Uptime)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PLUGIN\tPHASE\tSTATE\tSINCE\tRESTARTS\tIN-FLIGHT\tERROR")
+	for _, p := range status.Plugins {
+		flight := "-"
+		if n, ok := inFlight[p.Name]; ok {
+			flight = strconv.Itoa(n)
+		}
+
+		since := "-"
+		if !p.Since.IsZero() {
+			since = time.Since(p.Since).Round(time.Millisecond).String()
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%d\t%s\t%s\n", p.Name, p.Phase, p.State, since, p.Restarts, flight, p.Error)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\n=== goroutines (%d) ===\n", runtime.NumGoroutine())
+	return pprof.Lookup("goroutine").WriteTo(w, 2)
+}
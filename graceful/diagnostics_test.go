@@ -0,0 +1,59 @@
+package graceful_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func Test_Runner_WriteDiagnostics(t *testing.T) {
+	runner := graceful.NewRunner()
+
+	pool := graceful.WorkerPoolPlugin("pool", 1).QueueSize(2)
+	assert(t, pool.Submit(func(ctx context.Context) error { return nil }), nil)
+
+	runner.AddPlugin(pool)
+	runner.AddFunc("server", func(ctx context.Context) error { return nil })
+
+	var out bytes.Buffer
+	assert(t, runner.WriteDiagnostics(&out), nil)
+
+	dump := out.String()
+	assert(t, strings.Contains(dump, "runner: idle"), true)
+	assert(t, strings.Contains(dump, "=== goroutines"), true)
+
+	for _, line := range strings.Split(dump, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "pool":
+			assert(t, fields[len(fields)-1], "1")
+		case "server":
+			assert(t, fields[len(fields)-1], "-")
+		}
+	}
+}
@@ -0,0 +1,82 @@
+//go:build !windows
+
+package graceful_test
+
+import (
+	"context"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Runner_WithDiagnosticsSignal(t *testing.T) {
+	var out syncBuffer
+
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithDiagnosticsSignal(syscall.SIGUSR2),
+		graceful.WithDiagnosticsWriter(&out),
+	)
+
+	block := make(chan struct{})
+	pool := graceful.WorkerPoolPlugin("pool", 1).QueueSize(2)
+	for range 2 {
+		assert(t, pool.Submit(func(ctx context.Context) error {
+			<-block
+			return nil
+		}), nil)
+	}
+
+	runner.AddPlugin(pool)
+	runner.AddFunc("server", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+
+	for !strings.Contains(out.String(), "=== goroutines") {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the runner keeps running
+	select {
+	case err := <-errCh:
+		t.Fatalf("runner stopped: %v", err)
+	default:
+	}
+
+	close(block)
+	cancel()
+	assert(t, <-errCh, nil)
+
+	dump := out.String()
+	assert(t, strings.Contains(dump, "runner: ready"), true)
+	assert(t, strings.Contains(dump, "IN-FLIGHT"), true)
+	assert(t, strings.Contains(dump, "goroutine "), true)
+
+	for _, line := range strings.Split(dump, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "pool":
+			assert(t, fields[len(fields)-1], "2")
+		case "server":
+			assert(t, fields[len(fields)-1], "-")
+		}
+	}
+}
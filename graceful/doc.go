@@ -3,6 +3,6 @@
 //
 // It supports
 //   - graceful shutdown of plugins
-//   - timeout for plugins to shutdown
+//   - timeout for plugins to shutdown, globally or per plugin
 //   - os.Signals to listen for (defaults to os.Interrupt and syscall.SIGTERM)
 package graceful
@@ -0,0 +1,177 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+var (
+	ErrPluginRunning  = errors.New("plugin already running")
+	ErrPluginNotFound = errors.New("plugin not running")
+)
+
+// StartPlugin starts p while the Runner is running. The plugin adopts the lifecycle
+// of the Runner: it is configured with the options for its name, and it is stopped
+// before every other plugin when the Runner shuts down.
+//
+// It returns ErrRunnerNotStarted if the Runner is not running, and ErrPluginRunning
+// if a plugin with the same name is running.
+//
+// Example:
+//
+//	if flags.Enabled("billing-consumer") {
+//	  err = runner.StartPlugin(billingConsumer)
+//	} else {
+//	  err = runner.StopPlugin(ctx, billingConsumer.Name())
+//	}
+func (svr *Runner) StartPlugin(p Plugin) error {
+	svr.mu.Lock()
+
+	if svr.runCtx == nil || svr.runCtx.Err() != nil || !svr.stoppedAt.IsZero() {
+		svr.mu.Unlock()
+		return ErrRunnerNotStarted
+	}
+
+	if svr.findRun(p.Name()) != nil {
+		svr.mu.Unlock()
+		return ErrPluginRunning
+	}
+
+	run := newPluginRun(p)
+	ctx := run.withCancel(context.WithoutCancel(svr.runCtx))
+	svr.dynamic = append(svr.dynamic, run)
+	onError := svr.onError
+	svr.mu.Unlock()
+
+	svr.launch(ctx, run, onError)
+	return nil
+}
+
+// StopPlugin stops the running plugin with the given name and waits for it to
+// return from Start within its shutdown timeout, or until ctx is done. The plugin
+// can be any running plugin, including the ones added before the Runner was
+// started. Errors returned by the plugin after it was stopped are ignored.
+//
+// It returns ErrPluginNotFound if no plugin with the given name is running, and a
+// *ShutdownTimeoutError if the plugin did not stop in time.
+func (svr *Runner) StopPlugin(ctx context.Context, name string) error {
+	svr.mu.Lock()
+	run := svr.findRun(name)
+	if run != nil {
+		svr.dynamic = slices.DeleteFunc(svr.dynamic, func(r *pluginRun) bool { return r == run })
+	}
+	svr.mu.Unlock()
+
+	if run == nil {
+		return ErrPluginNotFound
+	}
+
+	run.stopRequested.Store(true)
+	run.setState(PluginStopping, nil)
+	run.cancel()
+	svr.stopRun(ctx, run)
+
+	began := time.Now()
+
+	stopped := make(chan []PluginShutdown, 1)
+	go func() {
+		stopped <- svr.waitStopped([]*pluginRun{run})
+	}()
+
+	select {
+	case plugins := <-stopped:
+		report := ShutdownReport{Cause: "StopPlugin", Duration: time.Since(began), Plugins: plugins}
+		if running := report.Running(); len(running) > 0 {
+			return &ShutdownTimeoutError{Plugins: running, Report: report}
+		}
+
+		svr.log.Info("plugin_removed", "plugin", name)
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// findRun returns the running plugin with the given name, or nil. It must be
+// called with svr.mu held.
+func (svr *Runner) findRun(name string) *pluginRun {
+	for _, run := range svr.startedRuns() {
+		if run.plugin.Name() != name {
+			continue
+		}
+
+		select {
+		case <-run.done:
+		default:
+			return run
+		}
+	}
+
+	return nil
+}
+
+// findStarted returns the plugin with the given name started during the current
+// call to Start, preferring a running one, or nil. It must be called with svr.mu
+// held.
+func (svr *Runner) findStarted(name string) *pluginRun {
+	if run := svr.findRun(name); run != nil {
+		return run
+	}
+
+	for _, run := range svr.startedRuns() {
+		if run.plugin.Name() == name {
+			return run
+		}
+	}
+
+	return nil
+}
+
+// startedRuns returns every plugin started during the current call to Start. It
+// must be called with svr.mu held.
+func (svr *Runner) startedRuns() []*pluginRun {
+	runs := slices.Clone(svr.dynamic)
+	for _, pr := range svr.phaseRuns {
+		runs = append(runs, pr.runs...)
+	}
+
+	return runs
+}
+
+// currentPlugins returns the plugins started during the current call to Start,
+// once per name and preferring a running one, or every added plugin if the Runner
+// has not been started.
+func (svr *Runner) currentPlugins() []Plugin {
+	svr.mu.Lock()
+	defer svr.mu.Unlock()
+
+	runs := svr.startedRuns()
+	if len(runs) == 0 {
+		plugins := make([]Plugin, 0, len(svr.plugins))
+		for _, p := range svr.plugins {
+			p, _ = unwrapPlugin(p)
+			plugins = append(plugins, p)
+		}
+
+		return plugins
+	}
+
+	var (
+		seen    = make(map[string]bool)
+		plugins []Plugin
+	)
+
+	for _, run := range runs {
+		name := run.plugin.Name()
+		if seen[name] {
+			continue
+		}
+
+		seen[name] = true
+		plugins = append(plugins, svr.findStarted(name).plugin)
+	}
+
+	return plugins
+}
@@ -0,0 +1,88 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Runner_StartStopPlugin(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+
+	consumer := graceful.PluginFunc("consumer", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	assert(t, runner.StartPlugin(consumer), graceful.ErrRunnerNotStarted)
+
+	server := plugResults{}
+	runner.AddFunc("server", func(ctx context.Context) error {
+		server.setStart(true)
+		<-ctx.Done()
+		server.setStop(true)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	for runner.Status().State != graceful.RunnerReady {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert(t, runner.StartPlugin(consumer), nil)
+	assert(t, runner.StartPlugin(consumer), graceful.ErrPluginRunning)
+
+	for runner.Status().Plugins[1].State != graceful.PluginRunning {
+		time.Sleep(time.Millisecond)
+	}
+
+	// stopping a plugin doesn't shut the Runner down, even if it returns an error
+	assert(t, runner.StopPlugin(ctx, "consumer"), nil)
+	assert(t, runner.StopPlugin(ctx, "consumer"), graceful.ErrPluginNotFound)
+	assert(t, server.getStop(), false)
+
+	// and it can be started again
+	assert(t, runner.StartPlugin(consumer), nil)
+
+	cancel()
+	assert(t, <-errCh, nil)
+	assert(t, server.getStop(), true)
+	assert(t, len(runner.Errors()), 0)
+	assert(t, errors.Is(runner.StartPlugin(consumer), graceful.ErrRunnerNotStarted), true)
+}
+
+func Test_Runner_StartPlugin_DecoratorAndHealth(t *testing.T) {
+	var runner *graceful.Runner
+	runner = graceful.NewRunner(graceful.WithContextDecorator(func(ctx context.Context, name string) context.Context {
+		// decorators may call the Runner while a plugin is started
+		_ = runner.Status()
+		return ctx
+	}))
+	runner.AddFunc("server", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	for runner.Status().State != graceful.RunnerReady {
+		time.Sleep(time.Millisecond)
+	}
+
+	assert(t, runner.StartPlugin(&healthPlugin{name: "cache", err: errors.New("connection refused")}), nil)
+
+	results := runner.Health(context.Background())
+	assert(t, len(results), 1)
+	assert(t, results["cache"].Error(), "connection refused")
+
+	cancel()
+	assert(t, <-errCh, nil)
+}
@@ -0,0 +1,86 @@
+package graceful
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/hay-kot/httpkit/internal/env"
+)
+
+// envSignals are the signals that can be named in the SIGNALS variable.
+var envSignals = map[string]os.Signal{
+	"SIGINT":  os.Interrupt,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGQUIT": syscall.SIGQUIT,
+}
+
+// NewRunnerFromEnv returns a Runner configured with opts and the environment
+// variables named "<prefix>_<NAME>", for twelve-factor deployments. Variables
+// that are set take precedence over opts:
+//
+//	<prefix>_SHUTDOWN_TIMEOUT     see WithTimeout
+//	<prefix>_SOFT_TIMEOUT         see WithSoftTimeout
+//	<prefix>_STARTUP_TIMEOUT      see WithStartupTimeout
+//	<prefix>_SIGNALS              comma separated list of SIGINT, SIGTERM, SIGHUP
+//	                              and SIGQUIT, or "none" to disable signal
+//	                              handling, see WithSignals
+//	<prefix>_FIRST_ERROR_CANCELS  see WithFirstErrorCancels
+//
+// Durations use the time.ParseDuration format, for example "30s". Every invalid
+// variable is reported in the returned error.
+//
+// Example:
+//
+//	runner, err := graceful.NewRunnerFromEnv("APP", graceful.WithSlog(logger))
+func NewRunnerFromEnv(prefix string, opts ...RunnerOptFunc) (*Runner, error) {
+	r := env.New(prefix)
+
+	if _, ok := r.Lookup("SHUTDOWN_TIMEOUT"); ok {
+		opts = append(opts, WithTimeout(r.Duration("SHUTDOWN_TIMEOUT", 0)))
+	}
+
+	if _, ok := r.Lookup("SOFT_TIMEOUT"); ok {
+		opts = append(opts, WithSoftTimeout(r.Duration("SOFT_TIMEOUT", 0)))
+	}
+
+	if _, ok := r.Lookup("STARTUP_TIMEOUT"); ok {
+		opts = append(opts, WithStartupTimeout(r.Duration("STARTUP_TIMEOUT", 0)))
+	}
+
+	if _, ok := r.Lookup("FIRST_ERROR_CANCELS"); ok {
+		opts = append(opts, WithFirstErrorCancels(r.Bool("FIRST_ERROR_CANCELS", true)))
+	}
+
+	if names, ok := r.List("SIGNALS"); ok {
+		var signals []os.Signal
+		for _, name := range names {
+			name = strings.ToUpper(name)
+			if name == "NONE" {
+				continue
+			}
+
+			if !strings.HasPrefix(name, "SIG") {
+				name = "SIG" + name
+			}
+
+			sig, ok := envSignals[name]
+			if !ok {
+				r.Errorf("SIGNALS", "unsupported signal %q", name)
+				continue
+			}
+
+			signals = append(signals, sig)
+		}
+
+		opts = append(opts, WithSignals(signals...))
+	}
+
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("graceful: invalid environment: %w", err)
+	}
+
+	return NewRunner(opts...), nil
+}
@@ -0,0 +1,65 @@
+package graceful
+
+import (
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func Test_NewRunnerFromEnv(t *testing.T) {
+	t.Setenv("APP_SHUTDOWN_TIMEOUT", "30s")
+	t.Setenv("APP_STARTUP_TIMEOUT", "1m")
+	t.Setenv("APP_SIGNALS", "sigterm, HUP")
+	t.Setenv("APP_FIRST_ERROR_CANCELS", "false")
+
+	runner, err := NewRunnerFromEnv("APP", WithTimeout(time.Second), WithSoftTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	o := runner.opts
+	if o.timeout != 30*time.Second || o.startup != time.Minute {
+		t.Errorf("unexpected timeouts shutdown=%s startup=%s", o.timeout, o.startup)
+	}
+
+	if o.softTimeout != time.Second {
+		t.Errorf("expected unset variables to keep the option value, got %s", o.softTimeout)
+	}
+
+	if len(o.signals) != 2 || o.signals[0] != syscall.SIGTERM || o.signals[1] != syscall.SIGHUP {
+		t.Errorf("unexpected signals %v", o.signals)
+	}
+
+	if !o.keepRunning {
+		t.Error("expected first error not to cancel the runner")
+	}
+}
+
+func Test_NewRunnerFromEnv_Defaults(t *testing.T) {
+	runner, err := NewRunnerFromEnv("UNSET")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if runner.opts.timeout != 5*time.Second || len(runner.opts.signals) != 2 || runner.opts.signals[0] != os.Interrupt {
+		t.Errorf("expected defaults, got %+v", runner.opts)
+	}
+}
+
+func Test_NewRunnerFromEnv_Invalid(t *testing.T) {
+	t.Setenv("APP_SHUTDOWN_TIMEOUT", "soon")
+	t.Setenv("APP_SIGNALS", "SIGKILL")
+
+	_, err := NewRunnerFromEnv("APP")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, want := range []string{"APP_SHUTDOWN_TIMEOUT", `APP_SIGNALS: unsupported signal "SIGKILL"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %s, got %v", want, err)
+		}
+	}
+}
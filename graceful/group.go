@@ -0,0 +1,107 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PluginGroup is a Plugin that runs a set of plugins as a single unit, so related
+// plugins can be composed and added to a parent Runner together. Create one with
+// Group.
+//
+// The plugins of a group are started in the order they were given, each one only
+// once the previous plugin is ready, see ReadyPlugin, and are stopped in reverse
+// order when the context of the group is cancelled. The group reports that it is
+// ready once every plugin in it is ready. If any plugin fails, the group stops the
+// others and returns the error to the parent Runner.
+type PluginGroup struct {
+	name   string
+	runner *Runner
+}
+
+// Group returns a PluginGroup with the given name that runs plugins in order. The
+// shutdown timeout of every plugin in the group defaults to 5 seconds.
+//
+// The shutdown timeout of the group in the parent Runner should cover the time
+// needed to stop every plugin in the group one after the other.
+//
+// Example:
+//
+//	api := graceful.Group("api", cacheWarmer, httpPlugin).Timeout(10 * time.Second)
+//	runner.AddPlugin(api, workers)
+func Group(name string, plugins ...Plugin) *PluginGroup {
+	runner := NewRunner(WithSignals())
+	for _, p := range plugins {
+		runner.AddPhase(p.Name(), p)
+	}
+
+	return &PluginGroup{name: name, runner: runner}
+}
+
+func (g *PluginGroup) Name() string {
+	return g.name
+}
+
+// Timeout sets the time every plugin in the group is given to stop once the group
+// is stopped. It must be called before the group is started and returns the
+// PluginGroup for chaining.
+func (g *PluginGroup) Timeout(d time.Duration) *PluginGroup {
+	g.runner.opts.timeout = d
+	return g
+}
+
+func (g *PluginGroup) Start(ctx context.Context) error {
+	return g.StartReady(ctx, func() {})
+}
+
+// StartReady starts every plugin in the group and calls ready once all of them are
+// ready. It blocks until ctx is done or a plugin fails, and returns once every
+// plugin in the group has stopped or timed out.
+func (g *PluginGroup) StartReady(ctx context.Context, ready func()) error {
+	waitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		if g.runner.WaitReady(waitCtx) == nil {
+			ready()
+		}
+	}()
+
+	return g.runner.Start(ctx)
+}
+
+// Validate implements Validator by validating the plugins of the group, see
+// Runner.Validate.
+func (g *PluginGroup) Validate() error {
+	return g.runner.Validate()
+}
+
+// Healthy implements HealthChecker by checking every plugin in the group that
+// implements HealthChecker.
+func (g *PluginGroup) Healthy(ctx context.Context) error {
+	results := g.runner.Health(ctx)
+
+	names := make([]string, 0, len(results))
+	for name := range results {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var errs []error
+	for _, name := range names {
+		if err := results[name]; err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Reload implements Reloader by reloading every plugin in the group that
+// implements Reloader.
+func (g *PluginGroup) Reload(ctx context.Context) error {
+	return g.runner.Reload(ctx)
+}
@@ -0,0 +1,67 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Group(t *testing.T) {
+	var log eventLog
+
+	plugin := func(name string) graceful.Plugin {
+		return graceful.ReadyPluginFunc(name, func(ctx context.Context, ready func()) error {
+			log.add(name + " started")
+			ready()
+
+			<-ctx.Done()
+			log.add(name + " stopped")
+			return nil
+		})
+	}
+
+	runner := graceful.NewRunner(graceful.WithTimeout(50 * time.Millisecond))
+	runner.AddPhase("api", graceful.Group("api", plugin("cache"), plugin("http")).Timeout(20*time.Millisecond))
+	runner.AddPhase("workers", plugin("worker"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	cancel()
+	assert(t, <-errCh, nil)
+
+	assert(t, log.String(), "cache started,http started,worker started,worker stopped,http stopped,cache stopped")
+}
+
+func Test_Group_Error(t *testing.T) {
+	errCache := errors.New("cache unavailable")
+
+	var log eventLog
+
+	group := graceful.Group("api",
+		graceful.PluginFunc("cache", func(ctx context.Context) error {
+			return errCache
+		}),
+		&healthPlugin{name: "http", err: errors.New("not serving")},
+	)
+
+	assert(t, group.Healthy(context.Background()).Error(), "plugin http: not serving")
+
+	runner := graceful.NewRunner(graceful.WithTimeout(50 * time.Millisecond))
+	runner.AddPlugin(group)
+	runner.AddFunc("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		log.add("worker stopped")
+		return nil
+	})
+
+	err := runner.Start(context.Background())
+
+	assert(t, errors.Is(err, errCache), true)
+	assert(t, log.String(), "worker stopped")
+}
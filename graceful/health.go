@@ -0,0 +1,82 @@
+package graceful
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/server"
+)
+
+// Health runs the health checks of every plugin that implements HealthChecker
+// concurrently, including the plugins started with StartPlugin, and returns the
+// result keyed by plugin name. A nil value means the plugin is healthy. Plugins
+// that do not implement HealthChecker are omitted.
+func (svr *Runner) Health(ctx context.Context) map[string]error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]error)
+	)
+
+	for _, p := range svr.currentPlugins() {
+		hc, ok := p.(HealthChecker)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := hc.Healthy(ctx)
+
+			mu.Lock()
+			results[p.Name()] = err
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// HealthResponse is the body written by Runner.HealthHandler.
+type HealthResponse struct {
+	// Status is "ok" when every plugin is healthy, and "unhealthy" otherwise.
+	Status string `json:"status"`
+	// Plugins maps the name of every plugin implementing HealthChecker to "ok" or
+	// the error returned by its health check.
+	Plugins map[string]string `json:"plugins"`
+}
+
+// HealthHandler returns a handler that runs Runner.Health and renders the result
+// as a HealthResponse, with a 200 OK status when every plugin is healthy and a 503
+// Service Unavailable status otherwise, so it can be used directly as a load
+// balancer health check.
+//
+// Example:
+//
+//	mux.Get("/healthz", runner.HealthHandler())
+func (svr *Runner) HealthHandler() errchain.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var (
+			status = http.StatusOK
+			resp   = HealthResponse{Status: "ok", Plugins: make(map[string]string)}
+		)
+
+		for name, err := range svr.Health(r.Context()) {
+			if err != nil {
+				status = http.StatusServiceUnavailable
+				resp.Status = "unhealthy"
+				resp.Plugins[name] = err.Error()
+				continue
+			}
+
+			resp.Plugins[name] = "ok"
+		}
+
+		return server.JSON(w, status, resp)
+	}
+}
@@ -0,0 +1,96 @@
+package graceful_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+type healthPlugin struct {
+	name string
+	err  error
+}
+
+func (p *healthPlugin) Name() string { return p.name }
+
+func (p *healthPlugin) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (p *healthPlugin) Healthy(context.Context) error { return p.err }
+
+func Test_Runner_Health(t *testing.T) {
+	runner := graceful.NewRunner()
+	runner.AddPlugin(
+		&healthPlugin{name: "db"},
+		&healthPlugin{name: "cache", err: errors.New("connection refused")},
+	)
+	runner.AddFunc("no-checks", func(ctx context.Context) error { return nil })
+
+	results := runner.Health(context.Background())
+
+	assert(t, len(results), 2)
+	assert(t, results["db"], nil)
+	assert(t, results["cache"].Error(), "connection refused")
+}
+
+func Test_Runner_HealthHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		plugins    []graceful.Plugin
+		wantStatus int
+		want       graceful.HealthResponse
+	}{
+		{
+			name:       "healthy",
+			plugins:    []graceful.Plugin{&healthPlugin{name: "db"}},
+			wantStatus: http.StatusOK,
+			want:       graceful.HealthResponse{Status: "ok", Plugins: map[string]string{"db": "ok"}},
+		},
+		{
+			name: "unhealthy",
+			plugins: []graceful.Plugin{
+				&healthPlugin{name: "db"},
+				&healthPlugin{name: "cache", err: errors.New("connection refused")},
+			},
+			wantStatus: http.StatusServiceUnavailable,
+			want: graceful.HealthResponse{
+				Status:  "unhealthy",
+				Plugins: map[string]string{"db": "ok", "cache": "connection refused"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := graceful.NewRunner()
+			runner.AddPlugin(tt.plugins...)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+
+			if err := runner.HealthHandler()(w, r); err != nil {
+				t.Fatal(err)
+			}
+
+			assert(t, w.Code, tt.wantStatus)
+
+			var got graceful.HealthResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			assert(t, got.Status, tt.want.Status)
+			assert(t, len(got.Plugins), len(tt.want.Plugins))
+			for name, v := range tt.want.Plugins {
+				assert(t, got.Plugins[name], v)
+			}
+		})
+	}
+}
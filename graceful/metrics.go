@@ -0,0 +1,184 @@
+package graceful
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// observer receives lifecycle measurements of the Runner, see Collector.
+type observer interface {
+	pluginStarted(name string, d time.Duration)
+	pluginStopped(name string, d time.Duration)
+}
+
+// observe calls fn with every observer of the Runner.
+func (svr *Runner) observe(fn func(o observer)) {
+	svr.mu.Lock()
+	observers := svr.observers
+	svr.mu.Unlock()
+
+	for _, o := range observers {
+		fn(o)
+	}
+}
+
+var (
+	pluginStates = []PluginState{
+		PluginPending, PluginStarting, PluginRunning, PluginRestarting,
+		PluginStopping, PluginStopped, PluginFailed,
+	}
+	runnerStates = []RunnerState{
+		RunnerIdle, RunnerStarting, RunnerReady, RunnerDraining, RunnerStopped,
+	}
+)
+
+type collector struct {
+	runner *Runner
+
+	runnerState *prometheus.Desc
+	pluginState *prometheus.Desc
+	restarts    *prometheus.Desc
+	lastError   *prometheus.Desc
+	startup     *prometheus.HistogramVec
+	shutdown    *prometheus.HistogramVec
+}
+
+// Collector returns a prometheus.Collector exporting the state of r and its
+// plugins, so the health of the Runner can be scraped alongside the HTTP metrics,
+// see middleware.Metrics.
+//
+// The following metrics are exported, labeled by plugin:
+//   - graceful_plugin_state, 1 for the current state of the plugin and 0 otherwise
+//   - graceful_plugin_restarts_total
+//   - graceful_plugin_last_error_timestamp_seconds, for plugins that returned an error
+//   - graceful_plugin_startup_duration_seconds, the time until a plugin is ready
+//   - graceful_plugin_shutdown_duration_seconds, the time until a plugin stopped
+//
+// Additionally graceful_runner_state reports the state of the Runner.
+//
+// Example:
+//
+//	registry.MustRegister(graceful.Collector(runner))
+func Collector(r *Runner) prometheus.Collector {
+	c := &collector{
+		runner: r,
+		runnerState: prometheus.NewDesc(
+			"graceful_runner_state",
+			"State of the Runner, 1 for the current state and 0 otherwise.",
+			[]string{"state"}, nil,
+		),
+		pluginState: prometheus.NewDesc(
+			"graceful_plugin_state",
+			"State of the plugin, 1 for the current state and 0 otherwise.",
+			[]string{"plugin", "state"}, nil,
+		),
+		restarts: prometheus.NewDesc(
+			"graceful_plugin_restarts_total",
+			"Number of times the plugin was restarted since the Runner was started.",
+			[]string{"plugin"}, nil,
+		),
+		lastError: prometheus.NewDesc(
+			"graceful_plugin_last_error_timestamp_seconds",
+			"Unix time of the last error returned by the plugin.",
+			[]string{"plugin"}, nil,
+		),
+		startup: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "graceful_plugin_startup_duration_seconds",
+			Help:    "Time from starting the plugin until it was ready in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"plugin"}),
+		shutdown: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "graceful_plugin_shutdown_duration_seconds",
+			Help:    "Time from cancelling the plugin until it stopped or timed out in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"plugin"}),
+	}
+
+	r.mu.Lock()
+	r.observers = append(r.observers, c)
+	r.mu.Unlock()
+
+	return c
+}
+
+func (c *collector) pluginStarted(name string, d time.Duration) {
+	c.startup.WithLabelValues(name).Observe(d.Seconds())
+}
+
+func (c *collector) pluginStopped(name string, d time.Duration) {
+	c.shutdown.WithLabelValues(name).Observe(d.Seconds())
+}
+
+// Describe implements prometheus.Collector.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.runnerState
+	ch <- c.pluginState
+	ch <- c.restarts
+	ch <- c.lastError
+	c.startup.Describe(ch)
+	c.shutdown.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	status := c.runner.Status()
+
+	for _, state := range runnerStates {
+		ch <- prometheus.MustNewConstMetric(c.runnerState, prometheus.GaugeValue, boolValue(status.State == state), string(state))
+	}
+
+	for _, ps := range uniquePlugins(status.Plugins) {
+		for _, state := range pluginStates {
+			ch <- prometheus.MustNewConstMetric(c.pluginState, prometheus.GaugeValue, boolValue(ps.State == state), ps.Name, string(state))
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.restarts, prometheus.CounterValue, float64(ps.Restarts), ps.Name)
+
+		if ps.ErrorAt != nil {
+			ch <- prometheus.MustNewConstMetric(c.lastError, prometheus.GaugeValue, float64(ps.ErrorAt.UnixNano())/1e9, ps.Name)
+		}
+	}
+
+	c.startup.Collect(ch)
+	c.shutdown.Collect(ch)
+}
+
+// uniquePlugins returns plugins with one status per name, as a plugin stopped with
+// StopPlugin and started again is listed once for every run. A running plugin is
+// preferred over a stopped one, and a later run over an earlier one.
+func uniquePlugins(plugins []PluginStatus) []PluginStatus {
+	var (
+		index  = make(map[string]int)
+		unique []PluginStatus
+	)
+
+	for _, ps := range plugins {
+		i, ok := index[ps.Name]
+		if !ok {
+			index[ps.Name] = len(unique)
+			unique = append(unique, ps)
+			continue
+		}
+
+		if stopped(ps.State) && !stopped(unique[i].State) {
+			continue
+		}
+
+		unique[i] = ps
+	}
+
+	return unique
+}
+
+func stopped(state PluginState) bool {
+	return state == PluginStopped || state == PluginFailed
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,94 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Collector(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithRestart("worker", graceful.Restart{Max: 1}),
+	)
+
+	runner.AddFunc("worker", func(ctx context.Context) error {
+		return errors.New("failed")
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(graceful.Collector(runner))
+
+	_ = runner.Start(context.Background())
+
+	expected := `
+# HELP graceful_plugin_restarts_total Number of times the plugin was restarted since the Runner was started.
+# TYPE graceful_plugin_restarts_total counter
+graceful_plugin_restarts_total{plugin="worker"} 1
+# HELP graceful_runner_state State of the Runner, 1 for the current state and 0 otherwise.
+# TYPE graceful_runner_state gauge
+graceful_runner_state{state="draining"} 0
+graceful_runner_state{state="idle"} 0
+graceful_runner_state{state="ready"} 0
+graceful_runner_state{state="starting"} 0
+graceful_runner_state{state="stopped"} 1
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"graceful_plugin_restarts_total", "graceful_runner_state")
+	if err != nil {
+		t.Error(err)
+	}
+
+	count, err := testutil.GatherAndCount(registry,
+		"graceful_plugin_state",
+		"graceful_plugin_last_error_timestamp_seconds",
+		"graceful_plugin_startup_duration_seconds",
+		"graceful_plugin_shutdown_duration_seconds",
+	)
+	assert(t, err, nil)
+	assert(t, count, 10) // 7 states, last error and both histograms
+}
+
+func Test_Collector_RestartedPlugin(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+
+	worker := graceful.PluginFunc("worker", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	runner.AddPlugin(worker)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(graceful.Collector(runner))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	for runner.Status().State != graceful.RunnerReady {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the stopped run and the new run share the plugin name
+	assert(t, runner.StopPlugin(ctx, "worker"), nil)
+	assert(t, runner.StartPlugin(worker), nil)
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP graceful_plugin_restarts_total Number of times the plugin was restarted since the Runner was started.
+# TYPE graceful_plugin_restarts_total counter
+graceful_plugin_restarts_total{plugin="worker"} 0
+`), "graceful_plugin_restarts_total")
+	if err != nil {
+		t.Error(err)
+	}
+
+	cancel()
+	assert(t, <-errCh, nil)
+}
@@ -0,0 +1,85 @@
+package graceful
+
+import "context"
+
+// phase is a group of plugins that are started together.
+type phase struct {
+	name    string
+	plugins []Plugin
+}
+
+// AddPhase adds a named phase of plugins to the Runner. Phases are started in the
+// order they were added, and the plugins of a phase are only started once every
+// plugin of the previous phase is ready, see ReadyPlugin. A ReadyPlugin that stops
+// without calling ready holds back the following phases. On shutdown the phases
+// are stopped in reverse order, so the plugins of a phase are only cancelled once
+// every plugin of the following phases has stopped. Every phase is given its own
+// shutdown timeout, see WithTimeout, so the total shutdown can take up to the
+// number of phases times the timeout.
+//
+// This covers the common case of starting servers only after the infrastructure
+// they depend on is available, without declaring a full dependency graph.
+//
+// Example:
+//
+//	runner.AddPhase("infra", dbPlugin, cachePlugin)
+//	runner.AddPhase("servers", httpPlugin)
+func (svr *Runner) AddPhase(name string, p ...Plugin) {
+	for _, plugin := range p {
+		plugin, _ = unwrapPlugin(plugin)
+		svr.plugins = append(svr.plugins, plugin)
+	}
+	svr.phases = append(svr.phases, &phase{name: name, plugins: p})
+}
+
+// phaseRun tracks the plugins of a started phase.
+type phaseRun struct {
+	name   string
+	cancel context.CancelFunc
+	runs   []*pluginRun
+}
+
+// startPhase records and starts every plugin of ph with a context that is only
+// cancelled by the cancel func of the phaseRun, see launch. The plugins are
+// recorded before any of them is started, so they can depend on each other with
+// StartAfter.
+func (svr *Runner) startPhase(ctx context.Context, ph *phase, onError func(Plugin, error)) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+
+	pr := &phaseRun{name: ph.name, cancel: cancel}
+	runCtxs := make([]context.Context, 0, len(ph.plugins))
+	for _, p := range ph.plugins {
+		run := newPluginRun(p)
+		pr.runs = append(pr.runs, run)
+		runCtxs = append(runCtxs, run.withCancel(ctx))
+	}
+
+	svr.mu.Lock()
+	svr.phaseRuns = append(svr.phaseRuns, pr)
+	svr.mu.Unlock()
+
+	for i, run := range pr.runs {
+		svr.launch(runCtxs[i], run, onError)
+	}
+}
+
+// phaseRun returns the started phase at index i.
+func (svr *Runner) phaseRun(i int) *phaseRun {
+	svr.mu.Lock()
+	defer svr.mu.Unlock()
+	return svr.phaseRuns[i]
+}
+
+// waitReady blocks until every plugin of the phase is ready. It returns false if
+// ctx is done first.
+func (pr *phaseRun) waitReady(ctx context.Context) bool {
+	for _, run := range pr.runs {
+		select {
+		case <-run.ready:
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,63 @@
+package graceful_test
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+type eventLog struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *eventLog) add(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *eventLog) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return strings.Join(l.events, ",")
+}
+
+func Test_Runner_AddPhase(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(50 * time.Millisecond))
+
+	var log eventLog
+
+	runner.AddPhase("infra", graceful.ReadyPluginFunc("db", func(ctx context.Context, ready func()) error {
+		time.Sleep(5 * time.Millisecond)
+		log.add("db started")
+		ready()
+
+		<-ctx.Done()
+		log.add("db stopped")
+		return nil
+	}))
+
+	runner.AddPhase("servers", graceful.PluginFunc("http", func(ctx context.Context) error {
+		log.add("http started")
+
+		<-ctx.Done()
+		time.Sleep(5 * time.Millisecond)
+		log.add("http stopped")
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	cancel()
+	assert(t, <-errCh, nil)
+
+	assert(t, log.String(), "db started,http started,http stopped,db stopped")
+}
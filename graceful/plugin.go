@@ -1,10 +1,15 @@
 package graceful
 
-import "context"
+import (
+	"context"
+
+	"github.com/hay-kot/httpkit/errtrace"
+)
 
 // Plugin defines the interface for a plugin that can be started and stopped via the
 // Runner. The plugin should be blocking until the context is cancelled. Note that startup
 // order for plugins is non-deterministic and should not be relied upon. If you need to
+// start plugins in order, add them as phases with Runner.AddPhase, otherwise you
 // will need to manage coordination between plugins.
 type Plugin interface {
 	// Name returns the name of the plugin. This can be any identifier you like and
@@ -15,10 +20,11 @@ type Plugin interface {
 	// if the context is cancelled, the plugin should stop, cleanup and return.
 	// The start method _should_ be blocking, until the context is cancelled.
 	// If the plugin terminates early with an error, it will cause a shutdown
-	// in the system.
+	// in the system. A panic is recovered and handled the same way as an error.
 	//
 	// Errors during shutdown should be logged within your plugin. Errors
-	// that occur during the shutdown process will be ignored by the server.
+	// that occur during the shutdown process will be ignored by the server,
+	// but are available from Runner.Errors.
 	//
 	// Example:
 	//   func (p *MyPlugin) Start(ctx context.Context) error {
@@ -30,6 +36,44 @@ type Plugin interface {
 	Start(ctx context.Context) error
 }
 
+// ReadyPlugin is an optional interface for plugins that take time to start serving,
+// such as a server that has to bind a port or a consumer that has to connect to a
+// broker. The Runner calls StartReady instead of Start, and the plugin is not
+// considered ready until it calls ready. Plugins that do not implement ReadyPlugin
+// are considered ready as soon as they are started.
+//
+// See Runner.WaitReady.
+type ReadyPlugin interface {
+	Plugin
+
+	// StartReady behaves like Start, but must call ready once the plugin is
+	// serving. Calling ready more than once is a no-op.
+	//
+	// Example:
+	//   func (p *MyPlugin) StartReady(ctx context.Context, ready func()) error {
+	//     ln, err := net.Listen("tcp", p.addr)
+	//     if err != nil {
+	//       return err
+	//     }
+	//     ready()
+	//     return p.serve(ctx, ln)
+	//   }
+	StartReady(ctx context.Context, ready func()) error
+}
+
+// startPlugin starts p, calling ready once it is serving. A panic in p is
+// recovered and returned as a traceable error wrapping an *errtrace.PanicError.
+func startPlugin(ctx context.Context, p Plugin, ready func()) (err error) {
+	defer errtrace.Recover(&err)
+
+	if rp, ok := p.(ReadyPlugin); ok {
+		return rp.StartReady(ctx, ready)
+	}
+
+	ready()
+	return p.Start(ctx)
+}
+
 type pluginFunc struct {
 	name  string
 	start func(ctx context.Context) error
@@ -46,3 +90,76 @@ func (p *pluginFunc) Start(ctx context.Context) error {
 func PluginFunc(name string, start func(ctx context.Context) error) Plugin {
 	return &pluginFunc{name: name, start: start}
 }
+
+// HealthChecker is an optional interface a Plugin can implement to report its
+// health to the Runner. See Runner.Health and Runner.HealthHandler.
+type HealthChecker interface {
+	// Healthy returns nil when the plugin is healthy, or an error describing why
+	// it is not. It should respect the deadline of ctx.
+	Healthy(ctx context.Context) error
+}
+
+type readyPluginFunc struct {
+	name  string
+	start func(ctx context.Context, ready func()) error
+}
+
+func (p *readyPluginFunc) Name() string {
+	return p.name
+}
+
+func (p *readyPluginFunc) Start(ctx context.Context) error {
+	return p.start(ctx, func() {})
+}
+
+func (p *readyPluginFunc) StartReady(ctx context.Context, ready func()) error {
+	return p.start(ctx, ready)
+}
+
+// ReadyPluginFunc returns a ReadyPlugin with the given name that calls start.
+func ReadyPluginFunc(name string, start func(ctx context.Context, ready func()) error) ReadyPlugin {
+	return &readyPluginFunc{name: name, start: start}
+}
+
+// Reloader is an optional interface a Plugin can implement to reload its
+// configuration without being restarted, for example to re-read a config file or
+// reopen log files. See WithReloadSignal and Runner.Reload.
+type Reloader interface {
+	Reload(ctx context.Context) error
+}
+
+// InFlighter is an optional interface a Plugin can implement to report the number
+// of units of work it is processing, such as requests, jobs or messages. It is
+// included in the diagnostics dump, see WithDiagnosticsSignal.
+type InFlighter interface {
+	InFlight() int
+}
+
+// ForceStopper is an optional interface a Plugin can implement to abort its work
+// when it does not stop within the soft timeout of the Runner, for example by
+// killing running queries or closing open sockets. See WithSoftTimeout.
+type ForceStopper interface {
+	ForceStop()
+}
+
+// Stopper is an optional interface a Plugin can implement to separate stopping to
+// accept work from finishing the work in flight, matching the Serve and Shutdown
+// split of http.Server. Once the context passed to Start is cancelled, the Runner
+// calls Stop with a context that expires after the shutdown timeout of the plugin,
+// and the plugin is only considered stopped once both Start and Stop returned.
+//
+// Example:
+//
+//	func (p *httpPlugin) Start(ctx context.Context) error {
+//	  if err := p.srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+//	    return err
+//	  }
+//	  return nil
+//	}
+//
+//	func (p *httpPlugin) Stop(ctx context.Context) error {
+//	  return p.srv.Shutdown(ctx)
+//	}
+type Stopper interface {
+	Stop(ctx context.Context) error
+}
@@ -0,0 +1,48 @@
+package graceful
+
+import "context"
+
+// Ready returns a channel that is closed once every plugin has been started and
+// reported that it is ready, see ReadyPlugin.
+//
+// Every call to Start uses a new channel, which is returned as soon as the previous
+// call to Start has returned, so the channel should be retrieved again after the
+// Runner is restarted.
+func (svr *Runner) Ready() <-chan struct{} {
+	return svr.currentCycle().ready
+}
+
+// WaitReady blocks until every plugin has been started and reported that it is
+// ready, see ReadyPlugin. It returns ErrRunnerStopped if the Runner begins shutting
+// down first, or the error of ctx if ctx is done first. Once Start has returned,
+// WaitReady waits for the next call to Start.
+//
+// It is intended to be called from main while Start runs in another goroutine,
+// to delay registering the service with service discovery until it is serving.
+//
+// Example:
+//
+//	go func() { errCh <- runner.Start(ctx) }()
+//
+//	if err := runner.WaitReady(ctx); err != nil {
+//	  return err
+//	}
+//	registry.Register(service)
+func (svr *Runner) WaitReady(ctx context.Context) error {
+	cyc := svr.currentCycle()
+
+	select {
+	case <-cyc.ready:
+		return nil
+	case <-cyc.draining:
+		// prefer ready if the Runner was ready before it started draining
+		select {
+		case <-cyc.ready:
+			return nil
+		default:
+			return ErrRunnerStopped
+		}
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,142 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Runner_WaitReady(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+
+	release := make(chan struct{})
+
+	runner.AddFunc("instant", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+	runner.AddPlugin(graceful.ReadyPluginFunc("slow", func(ctx context.Context, ready func()) error {
+		<-release
+		ready()
+		<-ctx.Done()
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	// not ready until the slow plugin reports ready
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer waitCancel()
+
+	err := runner.WaitReady(waitCtx)
+	assert(t, errors.Is(err, context.DeadlineExceeded), true)
+
+	close(release)
+
+	err = runner.WaitReady(context.Background())
+	assert(t, err, nil)
+
+	cancel()
+	assert(t, <-errCh, nil)
+}
+
+func Test_Runner_WaitReady_Stopped(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+
+	runner.AddPlugin(graceful.ReadyPluginFunc("never-ready", func(ctx context.Context, ready func()) error {
+		return errors.New("failed to connect")
+	}))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(context.Background()) }()
+
+	err := runner.WaitReady(context.Background())
+	assert(t, err, graceful.ErrRunnerStopped)
+	assert(t, (<-errCh).Error(), "failed to connect")
+}
+
+func Test_Runner_WaitReady_Restart(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+
+	release := make(chan struct{})
+	runner.AddPlugin(graceful.ReadyPluginFunc("slow", func(ctx context.Context, ready func()) error {
+		select {
+		case <-release:
+			ready()
+		case <-ctx.Done():
+			return nil
+		}
+
+		<-ctx.Done()
+		return nil
+	}))
+
+	// the first run becomes ready and drains
+	close(release)
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	cancel()
+	assert(t, <-errCh, nil)
+
+	// WaitReady waits for the second run instead of answering from the first
+	waitCtx, waitCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer waitCancel()
+	assert(t, errors.Is(runner.WaitReady(waitCtx), context.DeadlineExceeded), true)
+
+	release = make(chan struct{})
+	waitCh := make(chan error, 1)
+	go func() { waitCh <- runner.WaitReady(context.Background()) }()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	go func() { errCh <- runner.Start(ctx) }()
+
+	select {
+	case err := <-waitCh:
+		t.Fatalf("expected WaitReady to block until the second run is ready, got %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(release)
+	assert(t, <-waitCh, nil)
+
+	cancel()
+	assert(t, <-errCh, nil)
+}
+
+func Test_Runner_StartupTimeout(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithStartupTimeout(5*time.Millisecond),
+		graceful.WithPluginStartupTimeout("slow-but-fine", time.Second),
+	)
+
+	runner.AddPlugin(graceful.ReadyPluginFunc("slow-but-fine", func(ctx context.Context, ready func()) error {
+		time.Sleep(10 * time.Millisecond)
+		ready()
+		<-ctx.Done()
+		return nil
+	}))
+	runner.AddPlugin(graceful.ReadyPluginFunc("hangs", func(ctx context.Context, ready func()) error {
+		<-ctx.Done() // connecting to a dependency that never answers
+		return ctx.Err()
+	}))
+
+	err := runner.Start(context.Background())
+
+	var timeoutErr *graceful.StartupTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected StartupTimeoutError, got %v", err)
+	}
+
+	assert(t, timeoutErr.Plugin, "hangs")
+	assert(t, err.Error(), "plugin hangs failed to become ready within 5ms")
+}
@@ -0,0 +1,83 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+// WithReloadSignal provides a list of signals that make the Runner call Reload on
+// every running plugin implementing Reloader, without shutting down.
+//
+// Multiple calls to this option will override the previous signals.
+//
+// Example:
+//
+//	graceful.WithReloadSignal(syscall.SIGHUP)
+func WithReloadSignal(signals ...os.Signal) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		o.reloadSignals = signals
+	}
+}
+
+// Reload calls Reload on every running plugin implementing Reloader, in the order
+// the plugins were started, and returns their errors joined with errors.Join. Each
+// error is prefixed with the name of the plugin.
+func (svr *Runner) Reload(ctx context.Context) error {
+	svr.mu.Lock()
+	var runs []*pluginRun
+	for _, pr := range svr.phaseRuns {
+		runs = append(runs, pr.runs...)
+	}
+	runs = append(runs, svr.dynamic...)
+	svr.mu.Unlock()
+
+	var errs []error
+	for _, run := range runs {
+		r, ok := run.plugin.(Reloader)
+		if !ok || run.currentState() != PluginRunning {
+			continue
+		}
+
+		name := run.plugin.Name()
+		began := time.Now()
+
+		if err := r.Reload(ctx); err != nil {
+			svr.log.Error("plugin_reload_failed", "plugin", name, "duration", time.Since(began), "error", err)
+			errs = append(errs, fmt.Errorf("plugin %s: %w", name, err))
+			continue
+		}
+
+		svr.log.Info("plugin_reloaded", "plugin", name, "duration", time.Since(began))
+	}
+
+	return errors.Join(errs...)
+}
+
+// watchSignals starts listening for signals and returns a function that calls fn
+// with every received signal until ctx is done. The signals are registered before
+// watchSignals returns, so none are missed once Start runs.
+func watchSignals(ctx context.Context, signals []os.Signal, fn func(os.Signal)) func() {
+	if len(signals) == 0 {
+		return func() {}
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, signals...)
+
+	return func() {
+		defer signal.Stop(sigCh)
+
+		for {
+			select {
+			case sig := <-sigCh:
+				fn(sig)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+type reloadPlugin struct {
+	name    string
+	err     error
+	reloads atomic.Int32
+}
+
+func (p *reloadPlugin) Name() string { return p.name }
+
+func (p *reloadPlugin) Start(ctx context.Context) error {
+	<-ctx.Done()
+	return nil
+}
+
+func (p *reloadPlugin) Reload(context.Context) error {
+	p.reloads.Add(1)
+	return p.err
+}
+
+func Test_Runner_Reload(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+
+	config := &reloadPlugin{name: "config"}
+	logs := &reloadPlugin{name: "logs", err: errors.New("permission denied")}
+	runner.AddPlugin(config, logs)
+	runner.AddFunc("no-reload", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	for runner.Status().State != graceful.RunnerReady {
+		time.Sleep(time.Millisecond)
+	}
+
+	err := runner.Reload(ctx)
+	assert(t, err.Error(), "plugin logs: permission denied")
+	assert(t, config.reloads.Load(), int32(1))
+	assert(t, logs.reloads.Load(), int32(1))
+
+	cancel()
+	assert(t, <-errCh, nil)
+}
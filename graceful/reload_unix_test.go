@@ -0,0 +1,41 @@
+//go:build !windows
+
+package graceful_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Runner_WithReloadSignal(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithReloadSignal(syscall.SIGHUP),
+	)
+
+	config := &reloadPlugin{name: "config"}
+	runner.AddPlugin(config)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	for runner.Status().State != graceful.RunnerReady {
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := syscall.Kill(syscall.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+
+	for config.reloads.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	assert(t, <-errCh, nil)
+}
@@ -0,0 +1,62 @@
+package graceful
+
+import (
+	"time"
+)
+
+// Backoff returns the delay before the given restart attempt, starting at 0.
+type Backoff func(attempt int) time.Duration
+
+// ConstantBackoff returns a Backoff that always waits d.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a Backoff that waits base on the first attempt and
+// doubles the delay on every following attempt, up to maxDelay.
+func ExponentialBackoff(base, maxDelay time.Duration) Backoff {
+	return func(attempt int) time.Duration {
+		d := base
+		for i := 0; i < attempt && d < maxDelay; i++ {
+			d *= 2
+		}
+
+		return min(d, maxDelay)
+	}
+}
+
+// Restart is the restart policy of a plugin. When a plugin with a restart policy
+// returns an error from Start while the Runner is running, it is restarted after
+// the Backoff delay instead of shutting down the Runner. Once Max restarts have
+// been used, the error is handled as if there was no restart policy.
+type Restart struct {
+	// Max is the maximum number of restarts of a single run of the plugin. The
+	// count starts over whenever the plugin is started again, by another call to
+	// Runner.Start or by Runner.StartPlugin. A negative value allows unlimited
+	// restarts.
+	Max int
+	// Backoff returns the delay before each restart. Defaults to restarting
+	// immediately.
+	Backoff Backoff
+}
+
+// WithRestart sets the restart policy for the plugin with the given name, so
+// transient failures such as a lost broker connection trigger a supervised restart
+// instead of shutting down every plugin.
+//
+// Example:
+//
+//	graceful.WithRestart("consumer", graceful.Restart{
+//	  Max:     5,
+//	  Backoff: graceful.ExponentialBackoff(time.Second, 30*time.Second),
+//	})
+func WithRestart(name string, policy Restart) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		if o.restarts == nil {
+			o.restarts = make(map[string]Restart)
+		}
+		o.restarts[name] = policy
+	}
+}
@@ -0,0 +1,77 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_ExponentialBackoff(t *testing.T) {
+	backoff := graceful.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+
+	want := []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		50 * time.Millisecond,
+		50 * time.Millisecond,
+	}
+
+	for attempt, d := range want {
+		assert(t, backoff(attempt), d)
+	}
+}
+
+func Test_Runner_Restart(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithRestart("flaky", graceful.Restart{
+			Max:     3,
+			Backoff: graceful.ConstantBackoff(time.Millisecond),
+		}),
+	)
+
+	var starts atomic.Int32
+	runner.AddFunc("flaky", func(ctx context.Context) error {
+		if starts.Add(1) < 3 {
+			return errors.New("connection lost")
+		}
+
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	for starts.Load() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	assert(t, <-errCh, nil)
+	assert(t, starts.Load(), int32(3))
+}
+
+func Test_Runner_Restart_Exhausted(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithRestart("broken", graceful.Restart{Max: 2}),
+	)
+
+	var starts atomic.Int32
+	runner.AddFunc("broken", func(ctx context.Context) error {
+		starts.Add(1)
+		return errors.New("connection lost")
+	})
+
+	err := runner.Start(context.Background())
+	assert(t, err.Error(), "connection lost")
+	assert(t, starts.Load(), int32(3))
+}
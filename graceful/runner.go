@@ -3,9 +3,14 @@ package graceful
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -13,22 +18,77 @@ import (
 var (
 	ErrRunnerNotStarted     = errors.New("server not started")
 	ErrRunnerAlreadyStarted = errors.New("server already started")
+	ErrRunnerStopped        = errors.New("runner stopped before all plugins were ready")
 )
 
+// ShutdownTimeoutError is returned by Runner.Start when one or more plugins did not
+// stop within their shutdown timeout. It matches context.DeadlineExceeded with
+// errors.Is.
+type ShutdownTimeoutError struct {
+	// Plugins are the names of the plugins that were still running when their
+	// timeout expired, in the order they were stopped.
+	Plugins []string
+	// Report describes how every plugin stopped.
+	Report ShutdownReport
+}
+
+func (e *ShutdownTimeoutError) Error() string {
+	return "plugins did not stop within their shutdown timeout: " + strings.Join(e.Plugins, ", ")
+}
+
+func (e *ShutdownTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// StartupTimeoutError is returned by a plugin that did not become ready within its
+// startup timeout, see WithStartupTimeout. It matches context.DeadlineExceeded with
+// errors.Is.
+type StartupTimeoutError struct {
+	Plugin  string
+	Timeout time.Duration
+}
+
+func (e *StartupTimeoutError) Error() string {
+	return fmt.Sprintf("plugin %s failed to become ready within %s", e.Plugin, e.Timeout)
+}
+
+func (e *StartupTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
 // Runner is the orchestrator of the plugins provided. It will start and cancel
 // the plugins based on the context and os.Signals provided.
 type Runner struct {
-	started  bool
-	plugins  []Plugin
-	shutdown chan struct{}
-	opts     *runnerOpts
+	started atomic.Bool
+	plugins []Plugin
+	phases  []*phase
+	opts    *runnerOpts
+	log     *slog.Logger
+
+	mu           sync.Mutex
+	shutdown     chan struct{} // closed by Shutdown, replaced when Start returns
+	cycle        *cycle        // replaced when Start returns
+	errs         []error       // every error returned by a plugin
+	fatal        []error       // the errors that shut the Runner down
+	phaseRuns    []*phaseRun
+	dynamic      []*pluginRun // plugins started with StartPlugin
+	runCtx       context.Context
+	onError      func(Plugin, error)
+	lastShutdown ShutdownReport
+	deferred     []func(ctx context.Context) error
+	observers    []observer
+	startedAt    time.Time
+	shutdownAt   time.Time
+	stoppedAt    time.Time
 }
 
 func NewRunner(opts ...RunnerOptFunc) *Runner {
 	o := &runnerOpts{
 		signals: []os.Signal{os.Interrupt, syscall.SIGTERM},
 		timeout: 5 * time.Second,
-		println: func(v ...any) {}, // NOOP
+		logger:  slog.New(discardHandler{}),
+
+		diagnosticsWriter: os.Stderr,
 	}
 	for _, opt := range opts {
 		opt(o)
@@ -36,18 +96,62 @@ func NewRunner(opts ...RunnerOptFunc) *Runner {
 
 	return &Runner{
 		opts:     o,
+		log:      o.logger,
+		phases:   []*phase{{}},
 		shutdown: make(chan struct{}),
+		cycle:    newCycle(),
 	}
 }
 
+// cycle holds the state channels of a single call to Start.
+type cycle struct {
+	draining  chan struct{}
+	drainOnce sync.Once
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+func newCycle() *cycle {
+	return &cycle{
+		draining: make(chan struct{}),
+		ready:    make(chan struct{}),
+	}
+}
+
+func (c *cycle) drain() {
+	c.drainOnce.Do(func() {
+		close(c.draining)
+	})
+}
+
+func (c *cycle) markReady() {
+	c.readyOnce.Do(func() {
+		close(c.ready)
+	})
+}
+
+// currentCycle returns the cycle of the current or next call to Start.
+func (svr *Runner) currentCycle() *cycle {
+	svr.mu.Lock()
+	defer svr.mu.Unlock()
+	return svr.cycle
+}
+
 func (*Runner) Name() string {
 	return "runner"
 }
 
 // AddPlugin adds a plugin to the server during construction.
 // This returns the server for chaining.
+//
+// Plugins added with AddPlugin are started together, before the plugins of any
+// phase added with AddPhase.
 func (svr *Runner) AddPlugin(p ...Plugin) {
-	svr.plugins = append(svr.plugins, p...)
+	for _, plugin := range p {
+		plugin, _ = unwrapPlugin(plugin)
+		svr.plugins = append(svr.plugins, plugin)
+	}
+	svr.phases[0].plugins = append(svr.phases[0].plugins, p...)
 }
 
 // AddFunc adds a function as a plugin to the server during construction. This is
@@ -63,7 +167,7 @@ func (svr *Runner) AddPlugin(p ...Plugin) {
 //	  return nil
 //	}
 func (svr *Runner) AddFunc(name string, fn func(ctx context.Context) error) {
-	svr.plugins = append(svr.plugins, PluginFunc(name, fn))
+	svr.AddPlugin(PluginFunc(name, fn))
 }
 
 // Start start the server with a context provided for cancellation
@@ -72,87 +176,485 @@ func (svr *Runner) AddFunc(name string, fn func(ctx context.Context) error) {
 //
 // Note that a new context is created with the provided signals defined
 // when creating the server.
-func (svr *Runner) Start(ctx context.Context) error {
-	if svr.started {
+//
+// Once Start has returned, it can be called again to start every plugin anew
+// with the same configuration. The hooks registered with Defer run every time
+// Start returns.
+func (svr *Runner) Start(ctx context.Context) (err error) {
+	if !svr.started.CompareAndSwap(false, true) {
 		return ErrRunnerAlreadyStarted
 	}
+	defer svr.started.Store(false)
+
+	defer func(ctx context.Context) {
+		err = errors.Join(err, svr.runDeferred(ctx))
+	}(ctx)
 
-	// TODO: add options for signals
-	ctx, cancel := signal.NotifyContext(ctx, svr.opts.signals...)
+	var cancel context.CancelFunc
+	if len(svr.opts.signals) > 0 {
+		ctx, cancel = signal.NotifyContext(ctx, svr.opts.signals...)
+	} else {
+		// signal.NotifyContext relays every signal when none are given
+		ctx, cancel = context.WithCancel(ctx)
+	}
 	defer cancel()
 
-	// Start Plugins
+	go watchSignals(ctx, svr.opts.reloadSignals, func(sig os.Signal) {
+		svr.log.Info("reload_started", "signal", sig.String())
+		_ = svr.Reload(ctx) // errors are logged per plugin
+	})()
+
+	go watchSignals(ctx, svr.opts.diagnosticsSignals, func(sig os.Signal) {
+		svr.log.Info("diagnostics_dump", "signal", sig.String())
+		_ = svr.WriteDiagnostics(svr.opts.diagnosticsWriter)
+	})()
+
 	var (
-		wg          = sync.WaitGroup{}
-		pluginErrCh = make(chan error)
-		wgChannel   = make(chan struct{})
+		failed      = make(chan struct{})
+		failOnce    sync.Once
+		starterDone = make(chan struct{})
 	)
 
-	wg.Add(len(svr.plugins))
+	svr.mu.Lock()
+	cyc, shutdown := svr.cycle, svr.shutdown
+	svr.errs, svr.fatal = nil, nil
+	svr.phaseRuns, svr.dynamic = nil, nil
+	svr.startedAt, svr.shutdownAt, svr.stoppedAt = time.Now(), time.Time{}, time.Time{}
+	svr.mu.Unlock()
 
-	go func() {
-		wg.Wait()
-		close(wgChannel)
+	defer func() {
+		svr.mu.Lock()
+		svr.stoppedAt = time.Now()
+
+		// Ready, WaitReady and Draining wait for the next call to Start from now on
+		svr.cycle = newCycle()
+
+		// a consumed shutdown request must not stop the next call to Start
+		select {
+		case <-svr.shutdown:
+			svr.shutdown = make(chan struct{})
+		default:
+		}
+		svr.mu.Unlock()
 	}()
 
-	var plugErr error
-	for _, p := range svr.plugins {
-		if plugErr != nil {
-			break
-		}
-		go func(p Plugin) {
-			defer func() {
-				wg.Done()
-			}()
-
-			err := p.Start(ctx)
-			if err != nil {
-				plugErr = err
-
-				// safely write to the channel
-				// if the channel is full, we don't want to block
-				select {
-				case pluginErrCh <- err:
-				default:
-				}
-			}
-		}(p)
+	onError := func(p Plugin, err error) {
+		// plugins commonly return the error of their context when cancelled
+		if ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)) {
+			return
+		}
+
+		svr.mu.Lock()
+		svr.errs = append(svr.errs, err)
+		svr.mu.Unlock()
+
+		if svr.opts.errorPolicy(p.Name()) == ContinueOthers {
+			svr.log.Warn("plugin_error_ignored", "plugin", p.Name(), "error", err)
+			return
+		}
+
+		svr.mu.Lock()
+		svr.fatal = append(svr.fatal, err)
+		svr.mu.Unlock()
+
+		if svr.opts.keepRunning {
+			return
+		}
+
+		failOnce.Do(func() {
+			close(failed)
+		})
 	}
 
+	svr.mu.Lock()
+	svr.runCtx, svr.onError = ctx, onError
+	svr.mu.Unlock()
+
+	// Start the phases in order, waiting for every plugin of a phase to be ready
+	// before starting the next one. The plugins added with AddPlugin are always
+	// started, even if ctx is already done.
+	svr.startPhase(ctx, svr.phases[0], onError)
+
 	go func() {
-		<-svr.shutdown
-		cancel()
+		defer close(starterDone)
+
+		for i, ph := range svr.phases {
+			if i > 0 {
+				if ctx.Err() != nil {
+					return
+				}
+
+				svr.startPhase(ctx, ph, onError)
+			}
+
+			if !svr.phaseRun(i).waitReady(ctx) {
+				return
+			}
+
+			if ph.name != "" {
+				svr.log.Info("phase_ready", "phase", ph.name)
+			}
+		}
+
+		cyc.markReady()
+		svr.log.Info("runner_ready")
 	}()
 
-	svr.started = true
-	defer func() {
-		svr.started = false
+	// stop cancels the phases in reverse order, waiting for the plugins of each
+	// phase to stop before cancelling the previous one, and returns a report of
+	// how every plugin stopped.
+	stop := func(cause string) ShutdownReport {
+		began := time.Now()
+
+		svr.mu.Lock()
+		svr.shutdownAt = time.Now()
+		svr.mu.Unlock()
+
+		cancel()
+		<-starterDone
+
+		svr.mu.Lock()
+		phaseRuns, dynamic := svr.phaseRuns, svr.dynamic
+		svr.mu.Unlock()
+
+		// plugins started with StartPlugin are stopped first
+		for _, run := range dynamic {
+			run.setState(PluginStopping, nil)
+			run.cancel()
+			svr.stopRun(context.WithoutCancel(ctx), run)
+		}
+
+		report := ShutdownReport{Cause: cause, Plugins: svr.waitStopped(dynamic)}
+		for i := len(phaseRuns) - 1; i >= 0; i-- {
+			for _, run := range phaseRuns[i].runs {
+				run.setState(PluginStopping, nil)
+			}
+
+			phaseRuns[i].cancel()
+			for _, run := range phaseRuns[i].runs {
+				svr.stopRun(context.WithoutCancel(ctx), run)
+			}
+
+			report.Plugins = append(report.Plugins, svr.waitStopped(phaseRuns[i].runs)...)
+		}
+
+		report.Duration = time.Since(began)
+
+		svr.mu.Lock()
+		svr.lastShutdown = report
+		svr.mu.Unlock()
+
+		if len(report.Running()) > 0 {
+			svr.log.Error("shutdown_timeout", "report", report)
+		} else {
+			svr.log.Info("shutdown_complete", "report", report)
+		}
+
+		return report
+	}
+
+	go func() {
+		select {
+		case <-shutdown:
+			cancel()
+		case <-ctx.Done():
+		}
 	}()
 
 	// block until the context is done
 	select {
 	case <-ctx.Done():
-		newTimer := time.NewTimer(svr.opts.timeout)
-		defer newTimer.Stop()
+		cyc.drain()
 
-		svr.opts.println("server received signal, shutting down")
-		select {
-		case <-wgChannel:
-			svr.opts.println("all plugins have stopped, shutting down")
+		cause := context.Cause(ctx).Error()
+		svr.log.Info("shutdown_started", "cause", cause)
+
+		report := stop(cause)
+
+		var errs []error
+		if svr.opts.keepRunning {
+			svr.mu.Lock()
+			errs = slices.Clone(svr.fatal)
+			svr.mu.Unlock()
+		}
+
+		if running := report.Running(); len(running) > 0 {
+			errs = append(errs, &ShutdownTimeoutError{Plugins: running, Report: report})
+		}
+
+		if len(errs) == 1 {
+			return errs[0]
+		}
+
+		return errors.Join(errs...)
+	case <-failed:
+		cyc.drain()
+
+		svr.mu.Lock()
+		cause := svr.fatal[0].Error()
+		svr.mu.Unlock()
+
+		svr.log.Error("shutdown_started", "cause", cause)
+
+		stop(cause)
+
+		// every plugin has stopped or timed out, so no more errors are recorded
+		svr.mu.Lock()
+		defer svr.mu.Unlock()
+		return errors.Join(svr.fatal...)
+	}
+}
+
+// runPlugin starts the plugin of run, restarting it according to its restart
+// policy. It returns nil if ctx is done while waiting to restart.
+func (svr *Runner) runPlugin(ctx context.Context, run *pluginRun) error {
+	name := run.plugin.Name()
+	policy, hasPolicy := svr.opts.restarts[name]
+
+	if err := svr.waitAfter(ctx, run); err != nil {
+		if ctx.Err() != nil {
+			run.setState(PluginStopped, nil)
 			return nil
-		case <-newTimer.C:
-			svr.opts.println("timeout waiting for plugins to stop, shutting down")
-			return context.DeadlineExceeded
 		}
-	case err := <-pluginErrCh:
-		svr.opts.println("plugin error:", err)
+
+		run.setState(PluginFailed, err)
+		svr.log.Error("plugin_error", "plugin", name, "error", err)
 		return err
 	}
+
+	for attempt := 0; ; attempt++ {
+		began := time.Now()
+
+		run.setState(PluginStarting, nil)
+		svr.log.Info("plugin_starting", "plugin", name, "attempt", attempt)
+		err := svr.startAttempt(ctx, run)
+		if err == nil || ctx.Err() != nil {
+			run.setState(PluginStopped, err)
+			svr.log.Info("plugin_stopped", "plugin", name, "uptime", time.Since(began), "error", err)
+			return err
+		}
+
+		svr.log.Error("plugin_error", "plugin", name, "uptime", time.Since(began), "error", err)
+
+		if !hasPolicy || (policy.Max >= 0 && attempt >= policy.Max) {
+			run.setState(PluginFailed, err)
+			return err
+		}
+
+		run.setState(PluginRestarting, err)
+		run.addRestart()
+
+		var delay time.Duration
+		if policy.Backoff != nil {
+			delay = policy.Backoff(attempt)
+		}
+
+		svr.log.Warn("plugin_restarting", "plugin", name, "attempt", attempt+1, "delay", delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			run.setState(PluginStopped, nil)
+			return nil
+		}
+	}
+}
+
+// launch runs the plugin of run in a new goroutine with ctx decorated with the
+// context decorators of the Runner, and calls onError with the plugin and its
+// error when it returns an error, unless it was stopped with StopPlugin. ctx must
+// be cancelled by run.cancel, see pluginRun.withCancel. launch must not be called
+// with svr.mu held, as the decorators may call methods of the Runner.
+func (svr *Runner) launch(ctx context.Context, run *pluginRun, onError func(Plugin, error)) {
+	for _, decorate := range svr.opts.decorators {
+		ctx = decorate(ctx, run.plugin.Name())
+	}
+
+	go func() {
+		defer close(run.done)
+		defer run.cancel()
+		defer func() {
+			now := time.Now()
+			run.stoppedAt.Store(&now)
+		}()
+
+		if err := svr.runPlugin(ctx, run); err != nil && !run.stopRequested.Load() {
+			onError(run.plugin, err)
+		}
+	}()
+}
+
+// startAttempt starts the plugin of run once. If the plugin does not become ready
+// within its startup timeout, its context is cancelled and a *StartupTimeoutError
+// is returned once it has stopped.
+func (svr *Runner) startAttempt(ctx context.Context, run *pluginRun) error {
+	var (
+		name      = run.plugin.Name()
+		began     = time.Now()
+		ready     = make(chan struct{})
+		readyOnce sync.Once
+	)
+
+	markReady := func() {
+		readyOnce.Do(func() {
+			close(ready)
+			run.markReady()
+			run.setState(PluginRunning, nil)
+
+			d := time.Since(began)
+			svr.log.Info("plugin_started", "plugin", name, "duration", d)
+			svr.observe(func(o observer) { o.pluginStarted(name, d) })
+		})
+	}
+
+	timeout := svr.opts.startupTimeout(name)
+	if timeout <= 0 {
+		return startPlugin(ctx, run.plugin, markReady)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	go func() {
+		select {
+		case <-timer.C:
+			cancel(&StartupTimeoutError{Plugin: name, Timeout: timeout})
+		case <-ready:
+		case <-ctx.Done():
+		}
+	}()
+
+	err := startPlugin(ctx, run.plugin, markReady)
+
+	var timeoutErr *StartupTimeoutError
+	if errors.As(context.Cause(ctx), &timeoutErr) {
+		return timeoutErr
+	}
+
+	return err
+}
+
+// pluginRun tracks a single started plugin.
+type pluginRun struct {
+	plugin    Plugin
+	after     []string // see StartAfter
+	done      chan struct{}
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	cancel         context.CancelFunc
+	stopRequested  atomic.Bool
+	stopOnce       sync.Once
+	stopDone       chan struct{} // closed once Stop returned, see stopRun
+	stopReturnedAt atomic.Pointer[time.Time]
+	stoppedAt      atomic.Pointer[time.Time]
+
+	mu       sync.Mutex
+	state    PluginState
+	since    time.Time
+	restarts int
+	err      error
+	errAt    time.Time
+}
+
+func newPluginRun(p Plugin) *pluginRun {
+	p, after := unwrapPlugin(p)
+
+	return &pluginRun{
+		plugin:   p,
+		after:    after,
+		done:     make(chan struct{}),
+		ready:    make(chan struct{}),
+		stopDone: make(chan struct{}),
+		state:    PluginPending,
+		since:    time.Now(),
+	}
+}
+
+// withCancel returns a context derived from ctx that is cancelled by run.cancel.
+// It is called before the run is recorded, so run.cancel is set by the time the
+// run can be stopped.
+func (run *pluginRun) withCancel(ctx context.Context) context.Context {
+	ctx, run.cancel = context.WithCancel(ctx)
+	return ctx
+}
+
+// setState moves the plugin to state, recording err as its last error if it is
+// not nil. Stopped and failed plugins are not moved to another state.
+func (run *pluginRun) setState(state PluginState, err error) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	if run.state == PluginStopped || run.state == PluginFailed {
+		return
+	}
+
+	run.state, run.since = state, time.Now()
+	if err != nil {
+		run.err, run.errAt = err, run.since
+	}
+}
+
+func (run *pluginRun) currentState() PluginState {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	return run.state
+}
+
+func (run *pluginRun) addRestart() {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	run.restarts++
+}
+
+func (run *pluginRun) markReady() {
+	run.readyOnce.Do(func() {
+		close(run.ready)
+	})
+}
+
+// Errors returns every error returned by a plugin during the last call to Start,
+// including the errors of plugins with the ContinueOthers error policy, errors
+// returned while shutting down and errors returned by Stopper.Stop. Context errors
+// returned after shutdown began are not included.
+//
+// When a plugin error shuts the Runner down, Start returns the errors of every
+// plugin without the ContinueOthers error policy joined with errors.Join.
+func (svr *Runner) Errors() []error {
+	svr.mu.Lock()
+	defer svr.mu.Unlock()
+
+	return slices.Clone(svr.errs)
+}
+
+// Draining returns a channel that is closed as soon as the Runner begins shutting
+// down, either because a signal was received, the context was cancelled, Shutdown
+// was called or a plugin failed. It can be used to fail health checks while the
+// plugins are draining, see middleware.HealthGate.
+//
+// Every call to Start uses a new channel, which is returned as soon as the previous
+// call to Start has returned, so the channel should be retrieved again after the
+// Runner is restarted.
+func (svr *Runner) Draining() <-chan struct{} {
+	return svr.currentCycle().draining
 }
 
 // Shutdown sends a signal to the server to stop all plugins and
 // the server itself. This function returns immediately after the
-// signal is sent.
+// signal is sent. Calling Shutdown more than once is a no-op until
+// Start returns.
 func (svr *Runner) Shutdown() {
-	close(svr.shutdown)
+	svr.mu.Lock()
+	defer svr.mu.Unlock()
+
+	select {
+	case <-svr.shutdown:
+	default:
+		close(svr.shutdown)
+	}
 }
@@ -1,6 +1,9 @@
 package graceful
 
 import (
+	"context"
+	"io"
+	"log/slog"
 	"os"
 	"time"
 )
@@ -8,7 +11,46 @@ import (
 type runnerOpts struct {
 	signals []os.Signal
 	timeout time.Duration
-	println func(...any)
+	logger  *slog.Logger
+
+	softTimeout time.Duration
+
+	pluginTimeouts map[string]time.Duration
+	startup        time.Duration
+	pluginStartup  map[string]time.Duration
+	restarts       map[string]Restart
+	errorPolicies  map[string]ErrorPolicy
+	keepRunning    bool // see WithFirstErrorCancels
+	decorators     []func(ctx context.Context, name string) context.Context
+	reloadSignals  []os.Signal
+
+	diagnosticsSignals []os.Signal
+	diagnosticsWriter  io.Writer
+}
+
+// shutdownTimeout returns the time the plugin with the given name is given to
+// stop after the Runner begins shutting down.
+func (o *runnerOpts) shutdownTimeout(name string) time.Duration {
+	if d, ok := o.pluginTimeouts[name]; ok {
+		return d
+	}
+
+	return o.timeout
+}
+
+// startupTimeout returns the time the plugin with the given name is given to
+// become ready. Zero means no timeout.
+func (o *runnerOpts) startupTimeout(name string) time.Duration {
+	if d, ok := o.pluginStartup[name]; ok {
+		return d
+	}
+
+	return o.startup
+}
+
+// errorPolicy returns the ErrorPolicy of the plugin with the given name.
+func (o *runnerOpts) errorPolicy(name string) ErrorPolicy {
+	return o.errorPolicies[name] // defaults to ShutdownAll
 }
 
 type RunnerOptFunc func(*runnerOpts)
@@ -20,7 +62,8 @@ type RunnerOptFunc func(*runnerOpts)
 //   - os.Interrupt
 //   - syscall.SIGTERM
 //
-// Multiple calls to this option will override the previous
+// Multiple calls to this option will override the previous. Calling it without
+// signals disables signal handling.
 func WithSignals(signals ...os.Signal) RunnerOptFunc {
 	return func(o *runnerOpts) {
 		o.signals = signals
@@ -30,6 +73,9 @@ func WithSignals(signals ...os.Signal) RunnerOptFunc {
 // WithTimeout provides a timeout for the server to wait for
 // plugins to stop before shutting down.
 //
+// With phases, see AddPhase, the timeout applies to each phase in turn, so the
+// total shutdown can take up to the number of phases times the timeout.
+//
 // Defaults to 5 seconds
 func WithTimeout(timeout time.Duration) RunnerOptFunc {
 	return func(o *runnerOpts) {
@@ -37,9 +83,140 @@ func WithTimeout(timeout time.Duration) RunnerOptFunc {
 	}
 }
 
+// WithSoftTimeout enables a two-stage shutdown. Plugins implementing ForceStopper
+// that are still running when the soft timeout expires after their context was
+// cancelled have ForceStop called, and are then given until their shutdown timeout
+// to stop before they are abandoned. A soft timeout that is not lower than the
+// shutdown timeout of a plugin has no effect on it.
+//
+// Defaults to no soft timeout
+func WithSoftTimeout(timeout time.Duration) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		o.softTimeout = timeout
+	}
+}
+
+// WithPluginTimeout overrides the shutdown timeout for the plugin with the given
+// name, so a plugin that needs more time to stop doesn't dictate the timeout of
+// every other plugin. Plugins without an override use the WithTimeout value.
+//
+// Plugins that do not stop within their timeout are reported by name in the
+// *ShutdownTimeoutError returned by Start.
+func WithPluginTimeout(name string, timeout time.Duration) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		if o.pluginTimeouts == nil {
+			o.pluginTimeouts = make(map[string]time.Duration)
+		}
+		o.pluginTimeouts[name] = timeout
+	}
+}
+
+// WithStartupTimeout provides a timeout for plugins to become ready after they
+// are started, see ReadyPlugin. A plugin that is not ready in time has its context
+// cancelled and fails with a *StartupTimeoutError once it returns, which is then
+// handled by its restart and error policies.
+//
+// Defaults to no timeout
+func WithStartupTimeout(timeout time.Duration) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		o.startup = timeout
+	}
+}
+
+// WithPluginStartupTimeout overrides the startup timeout for the plugin with the
+// given name, see WithStartupTimeout. A zero timeout disables the startup timeout
+// for the plugin.
+func WithPluginStartupTimeout(name string, timeout time.Duration) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		if o.pluginStartup == nil {
+			o.pluginStartup = make(map[string]time.Duration)
+		}
+		o.pluginStartup[name] = timeout
+	}
+}
+
+// ErrorPolicy defines how the Runner reacts when a plugin returns an error from
+// Start while the Runner is running.
+type ErrorPolicy int
+
+const (
+	// ShutdownAll shuts down every plugin and returns the error from Start.
+	ShutdownAll ErrorPolicy = iota
+	// ContinueOthers logs the error and keeps the other plugins running.
+	ContinueOthers
+)
+
+// WithErrorPolicy sets the ErrorPolicy for the plugin with the given name, so a
+// non-critical plugin such as a metrics exporter can fail without taking the
+// other plugins down. The policy is applied after the restart policy of the
+// plugin, if any, is exhausted.
+//
+// Defaults to ShutdownAll
+func WithErrorPolicy(name string, policy ErrorPolicy) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		if o.errorPolicies == nil {
+			o.errorPolicies = make(map[string]ErrorPolicy)
+		}
+		o.errorPolicies[name] = policy
+	}
+}
+
+// WithFirstErrorCancels sets whether the first plugin error shuts the Runner
+// down, like an errgroup.Group created with errgroup.WithContext. When false, the
+// Runner keeps the other plugins running until its context is cancelled, and then
+// returns the errors of every plugin without the ContinueOthers error policy
+// joined with errors.Join.
+//
+// Defaults to true
+func WithFirstErrorCancels(cancels bool) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		o.keepRunning = !cancels
+	}
+}
+
+// WithContextDecorator provides a function that decorates the context passed to the
+// Start method of every plugin, for example to add a logger, tracer or metadata
+// scoped to the plugin name, so plugins don't have to wire that themselves.
+//
+// Multiple calls to this option add decorators, which are applied in order.
+//
+// Example:
+//
+//	graceful.WithContextDecorator(func(ctx context.Context, name string) context.Context {
+//	  return logger.With().Str("plugin", name).Logger().WithContext(ctx)
+//	})
+func WithContextDecorator(fn func(ctx context.Context, pluginName string) context.Context) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		o.decorators = append(o.decorators, fn)
+	}
+}
+
+// WithSlog provides a logger for the lifecycle events of the Runner. Every event is
+// logged with the event name as the message and the plugin name and durations as
+// attributes:
+//
+//   - plugin_waiting, plugin_starting, plugin_started, plugin_stopped
+//   - plugin_error, plugin_error_ignored, plugin_restarting, plugin_force_stopped
+//   - plugin_stop_failed
+//   - plugin_reloaded, plugin_reload_failed, reload_started
+//   - diagnostics_dump
+//   - runner_ready
+//   - hook_failed
+//   - shutdown_started, shutdown_complete, shutdown_timeout
+//
+// Defaults to discarding all events.
+func WithSlog(logger *slog.Logger) RunnerOptFunc {
+	return func(o *runnerOpts) {
+		o.logger = logger
+	}
+}
+
 // WithPrintln provides a function to print messages
+//
+// Deprecated: Use WithSlog instead. Every event is printed as the event name
+// followed by its attributes in key=value form.
 func WithPrintln(fn func(...any)) RunnerOptFunc {
 	return func(o *runnerOpts) {
-		o.println = fn
+		o.logger = slog.New(printlnHandler{println: fn})
 	}
 }
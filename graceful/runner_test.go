@@ -1,12 +1,20 @@
 package graceful_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/hay-kot/httpkit/errtrace"
 	"github.com/hay-kot/httpkit/graceful"
 )
 
@@ -27,6 +35,82 @@ func Test_Runner_FailedStartup(t *testing.T) {
 	assert(t, err.Error(), "failed to start")
 }
 
+func Test_Runner_AggregatesErrors(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithErrorPolicy("metrics", graceful.ContinueOthers),
+	)
+
+	var (
+		failMetrics = make(chan struct{})
+		failPlugins = make(chan struct{})
+		errDB       = errors.New("db connection lost")
+		errCache    = errors.New("cache connection lost")
+		errMetrics  = errors.New("exporter unavailable")
+	)
+
+	runner.AddFunc("metrics", func(ctx context.Context) error {
+		defer close(failPlugins)
+		<-failMetrics
+		return errMetrics
+	})
+
+	var started sync.WaitGroup
+	started.Add(2)
+	for _, err := range []error{errDB, errCache} {
+		runner.AddFunc(err.Error(), func(ctx context.Context) error {
+			started.Done()
+			started.Wait()
+			<-failPlugins
+			return err
+		})
+	}
+
+	close(failMetrics)
+	err := runner.Start(context.Background())
+
+	assert(t, errors.Is(err, errDB), true)
+	assert(t, errors.Is(err, errCache), true)
+	assert(t, errors.Is(err, errMetrics), false)
+
+	errs := runner.Errors()
+	assert(t, len(errs), 3)
+	assert(t, errors.Is(errors.Join(errs...), errMetrics), true)
+}
+
+func Test_Runner_RecoversPanic(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+
+	sibling := plugResults{}
+	runner.AddFunc("sibling", func(ctx context.Context) error {
+		sibling.setStart(true)
+		<-ctx.Done()
+		sibling.setStop(true)
+		return nil
+	})
+
+	runner.AddFunc("panics", func(ctx context.Context) error {
+		for !sibling.getStart() {
+			time.Sleep(time.Millisecond)
+		}
+		panic("boom")
+	})
+
+	err := runner.Start(context.Background())
+
+	var panicErr *errtrace.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected PanicError, got %v", err)
+	}
+
+	assert(t, panicErr.Value, any("boom"))
+	// the trace points at the panic in the plugin
+	errs := runner.Errors()
+	assert(t, len(errs), 1)
+	assert(t, strings.Contains(errtrace.TraceString(errs[0]), "runner_test.go"), true)
+	assert(t, sibling.getStop(), true)
+}
+
 type plugResults struct {
 	mu          sync.Mutex
 	start, stop bool
@@ -114,6 +198,321 @@ func Test_Runner_LifeCycle(t *testing.T) {
 	assert(t, plug3Got.getStop(), false)
 }
 
+func Test_Runner_PluginTimeout(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(3*time.Millisecond),
+		graceful.WithPluginTimeout("slow", 50*time.Millisecond),
+	)
+
+	block := make(chan struct{})
+	defer close(block)
+
+	runner.AddFunc("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	runner.AddFunc("stuck", func(ctx context.Context) error {
+		<-ctx.Done()
+		<-block
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := runner.Start(ctx)
+
+	var timeoutErr *graceful.ShutdownTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected ShutdownTimeoutError, got %v", err)
+	}
+
+	assert(t, errors.Is(err, context.DeadlineExceeded), true)
+	assert(t, len(timeoutErr.Plugins), 1)
+	assert(t, timeoutErr.Plugins[0], "stuck")
+
+	report := timeoutErr.Report
+	assert(t, report.Cause, "context canceled")
+	assert(t, len(report.Plugins), 2)
+
+	assert(t, report.Plugins[0].Name, "slow")
+	assert(t, report.Plugins[0].Stopped, true)
+	assert(t, report.Plugins[0].Duration >= 10*time.Millisecond, true)
+
+	assert(t, report.Plugins[1].Name, "stuck")
+	assert(t, report.Plugins[1].Stopped, false)
+
+	assert(t, strings.Contains(report.String(), "stopped slow in "), true)
+	assert(t, strings.HasSuffix(report.String(), "still running: stuck after 3ms"), true)
+	assert(t, runner.LastShutdown().Cause, report.Cause)
+}
+
+type forceStopPlugin struct {
+	name  string
+	force chan struct{}
+}
+
+func (p *forceStopPlugin) Name() string { return p.name }
+
+func (p *forceStopPlugin) Start(ctx context.Context) error {
+	<-ctx.Done()
+	<-p.force // ignores cancellation until forced
+	return nil
+}
+
+func (p *forceStopPlugin) ForceStop() { close(p.force) }
+
+func Test_Runner_WithSoftTimeout(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(50*time.Millisecond),
+		graceful.WithSoftTimeout(5*time.Millisecond),
+	)
+
+	runner.AddPlugin(&forceStopPlugin{name: "queries", force: make(chan struct{})})
+	runner.AddFunc("http", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert(t, runner.Start(ctx), nil)
+
+	report := runner.LastShutdown()
+	assert(t, len(report.Plugins), 2)
+	assert(t, report.Plugins[0].Name, "queries")
+	assert(t, report.Plugins[0].Stopped, true)
+	assert(t, report.Plugins[0].Forced, true)
+	assert(t, report.Plugins[1].Forced, false)
+	assert(t, strings.Contains(report.String(), "ms (forced)"), true)
+}
+
+func Test_Runner_ErrorPolicy(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithErrorPolicy("metrics", graceful.ContinueOthers),
+	)
+
+	failed := make(chan struct{})
+	runner.AddFunc("metrics", func(ctx context.Context) error {
+		defer close(failed)
+		return errors.New("exporter unavailable")
+	})
+
+	server := plugResults{}
+	runner.AddFunc("server", func(ctx context.Context) error {
+		server.setStart(true)
+		<-ctx.Done()
+		server.setStop(true)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	<-failed
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("runner stopped after non-critical plugin failure: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel()
+	assert(t, <-errCh, nil)
+	assert(t, server.getStart(), true)
+	assert(t, server.getStop(), true)
+}
+
+func Test_Runner_WithFirstErrorCancels(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithFirstErrorCancels(false),
+	)
+
+	errDB := errors.New("db connection lost")
+	failed := make(chan struct{})
+	runner.AddFunc("db", func(ctx context.Context) error {
+		defer close(failed)
+		return errDB
+	})
+
+	server := plugResults{}
+	runner.AddFunc("server", func(ctx context.Context) error {
+		server.setStart(true)
+		<-ctx.Done()
+		server.setStop(true)
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	<-failed
+
+	select {
+	case err := <-errCh:
+		t.Fatalf("runner stopped after plugin failure: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	cancel()
+	assert(t, errors.Is(<-errCh, errDB), true)
+	assert(t, server.getStart(), true)
+	assert(t, server.getStop(), true)
+}
+
+func Test_Runner_Restart_Cycles(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(10 * time.Millisecond))
+
+	var starts, stops atomic.Int32
+	runner.AddFunc("plug1", func(ctx context.Context) error {
+		starts.Add(1)
+		<-ctx.Done()
+		stops.Add(1)
+		return nil
+	})
+
+	for i := range 3 {
+		errCh := make(chan error, 1)
+		go func() { errCh <- runner.Start(context.Background()) }()
+
+		for runner.Status().State != graceful.RunnerReady {
+			time.Sleep(time.Millisecond)
+		}
+
+		select {
+		case <-runner.Draining():
+			t.Fatalf("cycle %d: runner draining before shutdown", i)
+		default:
+		}
+
+		runner.Shutdown()
+		runner.Shutdown() // no-op
+
+		assert(t, <-errCh, nil)
+	}
+
+	assert(t, starts.Load(), int32(3))
+	assert(t, stops.Load(), int32(3))
+}
+
+func Test_Runner_WithContextDecorator(t *testing.T) {
+	type ctxKey struct{}
+
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithContextDecorator(func(ctx context.Context, name string) context.Context {
+			return context.WithValue(ctx, ctxKey{}, "plugin:"+name)
+		}),
+		graceful.WithContextDecorator(func(ctx context.Context, name string) context.Context {
+			return context.WithValue(ctx, ctxKey{}, ctx.Value(ctxKey{}).(string)+":decorated")
+		}),
+	)
+
+	got := make(chan any, 1)
+	runner.AddFunc("plug1", func(ctx context.Context) error {
+		got <- ctx.Value(ctxKey{})
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, <-got, any("plugin:plug1:decorated"))
+
+	cancel()
+	assert(t, <-errCh, nil)
+}
+
+func Test_Runner_WithSlog(t *testing.T) {
+	var buf bytes.Buffer
+
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithSlog(slog.New(slog.NewJSONHandler(&buf, nil))),
+	)
+
+	runner.AddFunc("plug1", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	cancel()
+	assert(t, <-errCh, nil)
+
+	var events []string
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var event struct {
+			Msg    string `json:"msg"`
+			Plugin string `json:"plugin"`
+		}
+		if err := json.Unmarshal(line, &event); err != nil {
+			t.Fatal(err)
+		}
+
+		if event.Plugin != "" {
+			assert(t, event.Plugin, "plug1")
+		}
+
+		events = append(events, event.Msg)
+	}
+
+	// events are logged from multiple goroutines, so only the set is stable
+	want := []string{"plugin_started", "plugin_starting", "plugin_stopped", "runner_ready", "shutdown_complete", "shutdown_started"}
+	slices.Sort(events)
+	assert(t, strings.Join(events, ","), strings.Join(want, ","))
+}
+
+func Test_Runner_WithPrintln(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		lines []string
+	)
+
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithPrintln(func(args ...any) {
+			mu.Lock()
+			defer mu.Unlock()
+			lines = append(lines, fmt.Sprintln(args...))
+		}),
+	)
+
+	runner.AddFunc("plug1", func(ctx context.Context) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	cancel()
+	assert(t, <-errCh, nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// events are printed as the event name followed by key=value attributes
+	assert(t, slices.ContainsFunc(lines, func(line string) bool {
+		return strings.HasPrefix(line, "plugin_started plugin=plug1 duration=")
+	}), true)
+	assert(t, slices.Contains(lines, "runner_ready\n"), true)
+}
+
 func assert[T comparable](t *testing.T, got, expect T) {
 	t.Helper()
 	if expect != got {
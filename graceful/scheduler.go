@@ -0,0 +1,201 @@
+package graceful
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/hay-kot/httpkit/errtrace"
+)
+
+// Job is a function run by a Scheduler. The context is cancelled when the
+// Scheduler stops.
+type Job func(ctx context.Context) error
+
+type jobOpts struct {
+	name   string
+	jitter time.Duration
+}
+
+type JobOptFunc func(*jobOpts)
+
+// WithJobName provides a name for the job used when reporting its errors.
+//
+// Defaults to the interval or cron expression of the job.
+func WithJobName(name string) JobOptFunc {
+	return func(o *jobOpts) {
+		o.name = name
+	}
+}
+
+// WithJitter delays every run of the job by a random duration between 0 and d, so
+// jobs scheduled on many instances at the same time don't run in lockstep.
+func WithJitter(d time.Duration) JobOptFunc {
+	return func(o *jobOpts) {
+		o.jitter = d
+	}
+}
+
+// scheduledJob is a Job with its schedule. next returns the time of the next run
+// after the previous scheduled run and the current time.
+type scheduledJob struct {
+	opts jobOpts
+	job  Job
+	next func(prev, now time.Time) time.Time
+}
+
+// Scheduler is a Plugin that runs jobs on an interval or a cron schedule under the
+// lifecycle of the Runner. Create one with SchedulerPlugin.
+//
+// A job never overlaps with itself: runs that are due while the previous run is
+// still in progress are skipped. Panics in jobs are recovered and reported as
+// errors. When the Runner shuts down, no new runs are started and Start returns
+// once the running jobs have returned.
+type Scheduler struct {
+	name    string
+	jobs    []*scheduledJob
+	onError func(job string, err error)
+}
+
+// SchedulerPlugin returns a new Scheduler with the given name.
+//
+// Example:
+//
+//	scheduler := graceful.SchedulerPlugin("maintenance").
+//	  Every(time.Minute, refreshCache).
+//	  OnError(func(job string, err error) {
+//	    log.Error().Err(err).Str("job", job).Msg("scheduled job failed")
+//	  })
+//
+//	if err := scheduler.Cron("0 3 * * *", vacuum, graceful.WithJobName("vacuum")); err != nil {
+//	  return err
+//	}
+//
+//	runner.AddPlugin(scheduler)
+func SchedulerPlugin(name string) *Scheduler {
+	return &Scheduler{
+		name:    name,
+		onError: func(string, error) {}, // NOOP
+	}
+}
+
+func (s *Scheduler) Name() string {
+	return s.name
+}
+
+// OnError sets the function called with the name of the job and the error when a
+// job returns an error or panics. It returns the Scheduler for chaining.
+func (s *Scheduler) OnError(fn func(job string, err error)) *Scheduler {
+	s.onError = fn
+	return s
+}
+
+// Every schedules job to run every d, starting d after the Scheduler is started.
+// It returns the Scheduler for chaining. Like time.NewTicker, Every panics if d is
+// not positive.
+func (s *Scheduler) Every(d time.Duration, job Job, opts ...JobOptFunc) *Scheduler {
+	if d <= 0 {
+		panic("graceful: non-positive interval for Scheduler.Every")
+	}
+
+	o := jobOpts{name: "every " + d.String()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s.jobs = append(s.jobs, &scheduledJob{
+		opts: o,
+		job:  job,
+		next: func(prev, now time.Time) time.Time {
+			next := prev.Add(d)
+			if next.Before(now) {
+				// skip the runs missed while the previous run was in progress
+				next = next.Add(now.Sub(next).Truncate(d) + d)
+			}
+			return next
+		},
+	})
+
+	return s
+}
+
+// Cron schedules job to run on the given cron expression, evaluated in the local
+// time zone. The expression has the fields minute, hour, day of month, month and
+// day of week, each supporting "*", single values, ranges ("1-5"), steps ("*/15")
+// and lists ("1,15"). The descriptors @yearly, @monthly, @weekly, @daily and
+// @hourly are supported as well.
+func (s *Scheduler) Cron(expr string, job Job, opts ...JobOptFunc) error {
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return err
+	}
+
+	o := jobOpts{name: expr}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	s.jobs = append(s.jobs, &scheduledJob{
+		opts: o,
+		job:  job,
+		next: func(_, now time.Time) time.Time {
+			return schedule.next(now)
+		},
+	})
+
+	return nil
+}
+
+// Start runs the scheduled jobs until ctx is cancelled, then waits for the running
+// jobs to return.
+func (s *Scheduler) Start(ctx context.Context) error {
+	var wg sync.WaitGroup
+
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.schedule(ctx, job)
+		}()
+	}
+
+	wg.Wait()
+	return nil
+}
+
+// schedule runs job on its schedule until ctx is cancelled.
+func (s *Scheduler) schedule(ctx context.Context, job *scheduledJob) {
+	prev := time.Now()
+
+	for {
+		next := job.next(prev, time.Now())
+		if next.IsZero() {
+			return
+		}
+		prev = next
+
+		delay := time.Until(next)
+		if job.opts.jitter > 0 {
+			delay += rand.N(job.opts.jitter)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		if err := runJob(ctx, job.job); err != nil {
+			s.onError(job.opts.name, err)
+		}
+	}
+}
+
+// runJob runs job, converting a panic into an error.
+func runJob(ctx context.Context, job Job) (err error) {
+	defer errtrace.Recover(&err)
+	return job(ctx)
+}
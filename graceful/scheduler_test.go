@@ -0,0 +1,100 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/errtrace"
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Scheduler_Every(t *testing.T) {
+	var (
+		runs    atomic.Int32
+		running atomic.Int32
+		overlap atomic.Bool
+	)
+
+	scheduler := graceful.SchedulerPlugin("jobs").Every(time.Millisecond, func(ctx context.Context) error {
+		if running.Add(1) > 1 {
+			overlap.Store(true)
+		}
+		defer running.Add(-1)
+
+		runs.Add(1)
+		time.Sleep(3 * time.Millisecond)
+		return nil
+	}, graceful.WithJitter(time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- scheduler.Start(ctx) }()
+
+	for runs.Load() < 3 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel()
+	assert(t, <-errCh, nil)
+	assert(t, overlap.Load(), false)
+	assert(t, running.Load(), int32(0))
+}
+
+func Test_Scheduler_Errors(t *testing.T) {
+	type jobErr struct {
+		job string
+		err error
+	}
+
+	errs := make(chan jobErr, 2)
+
+	scheduler := graceful.SchedulerPlugin("jobs").
+		Every(time.Millisecond, func(ctx context.Context) error {
+			return errors.New("job failed")
+		}, graceful.WithJobName("failing")).
+		Every(time.Millisecond, func(ctx context.Context) error {
+			panic("boom")
+		}, graceful.WithJobName("panicking")).
+		OnError(func(job string, err error) {
+			select {
+			case errs <- jobErr{job: job, err: err}:
+			default:
+			}
+		})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = scheduler.Start(ctx) }()
+
+	got := map[string]error{}
+	for len(got) < 2 {
+		e := <-errs
+		got[e.job] = e.err
+	}
+
+	assert(t, got["failing"].Error(), "job failed")
+
+	var panicErr *errtrace.PanicError
+	assert(t, errors.As(got["panicking"], &panicErr), true)
+	assert(t, panicErr.Value, any("boom"))
+}
+
+func Test_Scheduler_CronInvalid(t *testing.T) {
+	err := graceful.SchedulerPlugin("jobs").Cron("61 * * * *", func(ctx context.Context) error { return nil })
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func Test_Scheduler_EveryNonPositive(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic for a zero interval")
+		}
+	}()
+
+	graceful.SchedulerPlugin("jobs").Every(0, func(ctx context.Context) error { return nil })
+}
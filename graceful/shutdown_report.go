@@ -0,0 +1,239 @@
+package graceful
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/hay-kot/httpkit/errtrace"
+)
+
+// PluginShutdown describes how a single plugin stopped.
+type PluginShutdown struct {
+	Name string `json:"name"`
+	// Stopped is false if the plugin was still running when its shutdown timeout
+	// expired.
+	Stopped bool `json:"stopped"`
+	// Duration is the time the plugin took to stop, or its shutdown timeout if it
+	// did not stop.
+	Duration time.Duration `json:"duration"`
+	// Forced is true if ForceStop was called because the plugin did not stop within
+	// the soft timeout, see WithSoftTimeout.
+	Forced bool `json:"forced,omitempty"`
+	// Error is the last error returned by the plugin, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// ShutdownReport describes a shutdown of the Runner, listing every plugin in the
+// order they were stopped. See Runner.LastShutdown.
+type ShutdownReport struct {
+	Cause    string           `json:"cause"`
+	Duration time.Duration    `json:"duration"`
+	Plugins  []PluginShutdown `json:"plugins"`
+}
+
+// Running returns the names of the plugins that were still running when their
+// shutdown timeout expired.
+func (r ShutdownReport) Running() []string {
+	var running []string
+	for _, p := range r.Plugins {
+		if !p.Stopped {
+			running = append(running, p.Name)
+		}
+	}
+
+	return running
+}
+
+// String returns the report on a single line, for example:
+//
+//	shutdown after "context canceled" took 5.002s: stopped http in 12ms, db in 3ms; still running: consumer after 5s
+func (r ShutdownReport) String() string {
+	var stopped, running []string
+	for _, p := range r.Plugins {
+		d := p.Duration.Round(time.Millisecond).String()
+		if p.Forced {
+			d += " (forced)"
+		}
+		if p.Stopped {
+			stopped = append(stopped, p.Name+" in "+d)
+		} else {
+			running = append(running, p.Name+" after "+d)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("shutdown after \"" + r.Cause + "\" took " + r.Duration.Round(time.Millisecond).String())
+
+	if len(stopped) > 0 {
+		b.WriteString(": stopped " + strings.Join(stopped, ", "))
+	}
+
+	if len(running) > 0 {
+		if len(stopped) > 0 {
+			b.WriteString("; ")
+		} else {
+			b.WriteString(": ")
+		}
+		b.WriteString("still running: " + strings.Join(running, ", "))
+	}
+
+	return b.String()
+}
+
+// LogValue implements slog.LogValuer, logging the report as a group with the
+// cause, the duration and the plugins that stopped and were still running.
+func (r ShutdownReport) LogValue() slog.Value {
+	stopped := make([]slog.Attr, 0, len(r.Plugins))
+	var running []string
+
+	for _, p := range r.Plugins {
+		if p.Stopped {
+			stopped = append(stopped, slog.Duration(p.Name, p.Duration))
+		} else {
+			running = append(running, p.Name)
+		}
+	}
+
+	return slog.GroupValue(
+		slog.String("cause", r.Cause),
+		slog.Duration("duration", r.Duration),
+		slog.Any("stopped", slog.GroupValue(stopped...)),
+		slog.Any("running", running),
+	)
+}
+
+// LastShutdown returns the report of the last shutdown of the Runner. It is the
+// zero value if the Runner has not shut down yet.
+func (svr *Runner) LastShutdown() ShutdownReport {
+	svr.mu.Lock()
+	defer svr.mu.Unlock()
+	return svr.lastShutdown
+}
+
+// stopRun calls Stop on the plugin of run if it implements Stopper and is still
+// running, with a context derived from ctx that expires after the shutdown timeout
+// of the plugin. Errors returned by Stop are recorded as plugin errors. It must be
+// called after the context of run is cancelled, and before waitStopped.
+func (svr *Runner) stopRun(ctx context.Context, run *pluginRun) {
+	run.stopOnce.Do(func() {
+		sp, ok := run.plugin.(Stopper)
+		if !ok {
+			close(run.stopDone)
+			return
+		}
+
+		select {
+		case <-run.done:
+			close(run.stopDone)
+			return
+		default:
+		}
+
+		name := run.plugin.Name()
+		ctx, cancel := context.WithTimeout(ctx, svr.opts.shutdownTimeout(name))
+
+		go func() {
+			defer close(run.stopDone)
+			defer cancel()
+
+			err := func() (err error) {
+				defer errtrace.Recover(&err)
+				return sp.Stop(ctx)
+			}()
+
+			now := time.Now()
+			run.stopReturnedAt.Store(&now)
+
+			if err != nil {
+				svr.log.Error("plugin_stop_failed", "plugin", name, "error", err)
+
+				svr.mu.Lock()
+				svr.errs = append(svr.errs, fmt.Errorf("stop %s: %w", name, err))
+				svr.mu.Unlock()
+			}
+		}()
+	})
+}
+
+// waitStopped waits for every plugin in runs to return from Start within its
+// shutdown timeout, calling ForceStop on the ones still running after the soft
+// timeout, and describes how each of them stopped. All timeouts are measured from
+// the moment waitStopped is called.
+func (svr *Runner) waitStopped(runs []*pluginRun) []PluginShutdown {
+	began := time.Now()
+	soft := svr.opts.softTimeout
+
+	plugins := make([]PluginShutdown, 0, len(runs))
+	for _, run := range runs {
+		name := run.plugin.Name()
+		timeout := svr.opts.shutdownTimeout(name)
+		timer := time.NewTimer(time.Until(began.Add(timeout)))
+
+		ps := PluginShutdown{Name: name}
+
+		if fs, ok := run.plugin.(ForceStopper); ok && soft > 0 && soft < timeout {
+			softTimer := time.NewTimer(time.Until(began.Add(soft)))
+
+			select {
+			case <-run.done:
+			case <-softTimer.C:
+				svr.log.Warn("plugin_force_stopped", "plugin", name, "after", soft)
+				svr.forceStop(name, fs)
+				ps.Forced = true
+			}
+
+			softTimer.Stop()
+		}
+
+		ps.Stopped = func() bool {
+			for _, done := range []chan struct{}{run.done, run.stopDone} {
+				select {
+				case <-done:
+				case <-timer.C:
+					return false
+				}
+			}
+			return true
+		}()
+
+		ps.Duration = timeout
+		if ps.Stopped {
+			stoppedAt := run.stoppedAt.Load()
+			if at := run.stopReturnedAt.Load(); at != nil && at.After(*stoppedAt) {
+				stoppedAt = at
+			}
+
+			ps.Duration = max(stoppedAt.Sub(began), 0)
+		}
+
+		timer.Stop()
+
+		svr.observe(func(o observer) { o.pluginStopped(name, ps.Duration) })
+
+		run.mu.Lock()
+		if run.err != nil {
+			ps.Error = run.err.Error()
+		}
+		run.mu.Unlock()
+
+		plugins = append(plugins, ps)
+	}
+
+	return plugins
+}
+
+// forceStop calls ForceStop on fs, logging a recovered panic.
+func (svr *Runner) forceStop(name string, fs ForceStopper) {
+	err := func() (err error) {
+		defer errtrace.Recover(&err)
+		fs.ForceStop()
+		return nil
+	}()
+
+	if err != nil {
+		svr.log.Error("plugin_error", "plugin", name, "error", err)
+	}
+}
@@ -0,0 +1,47 @@
+package graceful
+
+import (
+	"context"
+	"log/slog"
+)
+
+// discardHandler is a slog.Handler that discards every record.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return h }
+func (h discardHandler) WithGroup(string) slog.Handler           { return h }
+
+// printlnHandler is a slog.Handler that prints every record as its message
+// followed by its attributes in key=value form, see WithPrintln.
+type printlnHandler struct {
+	println func(...any)
+	attrs   []slog.Attr
+}
+
+func (printlnHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h printlnHandler) Handle(_ context.Context, r slog.Record) error {
+	args := make([]any, 0, 1+len(h.attrs)+r.NumAttrs())
+	args = append(args, r.Message)
+
+	for _, a := range h.attrs {
+		args = append(args, a.String())
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		args = append(args, a.String())
+		return true
+	})
+
+	h.println(args...)
+	return nil
+}
+
+func (h printlnHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h.attrs = append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)
+	return h
+}
+
+func (h printlnHandler) WithGroup(string) slog.Handler { return h }
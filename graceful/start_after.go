@@ -0,0 +1,71 @@
+package graceful
+
+import (
+	"context"
+	"fmt"
+)
+
+// afterPlugin is a Plugin that is only started once the plugins it depends on are
+// ready, see StartAfter.
+type afterPlugin struct {
+	Plugin
+	after []string
+}
+
+// StartAfter returns p wrapped so that its Start is delayed until every plugin with
+// one of the given names has reported that it is ready, see ReadyPlugin. The plugin
+// is configured with the options for its own name, and the Runner still uses every
+// interface implemented by p, such as HealthChecker and Reloader.
+//
+// The named plugins must be started before or together with p, in the same phase
+// or an earlier one, otherwise p fails with ErrPluginNotFound. A named plugin that
+// stops without becoming ready holds p back until the Runner shuts down.
+//
+// This covers the common case of starting consumers only after the database they
+// depend on is available, without declaring a full dependency graph.
+//
+// Example:
+//
+//	runner.AddPlugin(dbPlugin, graceful.StartAfter(consumerPlugin, dbPlugin.Name()))
+func StartAfter(p Plugin, names ...string) Plugin {
+	return &afterPlugin{Plugin: p, after: names}
+}
+
+// unwrapPlugin returns the plugin wrapped by StartAfter and the names of the
+// plugins it depends on.
+func unwrapPlugin(p Plugin) (Plugin, []string) {
+	if a, ok := p.(*afterPlugin); ok {
+		return a.Plugin, a.after
+	}
+
+	return p, nil
+}
+
+// waitAfter blocks until every plugin run depends on is ready. It returns
+// ErrPluginNotFound if a dependency is not running, and the error of ctx if ctx is
+// done first.
+func (svr *Runner) waitAfter(ctx context.Context, run *pluginRun) error {
+	if len(run.after) == 0 {
+		return nil
+	}
+
+	svr.log.Info("plugin_waiting", "plugin", run.plugin.Name(), "after", run.after)
+
+	for _, name := range run.after {
+		svr.mu.Lock()
+		dep := svr.findStarted(name)
+		svr.mu.Unlock()
+
+		if dep == nil {
+			return fmt.Errorf("start after %s: %w", name, ErrPluginNotFound)
+		}
+
+		select {
+		case <-dep.ready:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return nil
+}
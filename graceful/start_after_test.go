@@ -0,0 +1,53 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_StartAfter(t *testing.T) {
+	var log eventLog
+
+	db := graceful.ReadyPluginFunc("db", func(ctx context.Context, ready func()) error {
+		time.Sleep(5 * time.Millisecond)
+		log.add("db started")
+		ready()
+
+		<-ctx.Done()
+		return nil
+	})
+
+	consumer := graceful.PluginFunc("consumer", func(ctx context.Context) error {
+		log.add("consumer started")
+
+		<-ctx.Done()
+		return nil
+	})
+
+	runner := graceful.NewRunner(graceful.WithTimeout(50 * time.Millisecond))
+	runner.AddPlugin(graceful.StartAfter(consumer, "db"), db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	cancel()
+	assert(t, <-errCh, nil)
+
+	assert(t, log.String(), "db started,consumer started")
+}
+
+func Test_StartAfter_NotFound(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(50 * time.Millisecond))
+	runner.AddPlugin(graceful.StartAfter(&healthPlugin{name: "consumer"}, "db"))
+
+	err := runner.Start(context.Background())
+
+	assert(t, errors.Is(err, graceful.ErrPluginNotFound), true)
+	assert(t, len(runner.Health(context.Background())), 1)
+}
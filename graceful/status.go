@@ -0,0 +1,221 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/server"
+)
+
+// PluginState is the lifecycle state of a plugin, see Runner.Status.
+type PluginState string
+
+const (
+	PluginPending    PluginState = "pending"    // waiting for a previous phase to be ready
+	PluginStarting   PluginState = "starting"   // started, but not ready yet
+	PluginRunning    PluginState = "running"    // started and ready
+	PluginRestarting PluginState = "restarting" // failed and waiting to be restarted
+	PluginStopping   PluginState = "stopping"   // cancelled, but not stopped yet
+	PluginStopped    PluginState = "stopped"    // stopped
+	PluginFailed     PluginState = "failed"     // stopped with an error
+)
+
+// RunnerState is the lifecycle state of a Runner, see Runner.Status.
+type RunnerState string
+
+const (
+	RunnerIdle     RunnerState = "idle"     // Start has not been called
+	RunnerStarting RunnerState = "starting" // started, but not every plugin is ready
+	RunnerReady    RunnerState = "ready"    // every plugin is ready
+	RunnerDraining RunnerState = "draining" // shutting down
+	RunnerStopped  RunnerState = "stopped"  // Start has returned
+)
+
+// PluginStatus is the status of a single plugin.
+type PluginStatus struct {
+	Name     string      `json:"name"`
+	Phase    string      `json:"phase,omitempty"`
+	State    PluginState `json:"state"`
+	Since    time.Time   `json:"since"`
+	Restarts int         `json:"restarts"`
+	Error    string      `json:"error,omitempty"`
+	ErrorAt  *time.Time  `json:"errorAt,omitempty"`
+	// Health is the result of the health check of plugins implementing
+	// HealthChecker. It is only set by Runner.StatusHandler.
+	Health string `json:"health,omitempty"`
+}
+
+// RunnerStatus is a snapshot of the state of a Runner and its plugins.
+type RunnerStatus struct {
+	State             RunnerState    `json:"state"`
+	StartedAt         time.Time      `json:"startedAt"`
+	Uptime            string         `json:"uptime"`
+	ShutdownStartedAt *time.Time     `json:"shutdownStartedAt,omitempty"`
+	Plugins           []PluginStatus `json:"plugins"`
+}
+
+// Status returns a snapshot of the state of the Runner and every plugin, in the
+// order the plugins were added, followed by the plugins started with StartPlugin.
+func (svr *Runner) Status() RunnerStatus {
+	svr.mu.Lock()
+	var (
+		startedAt  = svr.startedAt
+		shutdownAt = svr.shutdownAt
+		stoppedAt  = svr.stoppedAt
+		phaseRuns  = svr.phaseRuns
+		dynamic    = svr.dynamic
+	)
+	svr.mu.Unlock()
+
+	status := RunnerStatus{State: RunnerIdle, StartedAt: startedAt}
+
+	switch {
+	case startedAt.IsZero():
+	case !stoppedAt.IsZero() && stoppedAt.After(startedAt):
+		status.State = RunnerStopped
+		status.Uptime = stoppedAt.Sub(startedAt).Round(time.Second).String()
+	default:
+		status.State = RunnerStarting
+		select {
+		case <-svr.currentCycle().ready:
+			status.State = RunnerReady
+		default:
+		}
+
+		status.Uptime = time.Since(startedAt).Round(time.Second).String()
+	}
+
+	if !shutdownAt.IsZero() && shutdownAt.After(startedAt) {
+		status.ShutdownStartedAt = &shutdownAt
+		if status.State != RunnerStopped {
+			status.State = RunnerDraining
+		}
+	}
+
+	for i, ph := range svr.phases {
+		for j, p := range ph.plugins {
+			ps := PluginStatus{Name: p.Name(), Phase: ph.name, State: PluginPending}
+
+			if i < len(phaseRuns) {
+				phaseRuns[i].runs[j].status(&ps)
+			}
+
+			status.Plugins = append(status.Plugins, ps)
+		}
+	}
+
+	for _, run := range dynamic {
+		ps := PluginStatus{Name: run.plugin.Name()}
+		run.status(&ps)
+		status.Plugins = append(status.Plugins, ps)
+	}
+
+	return status
+}
+
+// status copies the state of run into ps.
+func (run *pluginRun) status(ps *PluginStatus) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	ps.State, ps.Since, ps.Restarts = run.state, run.since, run.restarts
+	if run.err != nil {
+		errAt := run.errAt
+		ps.Error, ps.ErrorAt = run.err.Error(), &errAt
+	}
+}
+
+// StatusHandler returns a handler that renders Runner.Status as JSON, including the
+// health of every plugin implementing HealthChecker. It is meant as an operational
+// dashboard for the Runner, and always responds with 200 OK. Use HealthHandler for
+// load balancer health checks.
+//
+// Example:
+//
+//	mux.Get("/debug/runner", runner.StatusHandler())
+func (svr *Runner) StatusHandler() errchain.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		status := svr.Status()
+		health := svr.Health(r.Context())
+
+		for i := range status.Plugins {
+			err, ok := health[status.Plugins[i].Name]
+			switch {
+			case !ok:
+			case err != nil:
+				status.Plugins[i].Health = err.Error()
+			default:
+				status.Plugins[i].Health = "ok"
+			}
+		}
+
+		return server.JSON(w, http.StatusOK, status)
+	}
+}
+
+type statusPlugin struct {
+	addr   string
+	runner *Runner
+}
+
+// StatusPlugin returns a Plugin named "status" that serves the StatusHandler of
+// runner on "/" and its HealthHandler on "/healthz" at addr. Add it to the same
+// Runner to get an admin endpoint separate from the public listener.
+//
+// Example:
+//
+//	runner.AddPlugin(graceful.StatusPlugin("127.0.0.1:9090", runner))
+func StatusPlugin(addr string, runner *Runner) Plugin {
+	return &statusPlugin{addr: addr, runner: runner}
+}
+
+func (p *statusPlugin) Name() string {
+	return "status"
+}
+
+func (p *statusPlugin) Start(ctx context.Context) error {
+	handle := func(h errchain.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if err := h(w, r); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", handle(p.runner.StatusHandler()))
+	mux.Handle("GET /healthz", handle(p.runner.HealthHandler()))
+
+	srv := &http.Server{
+		Addr:              p.addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errCh; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
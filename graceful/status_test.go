@@ -0,0 +1,78 @@
+package graceful_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_Runner_Status(t *testing.T) {
+	runner := graceful.NewRunner(
+		graceful.WithTimeout(10*time.Millisecond),
+		graceful.WithErrorPolicy("metrics", graceful.ContinueOthers),
+	)
+
+	assert(t, runner.Status().State, graceful.RunnerIdle)
+
+	failed := make(chan struct{})
+	runner.AddFunc("metrics", func(ctx context.Context) error {
+		defer close(failed)
+		return errors.New("exporter unavailable")
+	})
+	runner.AddPlugin(&healthPlugin{name: "db"})
+	runner.AddPhase("servers", graceful.ReadyPluginFunc("http", func(ctx context.Context, ready func()) error {
+		<-ctx.Done()
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	<-failed
+	for runner.Status().Plugins[2].State != graceful.PluginStarting {
+		time.Sleep(time.Millisecond)
+	}
+
+	status := runner.Status()
+	assert(t, status.State, graceful.RunnerStarting)
+	assert(t, len(status.Plugins), 3)
+
+	assert(t, status.Plugins[0].Name, "metrics")
+	assert(t, status.Plugins[0].State, graceful.PluginFailed)
+	assert(t, status.Plugins[0].Error, "exporter unavailable")
+
+	assert(t, status.Plugins[1].Name, "db")
+	assert(t, status.Plugins[1].State, graceful.PluginRunning)
+
+	assert(t, status.Plugins[2].Name, "http")
+	assert(t, status.Plugins[2].Phase, "servers")
+
+	w := httptest.NewRecorder()
+	if err := runner.StatusHandler()(w, httptest.NewRequest(http.MethodGet, "/", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	var got graceful.RunnerStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	assert(t, got.Plugins[1].Health, "ok")
+	assert(t, got.Plugins[0].Health, "")
+
+	cancel()
+	assert(t, <-errCh, nil)
+
+	status = runner.Status()
+	assert(t, status.State, graceful.RunnerStopped)
+	assert(t, status.ShutdownStartedAt != nil, true)
+	assert(t, status.Plugins[1].State, graceful.PluginStopped)
+	assert(t, status.Plugins[2].State, graceful.PluginStopped)
+}
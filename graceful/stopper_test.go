@@ -0,0 +1,69 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+type stopperPlugin struct {
+	name    string
+	closed  chan struct{}
+	log     *eventLog
+	stopErr error
+}
+
+func (p *stopperPlugin) Name() string { return p.name }
+
+// Start ignores ctx, like http.Server.ListenAndServe
+func (p *stopperPlugin) Start(context.Context) error {
+	<-p.closed
+	p.log.add(p.name + " serve returned")
+	return nil
+}
+
+func (p *stopperPlugin) Stop(ctx context.Context) error {
+	close(p.closed)
+
+	_, hasDeadline := ctx.Deadline()
+	time.Sleep(5 * time.Millisecond) // drain in flight work
+	p.log.add(p.name + " drained")
+
+	if !hasDeadline {
+		return errors.New("stop without deadline")
+	}
+	return p.stopErr
+}
+
+func Test_Runner_Stopper(t *testing.T) {
+	var (
+		log     eventLog
+		errStop = errors.New("connections left open")
+	)
+
+	runner := graceful.NewRunner(graceful.WithTimeout(50 * time.Millisecond))
+	runner.AddPhase("infra", graceful.PluginFunc("db", func(ctx context.Context) error {
+		<-ctx.Done()
+		log.add("db stopped")
+		return nil
+	}))
+	runner.AddPhase("servers", &stopperPlugin{name: "http", closed: make(chan struct{}), log: &log, stopErr: errStop})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- runner.Start(ctx) }()
+
+	assert(t, runner.WaitReady(ctx), nil)
+	cancel()
+	assert(t, <-errCh, nil)
+
+	assert(t, log.String(), "http serve returned,http drained,db stopped")
+	assert(t, runner.LastShutdown().Plugins[0].Duration >= 5*time.Millisecond, true)
+
+	errs := runner.Errors()
+	assert(t, len(errs), 1)
+	assert(t, errors.Is(errs[0], errStop), true)
+}
@@ -0,0 +1,110 @@
+package graceful
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// Validator is an optional interface a Plugin can implement to check its
+// configuration without being started, see Runner.Validate.
+type Validator interface {
+	Validate() error
+}
+
+// Validate checks the wiring of the Runner without starting any plugin, so CI can
+// catch a misconfigured Runner without binding ports or connecting to
+// infrastructure. It reports:
+//
+//   - plugins without a name and plugins sharing a name
+//   - StartAfter dependencies on unknown plugins, or on plugins of a later phase
+//   - shutdown timeouts that are not positive
+//   - options for plugin names that were never added
+//   - the errors of plugins implementing Validator
+//
+// Every problem is returned, joined with errors.Join.
+//
+// Example:
+//
+//	func Test_Wiring(t *testing.T) {
+//	  if err := app.NewRunner(testConfig).Validate(); err != nil {
+//	    t.Fatal(err)
+//	  }
+//	}
+func (svr *Runner) Validate() error {
+	var (
+		errs    []error
+		seen    = make(map[string]bool)
+		phaseOf = make(map[string]int)
+	)
+
+	for i := len(svr.phases) - 1; i >= 0; i-- {
+		for _, p := range svr.phases[i].plugins {
+			phaseOf[p.Name()] = i
+		}
+	}
+
+	for i, ph := range svr.phases {
+		for _, p := range ph.plugins {
+			p, after := unwrapPlugin(p)
+			name := p.Name()
+
+			switch {
+			case name == "":
+				errs = append(errs, errors.New("plugin without a name"))
+			case seen[name]:
+				errs = append(errs, fmt.Errorf("plugin %s: added more than once", name))
+			}
+			seen[name] = true
+
+			for _, dep := range after {
+				switch phase, ok := phaseOf[dep]; {
+				case !ok:
+					errs = append(errs, fmt.Errorf("plugin %s: starts after unknown plugin %s", name, dep))
+				case phase > i:
+					errs = append(errs, fmt.Errorf("plugin %s: starts after plugin %s of a later phase", name, dep))
+				}
+			}
+
+			if timeout := svr.opts.shutdownTimeout(name); timeout <= 0 {
+				errs = append(errs, fmt.Errorf("plugin %s: shutdown timeout %s must be positive", name, timeout))
+			}
+
+			if v, ok := p.(Validator); ok {
+				if err := v.Validate(); err != nil {
+					errs = append(errs, fmt.Errorf("plugin %s: %w", name, err))
+				}
+			}
+		}
+	}
+
+	options := []struct {
+		option string
+		names  []string
+	}{
+		{"WithPluginTimeout", sortedKeys(svr.opts.pluginTimeouts)},
+		{"WithPluginStartupTimeout", sortedKeys(svr.opts.pluginStartup)},
+		{"WithRestart", sortedKeys(svr.opts.restarts)},
+		{"WithErrorPolicy", sortedKeys(svr.opts.errorPolicies)},
+	}
+
+	for _, o := range options {
+		for _, name := range o.names {
+			if !seen[name] {
+				errs = append(errs, fmt.Errorf("%s: unknown plugin %s", o.option, name))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,63 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+type validatePlugin struct {
+	healthPlugin
+	err error
+}
+
+func (p *validatePlugin) Validate() error { return p.err }
+
+func Test_Runner_Validate(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	runner := graceful.NewRunner(
+		graceful.WithPluginTimeout("http", 0),
+		graceful.WithRestart("metrics", graceful.Restart{Max: 3}),
+	)
+
+	runner.AddPhase("infra",
+		graceful.PluginFunc("db", noop),
+		graceful.StartAfter(graceful.PluginFunc("cache", noop), "db"),
+		graceful.StartAfter(graceful.PluginFunc("warmer", noop), "http"),
+	)
+	runner.AddPhase("servers",
+		graceful.PluginFunc("http", noop),
+		graceful.PluginFunc("db", noop),
+		graceful.StartAfter(graceful.PluginFunc("consumer", noop), "queue"),
+		graceful.Group("admin", &validatePlugin{
+			healthPlugin: healthPlugin{name: "status"},
+			err:          errors.New("missing address"),
+		}),
+	)
+
+	err := runner.Validate()
+
+	expected := []string{
+		"plugin warmer: starts after plugin http of a later phase",
+		"plugin http: shutdown timeout 0s must be positive",
+		"plugin db: added more than once",
+		"plugin consumer: starts after unknown plugin queue",
+		"plugin admin: plugin status: missing address",
+		"WithRestart: unknown plugin metrics",
+	}
+
+	assert(t, err.Error(), strings.Join(expected, "\n"))
+}
+
+func Test_Runner_Validate_Valid(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithPluginTimeout("http", time.Second))
+	runner.AddPlugin(graceful.PluginFunc("db", func(ctx context.Context) error { return nil }))
+	runner.AddPhase("servers", graceful.StartAfter(graceful.PluginFunc("http", func(ctx context.Context) error { return nil }), "db"))
+
+	assert(t, runner.Validate(), nil)
+}
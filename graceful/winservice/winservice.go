@@ -0,0 +1,52 @@
+// Package winservice runs a graceful.Runner as a native Windows service. Service
+// control requests to stop, shut down or pre-shut down the system are translated
+// into a Runner shutdown, and the service status reports the Runner lifecycle:
+// start pending until every plugin is ready, running, and stop pending while the
+// plugins are stopped.
+//
+// On other platforms Run returns ErrUnsupported and IsService returns false, so
+// the same main function can be used everywhere:
+//
+//	func main() {
+//	  runner := graceful.NewRunner()
+//	  runner.AddPlugin(httpPlugin)
+//
+//	  if ok, _ := winservice.IsService(); ok {
+//	    err = winservice.Run("my-service", runner)
+//	  } else {
+//	    err = runner.Start(context.Background())
+//	  }
+//	}
+package winservice
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrUnsupported = errors.New("winservice: windows services are only supported on windows")
+
+type opts struct {
+	stopWaitHint time.Duration
+}
+
+type OptFunc func(*opts)
+
+// WithStopWaitHint provides the time the service control manager is told the
+// service needs to stop. It should match the shutdown timeout of the Runner.
+//
+// Defaults to 5 seconds
+func WithStopWaitHint(d time.Duration) OptFunc {
+	return func(o *opts) {
+		o.stopWaitHint = d
+	}
+}
+
+func newOpts(fns []OptFunc) *opts {
+	o := &opts{stopWaitHint: 5 * time.Second}
+	for _, fn := range fns {
+		fn(o)
+	}
+
+	return o
+}
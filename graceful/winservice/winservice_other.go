@@ -0,0 +1,17 @@
+//go:build !windows
+
+package winservice
+
+import "github.com/hay-kot/httpkit/graceful"
+
+// IsService reports whether the process is running as a Windows service. It
+// always returns false on platforms other than Windows.
+func IsService() (bool, error) {
+	return false, nil
+}
+
+// Run runs runner as the Windows service with the given name. It returns
+// ErrUnsupported on platforms other than Windows.
+func Run(name string, runner *graceful.Runner, fns ...OptFunc) error {
+	return ErrUnsupported
+}
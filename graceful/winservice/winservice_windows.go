@@ -0,0 +1,83 @@
+//go:build windows
+
+package winservice
+
+import (
+	"context"
+
+	"golang.org/x/sys/windows/svc"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+// IsService reports whether the process is running as a Windows service.
+func IsService() (bool, error) {
+	return svc.IsWindowsService()
+}
+
+// Run runs runner as the Windows service with the given name and blocks until the
+// service is stopped. It returns the error returned by Runner.Start, or the error
+// of the service control manager.
+func Run(name string, runner *graceful.Runner, fns ...OptFunc) error {
+	h := &handler{runner: runner, opts: newOpts(fns)}
+	if err := svc.Run(name, h); err != nil {
+		return err
+	}
+
+	return h.err
+}
+
+type handler struct {
+	runner *graceful.Runner
+	opts   *opts
+	err    error
+}
+
+const accepts = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPreShutdown
+
+// Execute implements svc.Handler.
+func (h *handler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- h.runner.Start(ctx)
+	}()
+
+	ready := make(chan struct{})
+	go func() {
+		if h.runner.WaitReady(ctx) == nil {
+			close(ready)
+		}
+	}()
+
+	stopPending := svc.Status{State: svc.StopPending, WaitHint: uint32(h.opts.stopWaitHint.Milliseconds())}
+
+	for {
+		select {
+		case <-ready:
+			ready = nil
+			status <- svc.Status{State: svc.Running, Accepts: accepts}
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown, svc.PreShutdown:
+				status <- stopPending
+				h.runner.Shutdown()
+			}
+		case err := <-errCh:
+			h.err = err
+			status <- svc.Status{State: svc.StopPending}
+
+			if err != nil {
+				return true, 1
+			}
+
+			return false, 0
+		}
+	}
+}
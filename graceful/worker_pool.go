@@ -0,0 +1,224 @@
+package graceful
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrPoolStopped   = errors.New("worker pool stopped")
+	ErrPoolQueueFull = errors.New("worker pool queue full")
+)
+
+// DroppedJobsError is returned by WorkerPool.Start when queued jobs were dropped
+// because the queue could not be drained within the drain timeout.
+type DroppedJobsError struct {
+	Dropped int
+}
+
+func (e *DroppedJobsError) Error() string {
+	return fmt.Sprintf("worker pool dropped %d queued jobs", e.Dropped)
+}
+
+// WorkerPool is a Plugin that runs submitted jobs on a fixed number of workers under
+// the lifecycle of the Runner. Create one with WorkerPoolPlugin.
+//
+// When the Runner shuts down, the pool stops accepting jobs and keeps working on
+// the queued jobs for up to the drain timeout. Once the drain timeout expires, the
+// context of the running jobs is cancelled and the remaining queued jobs are
+// dropped. Panics in jobs are recovered and reported as errors.
+type WorkerPool struct {
+	name         string
+	workers      int
+	drainTimeout time.Duration
+	onError      func(err error)
+
+	mu      sync.RWMutex
+	stopped bool
+	queue   chan Job
+
+	running  atomic.Int64
+	dropping atomic.Bool
+	dropped  atomic.Int64
+}
+
+// WorkerPoolPlugin returns a new WorkerPool with the given name and number of
+// workers. The queue holds up to workers jobs and the drain timeout defaults to 5
+// seconds.
+//
+// Example:
+//
+//	pool := graceful.WorkerPoolPlugin("emails", 4).QueueSize(100)
+//	runner.AddPlugin(pool)
+//
+//	err := pool.Submit(func(ctx context.Context) error {
+//	  return mailer.Send(ctx, msg)
+//	})
+func WorkerPoolPlugin(name string, workers int) *WorkerPool {
+	workers = max(workers, 1)
+
+	return &WorkerPool{
+		name:         name,
+		workers:      workers,
+		drainTimeout: 5 * time.Second,
+		onError:      func(error) {}, // NOOP
+		queue:        make(chan Job, workers),
+	}
+}
+
+func (p *WorkerPool) Name() string {
+	return p.name
+}
+
+// QueueSize sets the number of jobs that can be queued before Submit returns
+// ErrPoolQueueFull. It must be called before the pool is used and returns the
+// WorkerPool for chaining.
+func (p *WorkerPool) QueueSize(n int) *WorkerPool {
+	p.queue = make(chan Job, max(n, 0))
+	return p
+}
+
+// DrainTimeout sets the time the pool keeps working on queued jobs after shutdown
+// begins. It should be lower than the shutdown timeout of the plugin in the Runner.
+// It returns the WorkerPool for chaining.
+func (p *WorkerPool) DrainTimeout(d time.Duration) *WorkerPool {
+	p.drainTimeout = d
+	return p
+}
+
+// OnError sets the function called with the error when a job returns an error or
+// panics. It returns the WorkerPool for chaining.
+func (p *WorkerPool) OnError(fn func(err error)) *WorkerPool {
+	p.onError = fn
+	return p
+}
+
+// Submit queues job to be run by the pool. It never blocks, and returns
+// ErrPoolQueueFull when the queue is full and ErrPoolStopped once the pool has
+// begun shutting down.
+func (p *WorkerPool) Submit(job Job) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.stopped {
+		return ErrPoolStopped
+	}
+
+	select {
+	case p.queue <- job:
+		return nil
+	default:
+		return ErrPoolQueueFull
+	}
+}
+
+// InFlight returns the number of queued and running jobs.
+func (p *WorkerPool) InFlight() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return len(p.queue) + int(p.running.Load())
+}
+
+// Dropped returns the number of queued jobs dropped during shutdown.
+func (p *WorkerPool) Dropped() int {
+	return int(p.dropped.Load())
+}
+
+// Start runs the workers until ctx is cancelled, then drains the queue. It returns
+// a *DroppedJobsError if queued jobs were dropped. A stopped pool can be started
+// again, for example when the plugin is restarted.
+func (p *WorkerPool) Start(ctx context.Context) error {
+	queue := p.open()
+	droppedBefore := p.dropped.Load()
+
+	// jobs keep their context while the queue is drained
+	jobCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+
+	var wg sync.WaitGroup
+	for range p.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.work(jobCtx, queue)
+		}()
+	}
+
+	<-ctx.Done()
+
+	p.close()
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(p.drainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		return nil
+	case <-timer.C:
+	}
+
+	p.dropping.Store(true)
+	cancelJobs()
+
+	// drop the remaining jobs alongside the workers
+	for range queue {
+		p.dropped.Add(1)
+	}
+
+	<-drained
+	return &DroppedJobsError{Dropped: int(p.dropped.Load() - droppedBefore)}
+}
+
+// open prepares the pool for a run and returns its queue. The queue of a stopped
+// pool is closed, so it is replaced with a new one of the same size; jobs submitted
+// before the first run are kept.
+func (p *WorkerPool) open() chan Job {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.stopped {
+		p.queue = make(chan Job, cap(p.queue))
+		p.stopped = false
+	}
+	p.dropping.Store(false)
+
+	return p.queue
+}
+
+// close stops the pool from accepting jobs and closes the queue.
+func (p *WorkerPool) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.stopped = true
+	close(p.queue)
+}
+
+// work runs queued jobs until the queue is closed and empty.
+func (p *WorkerPool) work(ctx context.Context, queue chan Job) {
+	for job := range queue {
+		if p.dropping.Load() {
+			p.dropped.Add(1)
+			continue
+		}
+
+		p.running.Add(1)
+		err := runJob(ctx, job)
+		p.running.Add(-1)
+
+		if err != nil {
+			p.onError(err)
+		}
+	}
+}
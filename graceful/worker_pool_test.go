@@ -0,0 +1,104 @@
+package graceful_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_WorkerPool_Drain(t *testing.T) {
+	pool := graceful.WorkerPoolPlugin("pool", 2).QueueSize(10)
+
+	var done atomic.Int32
+	for range 6 {
+		err := pool.Submit(func(ctx context.Context) error {
+			time.Sleep(time.Millisecond)
+			done.Add(1)
+			return nil
+		})
+		assert(t, err, nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	assert(t, pool.Start(ctx), nil)
+	assert(t, done.Load(), int32(6))
+	assert(t, pool.Submit(func(ctx context.Context) error { return nil }), graceful.ErrPoolStopped)
+}
+
+func Test_WorkerPool_DropsAfterDrainTimeout(t *testing.T) {
+	pool := graceful.WorkerPoolPlugin("pool", 1).QueueSize(5).DrainTimeout(5 * time.Millisecond)
+
+	for range 3 {
+		err := pool.Submit(func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		assert(t, err, nil)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := pool.Start(ctx)
+
+	var dropped *graceful.DroppedJobsError
+	if !errors.As(err, &dropped) {
+		t.Fatalf("expected DroppedJobsError, got %v", err)
+	}
+
+	assert(t, dropped.Dropped, 2)
+	assert(t, pool.Dropped(), 2)
+}
+
+func Test_WorkerPool_Errors(t *testing.T) {
+	errs := make(chan error, 2)
+	pool := graceful.WorkerPoolPlugin("pool", 1).QueueSize(2).OnError(func(err error) { errs <- err })
+
+	assert(t, pool.Submit(func(ctx context.Context) error { return errors.New("job failed") }), nil)
+	assert(t, pool.Submit(func(ctx context.Context) error { panic("boom") }), nil)
+	assert(t, pool.Submit(func(ctx context.Context) error { return nil }), graceful.ErrPoolQueueFull)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = pool.Start(ctx) }()
+
+	assert(t, (<-errs).Error(), "job failed")
+	assert(t, (<-errs).Error() != "", true)
+	cancel()
+}
+
+func Test_WorkerPool_Restart(t *testing.T) {
+	pool := graceful.WorkerPoolPlugin("pool", 1).QueueSize(2)
+
+	for range 2 {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		errc := make(chan error, 1)
+		go func() { errc <- pool.Start(ctx) }()
+
+		ran := make(chan struct{})
+		for {
+			err := pool.Submit(func(ctx context.Context) error {
+				close(ran)
+				return nil
+			})
+			if err == nil {
+				break
+			}
+			// the previous run has stopped and this one has not opened the queue yet
+			assert(t, err, graceful.ErrPoolStopped)
+			time.Sleep(time.Millisecond)
+		}
+
+		<-ran
+		cancel()
+		assert(t, <-errc, nil)
+	}
+
+	assert(t, pool.Submit(func(ctx context.Context) error { return nil }), graceful.ErrPoolStopped)
+}
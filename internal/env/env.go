@@ -0,0 +1,135 @@
+// Package env reads typed configuration values from environment variables and
+// collects every invalid value, so a misconfigured deployment reports all of
+// its problems at once.
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reader reads variables sharing a prefix. The zero value reads variables
+// without a prefix.
+type Reader struct {
+	prefix string
+	lookup func(key string) (string, bool)
+	errs   []error
+}
+
+// New returns a Reader for variables named "<prefix>_<NAME>". An empty prefix
+// reads the names as is.
+func New(prefix string) *Reader {
+	prefix = strings.TrimSuffix(prefix, "_")
+	if prefix != "" {
+		prefix += "_"
+	}
+
+	return &Reader{prefix: prefix, lookup: os.LookupEnv}
+}
+
+// Name returns the full name of the variable name.
+func (r *Reader) Name(name string) string {
+	return r.prefix + name
+}
+
+// Lookup returns the trimmed value of the variable name and whether it is set to
+// a non-empty value.
+func (r *Reader) Lookup(name string) (string, bool) {
+	lookup := r.lookup
+	if lookup == nil {
+		lookup = os.LookupEnv
+	}
+
+	v, ok := lookup(r.Name(name))
+	v = strings.TrimSpace(v)
+	return v, ok && v != ""
+}
+
+// String returns the value of the variable name or def if it is not set.
+func (r *Reader) String(name, def string) string {
+	if v, ok := r.Lookup(name); ok {
+		return v
+	}
+	return def
+}
+
+// Int returns the integer value of the variable name or def if it is not set.
+func (r *Reader) Int(name string, def int) int {
+	v, ok := r.Lookup(name)
+	if !ok {
+		return def
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		r.Errorf(name, "invalid integer %q", v)
+		return def
+	}
+
+	return n
+}
+
+// Bool returns the boolean value of the variable name or def if it is not set.
+func (r *Reader) Bool(name string, def bool) bool {
+	v, ok := r.Lookup(name)
+	if !ok {
+		return def
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		r.Errorf(name, "invalid boolean %q", v)
+		return def
+	}
+
+	return b
+}
+
+// Duration returns the non-negative duration value of the variable name, for
+// example "30s", or def if it is not set.
+func (r *Reader) Duration(name string, def time.Duration) time.Duration {
+	v, ok := r.Lookup(name)
+	if !ok {
+		return def
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		r.Errorf(name, "invalid duration %q", v)
+		return def
+	}
+
+	return d
+}
+
+// List returns the comma separated values of the variable name and whether it
+// is set.
+func (r *Reader) List(name string) ([]string, bool) {
+	v, ok := r.Lookup(name)
+	if !ok {
+		return nil, false
+	}
+
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+
+	return out, true
+}
+
+// Errorf records an error for the variable name.
+func (r *Reader) Errorf(name, format string, args ...any) {
+	r.errs = append(r.errs, fmt.Errorf("%s: %s", r.Name(name), fmt.Sprintf(format, args...)))
+}
+
+// Err returns the errors recorded while reading, joined with errors.Join, or nil.
+func (r *Reader) Err() error {
+	return errors.Join(r.errs...)
+}
@@ -0,0 +1,56 @@
+package env
+
+import (
+	"testing"
+	"time"
+)
+
+func Test_Reader(t *testing.T) {
+	vars := map[string]string{
+		"APP_PORT":    "9000",
+		"APP_DEBUG":   "true",
+		"APP_TIMEOUT": "2s",
+		"APP_LIST":    "a, b,,c",
+		"APP_BAD_INT": "abc",
+		"APP_BAD_DUR": "-1s",
+		"APP_EMPTY":   " ",
+	}
+
+	r := New("APP_")
+	r.lookup = func(key string) (string, bool) {
+		v, ok := vars[key]
+		return v, ok
+	}
+
+	if got := r.Int("PORT", 8080); got != 9000 {
+		t.Errorf("expected 9000, got %d", got)
+	}
+
+	if got := r.Bool("DEBUG", false); !got {
+		t.Error("expected true")
+	}
+
+	if got := r.Duration("TIMEOUT", time.Second); got != 2*time.Second {
+		t.Errorf("expected 2s, got %s", got)
+	}
+
+	if got, _ := r.List("LIST"); len(got) != 3 || got[2] != "c" {
+		t.Errorf("unexpected list %v", got)
+	}
+
+	if got := r.String("EMPTY", "default"); got != "default" {
+		t.Errorf("expected empty values to use the default, got %q", got)
+	}
+
+	if err := r.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	r.Int("BAD_INT", 0)
+	r.Duration("BAD_DUR", 0)
+
+	want := "APP_BAD_INT: invalid integer \"abc\"\nAPP_BAD_DUR: invalid duration \"-1s\""
+	if err := r.Err(); err == nil || err.Error() != want {
+		t.Errorf("expected %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,72 @@
+// Package promutil holds the helpers shared by the packages of the module that
+// record Prometheus metrics and other telemetry of HTTP requests.
+package promutil
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Register registers the collector with the registerer. If an identical collector
+// is already registered, the existing collector is returned so that middleware and
+// routers can be constructed more than once against the same registerer.
+func Register[T prometheus.Collector](registerer prometheus.Registerer, c T) T {
+	err := registerer.Register(c)
+	if err == nil {
+		return c
+	}
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			return existing
+		}
+	}
+
+	panic(err)
+}
+
+// ResponseRecorder wraps a http.ResponseWriter to capture the status code and the
+// number of bytes written to the client.
+type ResponseRecorder struct {
+	http.ResponseWriter
+	Status      int
+	Bytes       int
+	WroteHeader bool
+}
+
+func NewResponseRecorder(w http.ResponseWriter) *ResponseRecorder {
+	return &ResponseRecorder{ResponseWriter: w, Status: http.StatusOK}
+}
+
+func (rw *ResponseRecorder) WriteHeader(code int) {
+	if !rw.WroteHeader {
+		rw.Status = code
+		rw.WroteHeader = true
+	}
+
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *ResponseRecorder) Write(b []byte) (int, error) {
+	rw.WroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.Bytes += n
+	return n, err
+}
+
+// Flush implements the http.Flusher interface if the underlying writer supports it.
+func (rw *ResponseRecorder) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		rw.WroteHeader = true
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with
+// http.ResponseController.
+func (rw *ResponseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
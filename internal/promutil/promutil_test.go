@@ -0,0 +1,38 @@
+package promutil
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func Test_Register(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := prometheus.CounterOpts{Name: "requests_total", Help: "Requests."}
+
+	first := Register(reg, prometheus.NewCounter(opts))
+	second := Register(reg, prometheus.NewCounter(opts))
+
+	if first != second {
+		t.Error("expected the registered collector to be reused")
+	}
+}
+
+func Test_ResponseRecorder(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := NewResponseRecorder(rec)
+
+	rw.WriteHeader(http.StatusTeapot)
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write([]byte("hello"))
+
+	if rw.Status != http.StatusTeapot || rw.Bytes != 5 || !rw.WroteHeader {
+		t.Errorf("unexpected recorder state %+v", rw)
+	}
+
+	if http.NewResponseController(rw).Flush() != nil || !rec.Flushed {
+		t.Error("expected Flush to reach the underlying writer")
+	}
+}
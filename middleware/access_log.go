@@ -0,0 +1,255 @@
+package middleware
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hay-kot/httpkit/internal/promutil"
+)
+
+// Format defines the line format written by the AccessLogger.
+type Format int
+
+const (
+	// FormatCommon is the Apache Common Log Format.
+	//
+	//	127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326
+	FormatCommon Format = iota
+	// FormatCombined is the Apache Combined Log Format which extends the Common Log
+	// Format with the Referer and User-Agent headers.
+	FormatCombined
+	// FormatJSON writes one JSON document per line. Values resolved by inner
+	// middleware, like the tenant, are included in the "tags" field.
+	FormatJSON
+)
+
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+type accessLogOpts struct {
+	bufferSize    int
+	flushInterval time.Duration
+	now           func() time.Time
+}
+
+type AccessLogOptFunc func(*accessLogOpts)
+
+// WithBufferSize enables buffering of access log lines. Lines are written to the
+// underlying writer once the buffer is full, when the flush interval has elapsed or
+// when Flush is called.
+//
+// Defaults to 0 (unbuffered)
+func WithBufferSize(size int) AccessLogOptFunc {
+	return func(o *accessLogOpts) {
+		o.bufferSize = size
+	}
+}
+
+// WithFlushInterval sets the maximum time buffered lines are held before they are
+// flushed. The interval is checked when a line is written, so Flush should still be
+// called during shutdown. Only used when buffering is enabled.
+//
+// Defaults to 1 second
+func WithFlushInterval(d time.Duration) AccessLogOptFunc {
+	return func(o *accessLogOpts) {
+		o.flushInterval = d
+	}
+}
+
+// AccessLogger writes a line for every request in one of the supported formats. It is
+// safe for concurrent use.
+type AccessLogger struct {
+	mu        sync.Mutex
+	w         io.Writer
+	buf       *bufio.Writer
+	format    Format
+	opts      *accessLogOpts
+	lastFlush time.Time
+}
+
+// NewAccessLogger creates a new AccessLogger writing to w in the provided format.
+func NewAccessLogger(w io.Writer, format Format, opts ...AccessLogOptFunc) *AccessLogger {
+	o := &accessLogOpts{
+		flushInterval: time.Second,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	l := &AccessLogger{
+		w:         w,
+		format:    format,
+		opts:      o,
+		lastFlush: o.now(),
+	}
+
+	if o.bufferSize > 0 {
+		l.buf = bufio.NewWriterSize(w, o.bufferSize)
+	}
+
+	return l
+}
+
+// AccessLog returns a middleware that writes an access log line for every request to
+// w. It is a shortcut for NewAccessLogger(w, format, opts...).Middleware.
+func AccessLog(w io.Writer, format Format, opts ...AccessLogOptFunc) func(http.Handler) http.Handler {
+	return NewAccessLogger(w, format, opts...).Middleware
+}
+
+// Middleware logs every request served by h.
+func (l *AccessLogger) Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := l.opts.now()
+		rw := promutil.NewResponseRecorder(w)
+
+		ctx, tags := withTags(r.Context())
+		r = r.WithContext(ctx)
+
+		h.ServeHTTP(rw, r)
+
+		l.write(l.line(r, rw, tags.all(), start))
+	})
+}
+
+// Flush writes any buffered lines to the underlying writer.
+func (l *AccessLogger) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.flush()
+}
+
+// Rotate flushes any buffered lines and calls fn with the current writer. The writer
+// returned by fn is used for all subsequent lines. This is the hook used to integrate
+// with log rotation, fn is expected to close the old writer if required.
+//
+// Example:
+//
+//	err := logger.Rotate(func(old io.Writer) (io.Writer, error) {
+//	  _ = old.(*os.File).Close()
+//	  return os.OpenFile("access.log", os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+//	})
+func (l *AccessLogger) Rotate(fn func(old io.Writer) (io.Writer, error)) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.flush(); err != nil {
+		return err
+	}
+
+	w, err := fn(l.w)
+	if err != nil {
+		return err
+	}
+
+	l.w = w
+	if l.buf != nil {
+		l.buf.Reset(w)
+	}
+
+	return nil
+}
+
+func (l *AccessLogger) flush() error {
+	l.lastFlush = l.opts.now()
+	if l.buf == nil {
+		return nil
+	}
+
+	return l.buf.Flush()
+}
+
+func (l *AccessLogger) write(line []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.buf == nil {
+		_, _ = l.w.Write(line)
+		return
+	}
+
+	_, _ = l.buf.Write(line)
+
+	if l.opts.now().Sub(l.lastFlush) >= l.opts.flushInterval {
+		_ = l.flush()
+	}
+}
+
+type accessLogEntry struct {
+	Time       string            `json:"time"`
+	RemoteAddr string            `json:"remote_addr"`
+	User       string            `json:"user,omitempty"`
+	Method     string            `json:"method"`
+	URI        string            `json:"uri"`
+	Proto      string            `json:"proto"`
+	Status     int               `json:"status"`
+	Bytes      int               `json:"bytes"`
+	Referer    string            `json:"referer,omitempty"`
+	UserAgent  string            `json:"user_agent,omitempty"`
+	Duration   float64           `json:"duration_ms"`
+	Tags       map[string]string `json:"tags,omitempty"`
+}
+
+func (l *AccessLogger) line(r *http.Request, rw *promutil.ResponseRecorder, tags map[string]string, start time.Time) []byte {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	user, _, _ := r.BasicAuth()
+
+	if l.format == FormatJSON {
+		b, _ := json.Marshal(accessLogEntry{
+			Time:       start.Format(time.RFC3339),
+			RemoteAddr: host,
+			User:       user,
+			Method:     r.Method,
+			URI:        r.RequestURI,
+			Proto:      r.Proto,
+			Status:     rw.Status,
+			Bytes:      rw.Bytes,
+			Referer:    r.Referer(),
+			UserAgent:  r.UserAgent(),
+			Duration:   float64(l.opts.now().Sub(start).Microseconds()) / 1000,
+			Tags:       tags,
+		})
+
+		return append(b, '\n')
+	}
+
+	size := "-"
+	if rw.Bytes > 0 {
+		size = strconv.Itoa(rw.Bytes)
+	}
+
+	line := fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s",
+		host,
+		dash(user),
+		start.Format(clfTimeLayout),
+		r.Method,
+		r.RequestURI,
+		r.Proto,
+		rw.Status,
+		size,
+	)
+
+	if l.format == FormatCombined {
+		line += fmt.Sprintf(" %q %q", dash(r.Referer()), dash(r.UserAgent()))
+	}
+
+	return []byte(line + "\n")
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func Test_AccessLog(t *testing.T) {
+	now := time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	})
+
+	tests := []struct {
+		name   string
+		format Format
+		want   string
+	}{
+		{
+			name:   "common",
+			format: FormatCommon,
+			want:   `192.0.2.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /users?id=1 HTTP/1.1" 201 5` + "\n",
+		},
+		{
+			name:   "combined",
+			format: FormatCombined,
+			want:   `192.0.2.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /users?id=1 HTTP/1.1" 201 5 "-" "test-agent"` + "\n",
+		},
+		{
+			name:   "json",
+			format: FormatJSON,
+			want:   `{"time":"2000-10-10T13:55:36-07:00","remote_addr":"192.0.2.1","user":"frank","method":"GET","uri":"/users?id=1","proto":"HTTP/1.1","status":201,"bytes":5,"user_agent":"test-agent","duration_ms":0}` + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+
+			mw := AccessLog(out, tt.format, func(o *accessLogOpts) {
+				o.now = func() time.Time { return now }
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/users?id=1", nil)
+			req.SetBasicAuth("frank", "secret")
+			req.Header.Set("User-Agent", "test-agent")
+
+			mw(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+			assertEqual(t, out.String(), tt.want)
+		})
+	}
+}
+
+func Test_AccessLogger_BufferAndRotate(t *testing.T) {
+	first := &bytes.Buffer{}
+	second := &bytes.Buffer{}
+
+	logger := NewAccessLogger(first, FormatCommon, WithBufferSize(4096), WithFlushInterval(time.Hour))
+
+	handler := logger.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assertEqual(t, first.Len(), 0)
+
+	err := logger.Rotate(func(old io.Writer) (io.Writer, error) {
+		return second, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first.Len() == 0 {
+		t.Error("expected buffered line to be flushed to the old writer on rotate")
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	_ = logger.Flush()
+
+	if second.Len() == 0 {
+		t.Error("expected line to be written to the new writer")
+	}
+}
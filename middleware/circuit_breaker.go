@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/internal/promutil"
+	"github.com/hay-kot/httpkit/server"
+)
+
+// BreakerPolicy configures the CircuitBreaker middleware. Zero values are replaced
+// with their defaults.
+type BreakerPolicy struct {
+	// Window is the duration over which failures are counted while the breaker is
+	// closed. Counts are reset at the end of every window.
+	//
+	// Defaults to 10 seconds
+	Window time.Duration
+
+	// MinRequests is the minimum number of requests within a window before the
+	// failure ratio is evaluated.
+	//
+	// Defaults to 20
+	MinRequests int
+
+	// FailureRatio is the ratio of failed requests within a window that opens the
+	// breaker.
+	//
+	// Defaults to 0.5
+	FailureRatio float64
+
+	// OpenTimeout is the duration the breaker stays open before allowing probe
+	// requests through in the half-open state.
+	//
+	// Defaults to 30 seconds
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of successful probe requests required to close
+	// the breaker again. Only this many requests are let through while half-open.
+	//
+	// Defaults to 1
+	HalfOpenRequests int
+
+	// IsFailure reports whether a request failed. It receives the error returned by
+	// the handler chain and the status code written to the response.
+	//
+	// Defaults to treating non-response errors and 5xx status codes as failures
+	IsFailure func(err error, status int) bool
+}
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+type breaker struct {
+	mu          sync.Mutex
+	state       breakerState
+	windowStart time.Time
+	openedAt    time.Time
+	probeStart  time.Time
+	requests    int
+	failures    int
+	probes      int
+	successes   int
+}
+
+// CircuitBreaker returns an errchain.Middleware that tracks the failure rate of each
+// route and fails fast with a 503 Service Unavailable response while the breaker for
+// the route is open. After the open timeout, a limited number of probe requests are
+// let through; the breaker closes once they succeed and opens again on failure.
+//
+// Routes are identified by errchain.RoutePattern. Requests without a route pattern
+// share a single breaker, so client controlled paths cannot create new breakers.
+func CircuitBreaker(policy BreakerPolicy) errchain.Middleware {
+	if policy.Window <= 0 {
+		policy.Window = 10 * time.Second
+	}
+	if policy.MinRequests <= 0 {
+		policy.MinRequests = 20
+	}
+	if policy.FailureRatio <= 0 {
+		policy.FailureRatio = 0.5
+	}
+	if policy.OpenTimeout <= 0 {
+		policy.OpenTimeout = 30 * time.Second
+	}
+	if policy.HalfOpenRequests <= 0 {
+		policy.HalfOpenRequests = 1
+	}
+	if policy.IsFailure == nil {
+		policy.IsFailure = func(err error, status int) bool {
+			return status >= http.StatusInternalServerError || (err != nil && !server.IsResponseError(err))
+		}
+	}
+
+	cb := &circuitBreaker{
+		policy:   policy,
+		breakers: map[string]*breaker{},
+		now:      time.Now,
+	}
+
+	return cb.middleware
+}
+
+// unmatchedRoute is the breaker key for requests without a route pattern.
+const unmatchedRoute = "unmatched"
+
+type circuitBreaker struct {
+	policy   BreakerPolicy
+	mu       sync.Mutex
+	breakers map[string]*breaker
+	now      func() time.Time
+}
+
+func (cb *circuitBreaker) middleware(h errchain.Handler) errchain.Handler {
+	return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		key := errchain.RoutePattern(r)
+		if key == "" {
+			key = unmatchedRoute
+		}
+
+		b := cb.breaker(key)
+
+		if wait, ok := cb.allow(b); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+
+			return server.Error().
+				Status(http.StatusServiceUnavailable).
+				Msg("service temporarily unavailable").
+				Write(r.Context(), w)
+		}
+
+		// a panicking handler is recorded as a failure, otherwise a half-open
+		// probe would never report back
+		failed := true
+		defer func() { cb.record(b, failed) }()
+
+		rw := promutil.NewResponseRecorder(w)
+		err := h.ServeHTTP(rw, r)
+
+		failed = cb.policy.IsFailure(err, rw.Status)
+
+		return err
+	})
+}
+
+func (cb *circuitBreaker) breaker(key string) *breaker {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	b, ok := cb.breakers[key]
+	if !ok {
+		b = &breaker{windowStart: cb.now()}
+		cb.breakers[key] = b
+	}
+
+	return b
+}
+
+// allow reports whether a request may pass through the breaker. When it may not,
+// the remaining open duration is returned.
+func (cb *circuitBreaker) allow(b *breaker) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := cb.now()
+
+	switch b.state {
+	case breakerOpen:
+		remaining := cb.policy.OpenTimeout - now.Sub(b.openedAt)
+		if remaining > 0 {
+			return remaining, false
+		}
+
+		b.state = breakerHalfOpen
+		b.probes, b.successes = 0, 0
+		b.probeStart = now
+		fallthrough
+	case breakerHalfOpen:
+		if b.probes >= cb.policy.HalfOpenRequests {
+			remaining := cb.policy.OpenTimeout - now.Sub(b.probeStart)
+			if remaining > 0 {
+				return remaining, false
+			}
+
+			// probes still outstanding after another open timeout are abandoned
+			// and a new round of probes is let through
+			b.probes, b.successes = 0, 0
+			b.probeStart = now
+		}
+
+		b.probes++
+		return 0, true
+	default:
+		if now.Sub(b.windowStart) >= cb.policy.Window {
+			b.windowStart = now
+			b.requests, b.failures = 0, 0
+		}
+
+		return 0, true
+	}
+}
+
+func (cb *circuitBreaker) record(b *breaker, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case breakerHalfOpen:
+		if failed {
+			b.state = breakerOpen
+			b.openedAt = cb.now()
+			return
+		}
+
+		b.successes++
+		if b.successes >= cb.policy.HalfOpenRequests {
+			b.state = breakerClosed
+			b.windowStart = cb.now()
+			b.requests, b.failures = 0, 0
+		}
+	case breakerClosed:
+		b.requests++
+		if failed {
+			b.failures++
+		}
+
+		if b.requests >= cb.policy.MinRequests &&
+			float64(b.failures)/float64(b.requests) >= cb.policy.FailureRatio {
+			b.state = breakerOpen
+			b.openedAt = cb.now()
+		}
+	default:
+	}
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+func Test_CircuitBreaker(t *testing.T) {
+	now := time.Now()
+	fail := true
+
+	cb := &circuitBreaker{
+		policy: BreakerPolicy{
+			Window:           time.Minute,
+			MinRequests:      2,
+			FailureRatio:     0.5,
+			OpenTimeout:      time.Second,
+			HalfOpenRequests: 1,
+			IsFailure: func(err error, status int) bool {
+				return err != nil
+			},
+		},
+		breakers: map[string]*breaker{},
+		now:      func() time.Time { return now },
+	}
+
+	h := cb.middleware(errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if fail {
+			return errors.New("upstream failed")
+		}
+		return nil
+	}))
+
+	do := func() int {
+		rec := httptest.NewRecorder()
+		_ = h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/proxy", nil))
+		return rec.Code
+	}
+
+	// two failures open the breaker
+	do()
+	do()
+	assertEqual(t, do(), http.StatusServiceUnavailable)
+
+	// after the open timeout a failing probe re-opens the breaker
+	now = now.Add(2 * time.Second)
+	do()
+	assertEqual(t, do(), http.StatusServiceUnavailable)
+
+	// a successful probe closes the breaker
+	now = now.Add(2 * time.Second)
+	fail = false
+	assertEqual(t, do(), http.StatusOK)
+	assertEqual(t, do(), http.StatusOK)
+}
+
+func Test_CircuitBreaker_StuckProbe(t *testing.T) {
+	now := time.Now()
+
+	cb := &circuitBreaker{
+		policy: BreakerPolicy{
+			Window:           time.Minute,
+			MinRequests:      1,
+			FailureRatio:     0.5,
+			OpenTimeout:      time.Second,
+			HalfOpenRequests: 1,
+			IsFailure: func(err error, status int) bool {
+				return err != nil
+			},
+		},
+		breakers: map[string]*breaker{},
+		now:      func() time.Time { return now },
+	}
+
+	b := cb.breaker("/slow")
+	cb.record(b, true)
+
+	// a probe is let through but never records a result
+	now = now.Add(2 * time.Second)
+	_, ok := cb.allow(b)
+	assertEqual(t, ok, true)
+
+	_, ok = cb.allow(b)
+	assertEqual(t, ok, false)
+
+	// once the open timeout passes again a new probe is allowed
+	now = now.Add(2 * time.Second)
+	_, ok = cb.allow(b)
+	assertEqual(t, ok, true)
+}
+
+func Test_CircuitBreaker_PanicRecordsFailure(t *testing.T) {
+	cb := &circuitBreaker{
+		policy: BreakerPolicy{
+			Window:           time.Minute,
+			MinRequests:      1,
+			FailureRatio:     0.5,
+			OpenTimeout:      time.Second,
+			HalfOpenRequests: 1,
+			IsFailure: func(err error, status int) bool {
+				return err != nil
+			},
+		},
+		breakers: map[string]*breaker{},
+		now:      time.Now,
+	}
+
+	h := cb.middleware(errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		panic("boom")
+	}))
+
+	func() {
+		defer func() { _ = recover() }()
+		_ = h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/a", nil))
+	}()
+
+	// unmatched paths share one breaker, which the panic opened
+	rec := httptest.NewRecorder()
+	_ = h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/b", nil))
+	assertEqual(t, rec.Code, http.StatusServiceUnavailable)
+	assertEqual(t, len(cb.breakers), 1)
+}
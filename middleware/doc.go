@@ -0,0 +1,5 @@
+// Package middleware provides a collection of HTTP middleware for services built
+// with the httpkit packages. Middleware is provided either as a standard
+// func(http.Handler) http.Handler to be registered with errchain.Mux.Use, or as an
+// errchain.Middleware when it needs to participate in the error chain.
+package middleware
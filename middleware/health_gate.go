@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/hay-kot/httpkit/graceful"
+	"github.com/hay-kot/httpkit/server"
+)
+
+// HealthGate returns a middleware that makes the provided health check paths return
+// 503 Service Unavailable as soon as the runner begins shutting down, while all other
+// requests continue to be served. This signals load balancers to stop routing new
+// requests to the instance while in-flight requests are drained.
+//
+// Example:
+//
+//	mux.Use(middleware.HealthGate(runner, "/healthz"))
+func HealthGate(runner *graceful.Runner, paths ...string) func(http.Handler) http.Handler {
+	gated := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		gated[p] = struct{}{}
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, ok := gated[r.URL.Path]; ok {
+				select {
+				case <-runner.Draining():
+					w.Header().Set("Connection", "close")
+					_ = server.Error().
+						Status(http.StatusServiceUnavailable).
+						Msg("shutting down").
+						Write(r.Context(), w)
+					return
+				default:
+				}
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/graceful"
+)
+
+func Test_HealthGate(t *testing.T) {
+	runner := graceful.NewRunner(graceful.WithTimeout(time.Second))
+
+	stopped := make(chan struct{})
+	runner.AddFunc("blocker", func(ctx context.Context) error {
+		<-ctx.Done()
+		<-stopped
+		return nil
+	})
+
+	h := HealthGate(runner, "/healthz")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	do := func(path string) int {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = runner.Start(context.Background())
+	}()
+
+	assertEqual(t, do("/healthz"), http.StatusOK)
+
+	runner.Shutdown()
+	<-runner.Draining()
+
+	assertEqual(t, do("/healthz"), http.StatusServiceUnavailable)
+	assertEqual(t, do("/users"), http.StatusOK)
+
+	close(stopped)
+	<-done
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/text/language"
+
+	"github.com/hay-kot/httpkit/ctxkit"
+)
+
+const localeParam = "lang"
+
+// LocaleKey is the context key of the locale negotiated by the Locale middleware.
+var LocaleKey = ctxkit.NewKey[language.Tag]("locale")
+
+// Locale returns a middleware that negotiates the locale of the request against the
+// supported tags and stores the result in the request context, see GetLocale. The
+// first supported tag is used as the fallback.
+//
+// The locale is selected from, in order of precedence:
+//  1. the "lang" query parameter
+//  2. the "lang" cookie
+//  3. the Accept-Language header
+//
+// Combine this with server.SetTranslateFunc to translate error messages written by
+// the ErrorBuilder.
+func Locale(supported ...language.Tag) func(http.Handler) http.Handler {
+	if len(supported) == 0 {
+		panic("middleware: Locale requires at least one supported tag")
+	}
+
+	matcher := language.NewMatcher(supported)
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tag := matchLocale(r, matcher, supported)
+
+			w.Header().Add("Vary", "Accept-Language")
+			w.Header().Set("Content-Language", tag.String())
+
+			h.ServeHTTP(w, LocaleKey.SetRequest(r, tag))
+		})
+	}
+}
+
+// GetLocale returns the locale negotiated by the Locale middleware. If the
+// middleware was not used, language.Und is returned.
+func GetLocale(ctx context.Context) language.Tag {
+	return LocaleKey.GetOr(ctx, language.Und)
+}
+
+func matchLocale(r *http.Request, matcher language.Matcher, supported []language.Tag) language.Tag {
+	overrides := []string{r.URL.Query().Get(localeParam)}
+	if c, err := r.Cookie(localeParam); err == nil {
+		overrides = append(overrides, c.Value)
+	}
+
+	for _, v := range overrides {
+		if v == "" {
+			continue
+		}
+
+		tag, err := language.Parse(v)
+		if err != nil {
+			continue
+		}
+
+		if _, idx, conf := matcher.Match(tag); conf != language.No {
+			return supported[idx]
+		}
+	}
+
+	tags, _, err := language.ParseAcceptLanguage(r.Header.Get("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return supported[0]
+	}
+
+	_, idx, _ := matcher.Match(tags...)
+	return supported[idx]
+}
@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func Test_Locale(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		cookie string
+		accept string
+		want   language.Tag
+	}{
+		{name: "fallback", target: "/", want: language.English},
+		{name: "accept language", target: "/", accept: "fr-CH, fr;q=0.9, en;q=0.8", want: language.French},
+		{name: "unsupported", target: "/", accept: "ja", want: language.English},
+		{name: "cookie override", target: "/", cookie: "de", accept: "fr", want: language.German},
+		{name: "query override", target: "/?lang=fr", cookie: "de", want: language.French},
+		{name: "invalid override", target: "/?lang=!!", accept: "de", want: language.German},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got language.Tag
+
+			h := Locale(language.English, language.French, language.German)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = GetLocale(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+			}
+			if tt.accept != "" {
+				req.Header.Set("Accept-Language", tt.accept)
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assertEqual(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/internal/promutil"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var metricLabels = []string{"method", "route", "status"}
+
+type metrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+type metricsOpts struct {
+	tenantLabel bool
+}
+
+type MetricsOptFunc func(*metricsOpts)
+
+// WithTenantLabel adds a "tenant" label to the request metrics, set to the tenant
+// resolved by the Tenant middleware. Requests without a tenant are labeled with an
+// empty tenant. Every tenant adds a series per route and status, so the resolver
+// should only accept known tenants, see KnownTenants.
+//
+// Defaults to false
+func WithTenantLabel() MetricsOptFunc {
+	return func(o *metricsOpts) {
+		o.tenantLabel = true
+	}
+}
+
+// Metrics returns a middleware that records Prometheus metrics for every request
+// and registers the collectors with the provided registerer. If registerer is nil,
+// prometheus.DefaultRegisterer is used.
+//
+// The following metrics are exported, labeled by method, route pattern and status,
+// and optionally by tenant, see WithTenantLabel:
+//   - http_requests_total
+//   - http_request_duration_seconds
+//   - http_response_size_bytes
+//
+// Additionally http_requests_in_flight reports the number of requests currently
+// being served.
+//
+// The route label is read from errchain.RoutePattern, so the middleware should be
+// registered with errchain.Mux.Use. Requests without a route pattern are labeled
+// as "unmatched" to keep the label cardinality bounded.
+func Metrics(registerer prometheus.Registerer, opts ...MetricsOptFunc) func(http.Handler) http.Handler {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	o := &metricsOpts{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	labelNames := slices.Clone(metricLabels)
+	if o.tenantLabel {
+		labelNames = append(labelNames, "tenant")
+	}
+
+	m := &metrics{
+		requests: promutil.Register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests processed.",
+		}, labelNames)),
+		duration: promutil.Register(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, labelNames)),
+		size: promutil.Register(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of HTTP responses in bytes.",
+			Buckets: prometheus.ExponentialBuckets(100, 10, 7),
+		}, labelNames)),
+		inFlight: promutil.Register(registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "Number of HTTP requests currently being served.",
+		})),
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.inFlight.Inc()
+			defer m.inFlight.Dec()
+
+			start := time.Now()
+			rw := promutil.NewResponseRecorder(w)
+
+			var tags *requestTags
+			if o.tenantLabel {
+				var ctx context.Context
+				ctx, tags = withTags(r.Context())
+				r = r.WithContext(ctx)
+			}
+
+			h.ServeHTTP(rw, r)
+
+			route := errchain.RoutePattern(r)
+			if route == "" {
+				route = "unmatched"
+			}
+
+			labels := prometheus.Labels{
+				"method": r.Method,
+				"route":  route,
+				"status": strconv.Itoa(rw.Status),
+			}
+			if tags != nil {
+				labels["tenant"] = tags.get("tenant")
+			}
+
+			m.requests.With(labels).Inc()
+			m.duration.With(labels).Observe(time.Since(start).Seconds())
+			m.size.With(labels).Observe(float64(rw.Bytes))
+		})
+	}
+}
+
+// MetricsHandler returns a http.Handler that exposes the metrics collected by the
+// provided gatherer in the Prometheus text format. If gatherer is nil,
+// prometheus.DefaultGatherer is used.
+//
+// Example:
+//
+//	mux.Handle("GET /metrics", middleware.MetricsHandler(registry))
+func MetricsHandler(gatherer prometheus.Gatherer) http.Handler {
+	if gatherer == nil {
+		gatherer = prometheus.DefaultGatherer
+	}
+
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
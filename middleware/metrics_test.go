@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_Metrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	mux := errchain.NewMux(errchain.New(testErrHandler))
+	mux.Use(Metrics(registry))
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		_, err := w.Write([]byte("hello"))
+		return err
+	})
+
+	for i := 0; i < 3; i++ {
+		mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1", nil))
+	}
+
+	expected := `
+# HELP http_requests_total Total number of HTTP requests processed.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="GET /users/{id}",status="200"} 3
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total")
+	if err != nil {
+		t.Error(err)
+	}
+
+	// constructing the middleware twice must not panic
+	_ = Metrics(registry)
+
+	rec := httptest.NewRecorder()
+	MetricsHandler(registry).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if !strings.Contains(rec.Body.String(), "http_request_duration_seconds") {
+		t.Errorf("expected metrics output to contain duration histogram")
+	}
+}
+
+func Test_Metrics_TenantLabel(t *testing.T) {
+	registry := prometheus.NewRegistry()
+
+	chain := errchain.New(testErrHandler)
+	chain.Use(Tenant(TenantFromHeader("X-Tenant-ID")))
+
+	mux := errchain.NewMux(chain)
+	mux.Use(Metrics(registry, WithTenantLabel()))
+	mux.Get("/items", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	for _, tenant := range []string{"acme", "acme", "globex"} {
+		req := httptest.NewRequest(http.MethodGet, "/items", nil)
+		req.Header.Set("X-Tenant-ID", tenant)
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	expected := `
+# HELP http_requests_total Total number of HTTP requests processed.
+# TYPE http_requests_total counter
+http_requests_total{method="GET",route="GET /items",status="200",tenant="acme"} 2
+http_requests_total{method="GET",route="GET /items",status="200",tenant="globex"} 1
+`
+	err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "http_requests_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+var testErrHandler = func(h errchain.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err := h.ServeHTTP(w, r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+func assertEqual[T comparable](t *testing.T, got, expect T) {
+	t.Helper()
+	if expect != got {
+		t.Errorf("expect %v, got %v", expect, got)
+	}
+}
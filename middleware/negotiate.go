@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/server"
+)
+
+// AllowContentType returns an errchain.Middleware that rejects requests with a body
+// whose Content-Type is not one of the provided media types. Rejected requests
+// receive a 415 Unsupported Media Type error response written with server.Error.
+// Requests without a body are always allowed.
+//
+// Example:
+//
+//	mux.Post("/users", createUser, middleware.AllowContentType("application/json"))
+func AllowContentType(types ...string) errchain.Middleware {
+	allowed := make(map[string]struct{}, len(types))
+	for _, t := range types {
+		allowed[strings.ToLower(strings.TrimSpace(t))] = struct{}{}
+	}
+
+	return func(h errchain.Handler) errchain.Handler {
+		return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			if r.ContentLength == 0 && len(r.TransferEncoding) == 0 {
+				return h.ServeHTTP(w, r)
+			}
+
+			mediatype, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err == nil {
+				if _, ok := allowed[mediatype]; ok {
+					return h.ServeHTTP(w, r)
+				}
+			}
+
+			return server.Error().
+				Status(http.StatusUnsupportedMediaType).
+				Msgf("unsupported content type %q", r.Header.Get("Content-Type")).
+				Data(map[string]any{"allowed": types}).
+				Write(r.Context(), w)
+		})
+	}
+}
+
+// RequireAccept returns an errchain.Middleware that rejects requests whose Accept
+// header does not accept any of the provided media types. Rejected requests receive
+// a 406 Not Acceptable error response written with server.Error. Requests without
+// an Accept header are always allowed.
+//
+// Example:
+//
+//	chain.Use(middleware.RequireAccept("application/json"))
+func RequireAccept(types ...string) errchain.Middleware {
+	return func(h errchain.Handler) errchain.Handler {
+		return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			accept := r.Header.Values("Accept")
+			if len(accept) == 0 || accepts(strings.Join(accept, ","), types) {
+				return h.ServeHTTP(w, r)
+			}
+
+			// the error response is always JSON, regardless of the Accept header
+			return server.Error().
+				Status(http.StatusNotAcceptable).
+				Msg("none of the requested media types are available").
+				Data(map[string]any{"available": types}).
+				Write(r.Context(), w)
+		})
+	}
+}
+
+// accepts reports whether any of the media ranges in the Accept header value
+// match one of the provided media types.
+func accepts(header string, types []string) bool {
+	for _, part := range strings.Split(header, ",") {
+		mediarange, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		if v, ok := params["q"]; ok {
+			if q, err := strconv.ParseFloat(v, 64); err != nil || q <= 0 {
+				continue
+			}
+		}
+
+		for _, t := range types {
+			if matchMediaRange(mediarange, strings.ToLower(t)) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchMediaRange(mediarange, mediatype string) bool {
+	if mediarange == "*/*" || mediarange == mediatype {
+		return true
+	}
+
+	prefix, ok := strings.CutSuffix(mediarange, "/*")
+	if !ok {
+		return false
+	}
+
+	return strings.HasPrefix(mediatype, prefix+"/")
+}
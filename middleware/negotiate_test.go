@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+func Test_AllowContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        int
+	}{
+		{name: "no body", body: "", contentType: "", want: http.StatusOK},
+		{name: "allowed", body: "{}", contentType: "application/json", want: http.StatusOK},
+		{name: "allowed with params", body: "{}", contentType: "Application/JSON; charset=utf-8", want: http.StatusOK},
+		{name: "not allowed", body: "a=b", contentType: "application/x-www-form-urlencoded", want: http.StatusUnsupportedMediaType},
+		{name: "missing", body: "{}", contentType: "", want: http.StatusUnsupportedMediaType},
+	}
+
+	h := AllowContentType("application/json")(errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			rec := httptest.NewRecorder()
+			_ = h.ServeHTTP(rec, req)
+
+			assertEqual(t, rec.Code, tt.want)
+		})
+	}
+}
+
+func Test_RequireAccept(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   int
+	}{
+		{name: "no header", accept: "", want: http.StatusOK},
+		{name: "exact", accept: "application/json", want: http.StatusOK},
+		{name: "wildcard", accept: "*/*", want: http.StatusOK},
+		{name: "subtype wildcard", accept: "text/html, application/*;q=0.8", want: http.StatusOK},
+		{name: "q zero", accept: "application/json;q=0", want: http.StatusNotAcceptable},
+		{name: "q zero long", accept: "application/json;q=0.0000", want: http.StatusNotAcceptable},
+		{name: "q zero trailing dot", accept: "application/json;q=0.", want: http.StatusNotAcceptable},
+		{name: "q invalid", accept: "application/json;q=abc", want: http.StatusNotAcceptable},
+		{name: "not acceptable", accept: "text/html", want: http.StatusNotAcceptable},
+	}
+
+	h := RequireAccept("application/json")(errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	}))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+
+			rec := httptest.NewRecorder()
+			_ = h.ServeHTTP(rec, req)
+
+			assertEqual(t, rec.Code, tt.want)
+		})
+	}
+}
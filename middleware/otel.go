@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/internal/promutil"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/hay-kot/httpkit/middleware"
+
+// Otel returns an errchain.Middleware that creates a server span for every request.
+// The span is named after the route pattern the request was matched against (see
+// errchain.RoutePattern) and falls back to the request method when no pattern is
+// available.
+//
+// Incoming trace context is extracted from the request headers using the provided
+// propagators and errors returned by the handler chain are recorded on the span.
+//
+// If tp is nil, the global TracerProvider is used. If propagators is nil, the
+// global TextMapPropagator is used.
+func Otel(tp trace.TracerProvider, propagators propagation.TextMapPropagator) errchain.Middleware {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	if propagators == nil {
+		propagators = otel.GetTextMapPropagator()
+	}
+
+	tracer := tp.Tracer(tracerName)
+
+	return func(h errchain.Handler) errchain.Handler {
+		return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			ctx := propagators.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			route := errchain.RoutePattern(r)
+
+			name := route
+			if name == "" {
+				name = "HTTP " + r.Method
+			}
+
+			attrs := []attribute.KeyValue{
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			}
+
+			if route != "" {
+				attrs = append(attrs, attribute.String("http.route", route))
+			}
+
+			ctx, span := tracer.Start(ctx, name,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attrs...),
+			)
+			defer span.End()
+
+			rw := promutil.NewResponseRecorder(w)
+
+			err := h.ServeHTTP(rw, r.WithContext(ctx))
+
+			if rw.WroteHeader {
+				span.SetAttributes(attribute.Int("http.response.status_code", rw.Status))
+			}
+
+			switch {
+			case err != nil:
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			case rw.Status >= http.StatusInternalServerError:
+				span.SetStatus(codes.Error, http.StatusText(rw.Status))
+			}
+
+			return err
+		})
+	}
+}
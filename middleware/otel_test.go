@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_Otel(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	chain := errchain.New(testErrHandler)
+	chain.Use(Otel(tp, propagation.TraceContext{}))
+
+	mux := errchain.NewMux(chain)
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return errors.New("user not found")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+
+	span := spans[0]
+
+	assertEqual(t, span.Name(), "GET /users/{id}")
+	assertEqual(t, span.Status().Code, codes.Error)
+	assertEqual(t, span.Parent().TraceID().String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+}
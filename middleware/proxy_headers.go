@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+var proxyHeaders = []string{
+	"Forwarded",
+	"X-Forwarded-For",
+	"X-Forwarded-Host",
+	"X-Forwarded-Proto",
+}
+
+// ProxyHeaders returns a middleware that rewrites r.RemoteAddr, r.Host, r.URL.Host
+// and r.URL.Scheme from the Forwarded (RFC 7239) or X-Forwarded-* headers when the
+// request was received from a trusted proxy. The Forwarded header takes precedence
+// when both are present.
+//
+// The client address is the right-most address in the forwarding chain that is not
+// a trusted proxy, so clients cannot spoof their address by sending the headers
+// themselves. r.RemoteAddr keeps the host:port form, with the port of the client
+// if the proxy forwarded it and 0 otherwise. Requests from untrusted peers have the
+// proxy headers removed.
+//
+// Example:
+//
+//	mux.Use(middleware.ProxyHeaders([]netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}))
+func ProxyHeaders(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		addr = addr.Unmap()
+		for _, p := range trusted {
+			if p.Contains(addr) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, ok := parseAddr(r.RemoteAddr)
+			if !ok || !isTrusted(peer) {
+				for _, name := range proxyHeaders {
+					r.Header.Del(name)
+				}
+
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			var fwd forwarded
+			if v := r.Header.Values("Forwarded"); len(v) > 0 {
+				fwd = parseForwarded(v)
+			} else {
+				fwd = forwarded{
+					proto: r.Header.Get("X-Forwarded-Proto"),
+					host:  r.Header.Get("X-Forwarded-Host"),
+				}
+
+				for _, v := range r.Header.Values("X-Forwarded-For") {
+					for _, addr := range strings.Split(v, ",") {
+						fwd.chain = append(fwd.chain, strings.TrimSpace(addr))
+					}
+				}
+			}
+
+			// walk the chain right to left and stop at the first untrusted address
+			for i := len(fwd.chain) - 1; i >= 0; i-- {
+				addr, ok := parseAddr(fwd.chain[i])
+				if !ok {
+					break
+				}
+
+				r.RemoteAddr = net.JoinHostPort(addr.String(), addrPort(fwd.chain[i]))
+				if !isTrusted(addr) {
+					break
+				}
+			}
+
+			if proto := strings.ToLower(strings.TrimSpace(fwd.proto)); proto == "http" || proto == "https" {
+				r.URL.Scheme = proto
+			}
+
+			if host := strings.TrimSpace(fwd.host); host != "" {
+				r.Host = host
+				r.URL.Host = host
+			}
+
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+type forwarded struct {
+	chain []string
+	proto string
+	host  string
+}
+
+// parseForwarded parses the Forwarded header values. The proto and host are taken
+// from the last element, which was added by the closest proxy.
+func parseForwarded(values []string) forwarded {
+	var fwd forwarded
+
+	for _, v := range values {
+		for _, element := range strings.Split(v, ",") {
+			fwd.proto, fwd.host = "", ""
+
+			for _, pair := range strings.Split(element, ";") {
+				key, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+
+				value = strings.Trim(value, `"`)
+
+				switch strings.ToLower(key) {
+				case "for":
+					fwd.chain = append(fwd.chain, value)
+				case "proto":
+					fwd.proto = value
+				case "host":
+					fwd.host = value
+				}
+			}
+		}
+	}
+
+	return fwd
+}
+
+// parseAddr parses an IP address with an optional port, including the bracketed IPv6
+// form used by the Forwarded header.
+func parseAddr(s string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+
+	addr, err := netip.ParseAddr(strings.Trim(s, "[]"))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+
+	return addr.Unmap(), true
+}
+
+// addrPort returns the numeric port of an address in the forwarding chain, or "0"
+// if it has none.
+func addrPort(s string) string {
+	_, port, err := net.SplitHostPort(s)
+	if err != nil {
+		return "0"
+	}
+
+	if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+		return "0"
+	}
+
+	return port
+}
@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+)
+
+func Test_ProxyHeaders(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		wantAddr   string
+		wantHost   string
+		wantScheme string
+	}{
+		{
+			name:       "untrusted peer",
+			remoteAddr: "203.0.113.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Forwarded-Host": "evil.com"},
+			wantAddr:   "203.0.113.1:1234",
+			wantHost:   "example.com",
+		},
+		{
+			name:       "x-forwarded headers",
+			remoteAddr: "10.0.0.1:1234",
+			headers: map[string]string{
+				"X-Forwarded-For":   "198.51.100.1, 10.0.0.2",
+				"X-Forwarded-Host":  "api.example.com",
+				"X-Forwarded-Proto": "https",
+			},
+			wantAddr:   "198.51.100.1:0",
+			wantHost:   "api.example.com",
+			wantScheme: "https",
+		},
+		{
+			name:       "spoofed x-forwarded-for",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"X-Forwarded-For": "1.1.1.1, 198.51.100.1"},
+			wantAddr:   "198.51.100.1:0",
+			wantHost:   "example.com",
+		},
+		{
+			name:       "forwarded header",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string]string{"Forwarded": `for="[2001:db8::1]:4711";proto=https;host=api.example.com`},
+			wantAddr:   "[2001:db8::1]:4711",
+			wantHost:   "api.example.com",
+			wantScheme: "https",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got *http.Request
+
+			h := ProxyHeaders(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = r
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.URL.Scheme = ""
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+
+			h.ServeHTTP(httptest.NewRecorder(), req)
+
+			assertEqual(t, got.RemoteAddr, tt.wantAddr)
+			assertEqual(t, got.Host, tt.wantHost)
+			assertEqual(t, got.URL.Scheme, tt.wantScheme)
+		})
+	}
+}
@@ -0,0 +1,479 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hay-kot/httpkit/cookies"
+	"github.com/hay-kot/httpkit/ctxkit"
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+// ErrSessionNotFound is returned by a SessionStore when no session exists for the
+// provided ID or the session has expired.
+var ErrSessionNotFound = errors.New("session not found")
+
+// SessionStore defines the contract for persisting session data. Implementations
+// must be safe for concurrent use. Data is opaque to the store and ttl is the
+// duration after which the store may discard the session.
+type SessionStore interface {
+	Get(ctx context.Context, id string) ([]byte, error)
+	Set(ctx context.Context, id string, data []byte, ttl time.Duration) error
+	Delete(ctx context.Context, id string) error
+}
+
+type sessionOpts struct {
+	cookieName      string
+	cookiePath      string
+	cookieDomain    string
+	secure          bool
+	sameSite        http.SameSite
+	idleTimeout     time.Duration
+	absoluteTimeout time.Duration
+	signingKeys     [][]byte
+	now             func() time.Time
+}
+
+type SessionOptFunc func(*sessionOpts)
+
+// WithSessionCookie sets the name of the cookie used to store the session ID.
+//
+// Defaults to "session"
+func WithSessionCookie(name string) SessionOptFunc {
+	return func(o *sessionOpts) {
+		o.cookieName = name
+	}
+}
+
+// WithSessionCookieScope sets the path and domain attributes of the session cookie.
+//
+// Defaults to path "/" and no domain
+func WithSessionCookieScope(path, domain string) SessionOptFunc {
+	return func(o *sessionOpts) {
+		o.cookiePath = path
+		o.cookieDomain = domain
+	}
+}
+
+// WithSessionSecure sets the Secure attribute of the session cookie. Disable this
+// only for local development over plain HTTP.
+//
+// Defaults to true
+func WithSessionSecure(secure bool) SessionOptFunc {
+	return func(o *sessionOpts) {
+		o.secure = secure
+	}
+}
+
+// WithSessionSameSite sets the SameSite attribute of the session cookie.
+//
+// Defaults to http.SameSiteLaxMode
+func WithSessionSameSite(mode http.SameSite) SessionOptFunc {
+	return func(o *sessionOpts) {
+		o.sameSite = mode
+	}
+}
+
+// WithIdleTimeout sets the duration of inactivity after which a session expires.
+//
+// Defaults to 30 minutes
+func WithIdleTimeout(d time.Duration) SessionOptFunc {
+	return func(o *sessionOpts) {
+		o.idleTimeout = d
+	}
+}
+
+// WithAbsoluteTimeout sets the maximum lifetime of a session regardless of activity.
+//
+// Defaults to 24 hours
+func WithAbsoluteTimeout(d time.Duration) SessionOptFunc {
+	return func(o *sessionOpts) {
+		o.absoluteTimeout = d
+	}
+}
+
+// WithSessionSigningKeys signs the session cookie using cookies.SetSigned. The first
+// key is used for signing, all keys are accepted when verifying, see the cookies
+// package for details on key rotation.
+//
+// Defaults to unsigned session cookies
+func WithSessionSigningKeys(keys ...[]byte) SessionOptFunc {
+	return func(o *sessionOpts) {
+		o.signingKeys = keys
+	}
+}
+
+// sessionRecord is the serialized form of a session written to the store.
+type sessionRecord struct {
+	Values    map[string]json.RawMessage `json:"values"`
+	CreatedAt time.Time                  `json:"createdAt"`
+	LastSeen  time.Time                  `json:"lastSeen"`
+}
+
+// SessionData holds the values for a single client session. It is attached to the
+// request context by the Session middleware and can be retrieved with GetSession.
+type SessionData struct {
+	mu         sync.Mutex
+	id         string
+	oldID      string
+	record     sessionRecord
+	modified   bool
+	destroyed  bool
+	isNew      bool
+	committed  bool
+	commitErr  error
+	regenerate bool
+}
+
+// ID returns the current session ID. The ID of a new session is empty until the
+// session is committed.
+func (s *SessionData) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.id
+}
+
+// Set stores the JSON encoding of v under key.
+func (s *SessionData) Set(key string, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.record.Values[key] = b
+	s.modified = true
+	return nil
+}
+
+// Delete removes the value stored under key.
+func (s *SessionData) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.record.Values[key]; ok {
+		delete(s.record.Values, key)
+		s.modified = true
+	}
+}
+
+// Regenerate assigns a new ID to the session while keeping its values. The old ID
+// is removed from the store. Call Regenerate whenever the privilege level of the
+// session changes, for example on login or logout, to prevent session fixation.
+func (s *SessionData) Regenerate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.regenerate = true
+	s.modified = true
+}
+
+// Destroy removes the session from the store and expires the session cookie.
+func (s *SessionData) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.destroyed = true
+	s.record.Values = map[string]json.RawMessage{}
+}
+
+func (s *SessionData) get(key string) (json.RawMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.record.Values[key]
+	return v, ok
+}
+
+var sessionKey = ctxkit.NewKey[*SessionData]("session")
+
+// GetSession returns the SessionData attached to the context by the Session middleware.
+// If no session is present, nil is returned.
+func GetSession(ctx context.Context) *SessionData {
+	s, _ := sessionKey.Get(ctx)
+	return s
+}
+
+// SessionGet returns the value stored under key in the session attached to ctx
+// decoded as T. The second return value is false if there is no session, the key
+// does not exist or the value cannot be decoded as T.
+func SessionGet[T any](ctx context.Context, key string) (T, bool) {
+	var v T
+
+	s := GetSession(ctx)
+	if s == nil {
+		return v, false
+	}
+
+	raw, ok := s.get(key)
+	if !ok {
+		return v, false
+	}
+
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return v, false
+	}
+
+	return v, true
+}
+
+// SessionSet stores v under key in the session attached to ctx. It returns an error
+// if there is no session or v cannot be encoded as JSON.
+func SessionSet(ctx context.Context, key string, v any) error {
+	s := GetSession(ctx)
+	if s == nil {
+		return errors.New("middleware: no session in context")
+	}
+
+	return s.Set(key, v)
+}
+
+// Session returns an errchain.Middleware that loads the session identified by the
+// session cookie from the store and attaches it to the request context. Changes to
+// the session are written back to the store before the response headers are sent.
+//
+// Sessions expire after the idle timeout without activity, or once the absolute
+// timeout has passed since they were created. Empty sessions are never persisted.
+func Session(store SessionStore, opts ...SessionOptFunc) errchain.Middleware {
+	o := &sessionOpts{
+		cookieName:      "session",
+		cookiePath:      "/",
+		secure:          true,
+		sameSite:        http.SameSiteLaxMode,
+		idleTimeout:     30 * time.Minute,
+		absoluteTimeout: 24 * time.Hour,
+		now:             time.Now,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	m := &sessionManager{store: store, opts: o}
+
+	return func(h errchain.Handler) errchain.Handler {
+		return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			s, err := m.load(r)
+			if err != nil {
+				return err
+			}
+
+			sw := &sessionWriter{ResponseWriter: w, commit: func() { m.commit(r.Context(), w, s) }}
+
+			err = h.ServeHTTP(sw, sessionKey.SetRequest(r, s))
+
+			m.commit(r.Context(), w, s)
+
+			return errors.Join(err, s.commitErr)
+		})
+	}
+}
+
+type sessionManager struct {
+	store SessionStore
+	opts  *sessionOpts
+}
+
+func (m *sessionManager) load(r *http.Request) (*SessionData, error) {
+	now := m.opts.now()
+
+	s := &SessionData{
+		isNew: true,
+		record: sessionRecord{
+			Values:    map[string]json.RawMessage{},
+			CreatedAt: now,
+			LastSeen:  now,
+		},
+	}
+
+	id, err := m.readCookie(r)
+	if err != nil || id == "" {
+		return s, nil //nolint:nilerr // missing or invalid cookies start a new session
+	}
+
+	data, err := m.store.Get(r.Context(), id)
+	switch {
+	case errors.Is(err, ErrSessionNotFound):
+		return s, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var record sessionRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return s, nil //nolint:nilerr // corrupt sessions are replaced with a new session
+	}
+
+	if m.expired(record, now) {
+		_ = m.store.Delete(r.Context(), id)
+		return s, nil
+	}
+
+	if record.Values == nil {
+		record.Values = map[string]json.RawMessage{}
+	}
+
+	record.LastSeen = now
+
+	s.id = id
+	s.record = record
+	s.isNew = false
+
+	return s, nil
+}
+
+func (m *sessionManager) expired(record sessionRecord, now time.Time) bool {
+	return now.Sub(record.LastSeen) > m.opts.idleTimeout ||
+		now.Sub(record.CreatedAt) > m.opts.absoluteTimeout
+}
+
+// commit persists the session and sets the session cookie. It is called once,
+// either when the handler first writes to the response or after it returns.
+func (m *sessionManager) commit(ctx context.Context, w http.ResponseWriter, s *SessionData) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.committed {
+		return
+	}
+	s.committed = true
+
+	if s.destroyed {
+		if s.id != "" {
+			s.commitErr = m.store.Delete(ctx, s.id)
+			http.SetCookie(w, m.cookie("", -1))
+		}
+		return
+	}
+
+	// new sessions are only persisted once they hold a value
+	if s.isNew && !s.modified {
+		return
+	}
+
+	ttl := m.opts.idleTimeout
+	if remaining := m.opts.absoluteTimeout - s.record.LastSeen.Sub(s.record.CreatedAt); remaining < ttl {
+		ttl = remaining
+	}
+
+	// a non-positive ttl would be read as "no expiry" by both the cookie and
+	// Redis, so the session is treated as expired instead
+	if ttl <= 0 {
+		if s.id != "" {
+			s.commitErr = m.store.Delete(ctx, s.id)
+		}
+		http.SetCookie(w, m.cookie("", -1))
+		return
+	}
+
+	if s.id == "" || s.regenerate {
+		id, err := newSessionID()
+		if err != nil {
+			s.commitErr = err
+			return
+		}
+
+		s.oldID, s.id = s.id, id
+	}
+
+	if s.oldID != "" {
+		if err := m.store.Delete(ctx, s.oldID); err != nil {
+			s.commitErr = err
+			return
+		}
+	}
+
+	data, err := json.Marshal(s.record)
+	if err != nil {
+		s.commitErr = err
+		return
+	}
+
+	if err := m.store.Set(ctx, s.id, data, ttl); err != nil {
+		s.commitErr = err
+		return
+	}
+
+	s.commitErr = m.writeCookie(w, m.cookie(s.id, maxAge(ttl)))
+}
+
+func (m *sessionManager) readCookie(r *http.Request) (string, error) {
+	if len(m.opts.signingKeys) > 0 {
+		return cookies.GetSigned(r, m.opts.cookieName, m.opts.signingKeys...)
+	}
+
+	c, err := r.Cookie(m.opts.cookieName)
+	if err != nil {
+		return "", err
+	}
+
+	return c.Value, nil
+}
+
+func (m *sessionManager) writeCookie(w http.ResponseWriter, c *http.Cookie) error {
+	if len(m.opts.signingKeys) > 0 {
+		return cookies.SetSigned(w, c, m.opts.signingKeys...)
+	}
+
+	http.SetCookie(w, c)
+	return nil
+}
+
+func (m *sessionManager) cookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     m.opts.cookieName,
+		Value:    value,
+		Path:     m.opts.cookiePath,
+		Domain:   m.opts.cookieDomain,
+		MaxAge:   maxAge,
+		Secure:   m.opts.secure,
+		HttpOnly: true,
+		SameSite: m.opts.sameSite,
+	}
+}
+
+// maxAge converts ttl to a cookie Max-Age, rounding up so that a ttl below one
+// second does not become 0, which drops the attribute and makes the cookie a
+// browser session cookie.
+func maxAge(ttl time.Duration) int {
+	return int((ttl + time.Second - 1) / time.Second)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// sessionWriter commits the session before the response headers are written so the
+// session cookie can still be set.
+type sessionWriter struct {
+	http.ResponseWriter
+	commit func()
+}
+
+func (sw *sessionWriter) WriteHeader(code int) {
+	sw.commit()
+	sw.ResponseWriter.WriteHeader(code)
+}
+
+func (sw *sessionWriter) Write(b []byte) (int, error) {
+	sw.commit()
+	return sw.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying http.ResponseWriter for use with
+// http.ResponseController.
+func (sw *sessionWriter) Unwrap() http.ResponseWriter {
+	return sw.ResponseWriter
+}
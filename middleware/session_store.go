@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+var _ SessionStore = (*MemoryStore)(nil)
+
+type memoryEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+// memorySweepInterval is the minimum time between sweeps of expired sessions in
+// MemoryStore.Set.
+const memorySweepInterval = time.Minute
+
+// MemoryStore is an in-memory SessionStore. Sessions are lost when the process
+// exits, so it is primarily useful for development, tests and single instance
+// deployments.
+//
+// Expired sessions are removed when they are read, and by a sweep that runs at
+// most once per minute from Set.
+type MemoryStore struct {
+	mu        sync.Mutex
+	sessions  map[string]memoryEntry
+	now       func() time.Time
+	nextSweep time.Time
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions: map[string]memoryEntry{},
+		now:      time.Now,
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+
+	if s.now().After(e.expires) {
+		delete(s.sessions, id)
+		return nil, ErrSessionNotFound
+	}
+
+	return e.data, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, id string, data []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+
+	// sessions that are never read again would otherwise stay in the map forever,
+	// the sweep is rate limited so Set stays O(1) on the request path
+	if now.After(s.nextSweep) {
+		s.nextSweep = now.Add(memorySweepInterval)
+
+		for k, e := range s.sessions {
+			if now.After(e.expires) {
+				delete(s.sessions, k)
+			}
+		}
+	}
+
+	s.sessions[id] = memoryEntry{data: data, expires: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+func Test_Session(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	chain := errchain.New(testErrHandler)
+	chain.Use(Session(store, WithSessionSigningKeys([]byte("secret")), func(o *sessionOpts) {
+		o.now = func() time.Time { return now }
+	}))
+
+	mux := errchain.NewMux(chain)
+	mux.Post("/login", func(w http.ResponseWriter, r *http.Request) error {
+		GetSession(r.Context()).Regenerate()
+		return SessionSet(r.Context(), "user_id", 42)
+	})
+	mux.Get("/me", func(w http.ResponseWriter, r *http.Request) error {
+		id, ok := SessionGet[int](r.Context(), "user_id")
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return nil
+		}
+
+		return SessionSet(r.Context(), "seen", id)
+	})
+
+	// anonymous requests do not create a session
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/me", nil))
+	assertEqual(t, rec.Code, http.StatusUnauthorized)
+	assertEqual(t, len(rec.Result().Cookies()), 0)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected session cookie, got %d cookies", len(cookies))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(cookies[0])
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assertEqual(t, rec.Code, http.StatusOK)
+
+	// idle timeout expires the session
+	now = now.Add(time.Hour)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assertEqual(t, rec.Code, http.StatusUnauthorized)
+}
+
+func Test_Session_AbsoluteTimeout(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Now()
+
+	chain := errchain.New(testErrHandler)
+	chain.Use(Session(store, WithIdleTimeout(time.Hour), WithAbsoluteTimeout(90*time.Minute), func(o *sessionOpts) {
+		o.now = func() time.Time { return now }
+	}))
+
+	mux := errchain.NewMux(chain)
+	mux.Post("/login", func(w http.ResponseWriter, r *http.Request) error {
+		return SessionSet(r.Context(), "user_id", 42)
+	})
+	mux.Get("/me", func(w http.ResponseWriter, r *http.Request) error {
+		if _, ok := SessionGet[int](r.Context(), "user_id"); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+	cookie := rec.Result().Cookies()[0]
+
+	req := httptest.NewRequest(http.MethodGet, "/me", nil)
+	req.AddCookie(cookie)
+
+	// activity within the idle timeout keeps the session alive until the
+	// absolute timeout is reached
+	now = now.Add(45 * time.Minute)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assertEqual(t, rec.Code, http.StatusOK)
+
+	now = now.Add(46 * time.Minute)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assertEqual(t, rec.Code, http.StatusUnauthorized)
+}
+
+func Test_Session_SubSecondTTL(t *testing.T) {
+	store := NewMemoryStore()
+	created := time.Now()
+	now := created
+
+	chain := errchain.New(testErrHandler)
+	chain.Use(Session(store, WithAbsoluteTimeout(time.Minute), func(o *sessionOpts) {
+		o.now = func() time.Time { return now }
+	}))
+
+	mux := errchain.NewMux(chain)
+	mux.Post("/set", func(w http.ResponseWriter, r *http.Request) error {
+		return SessionSet(r.Context(), "n", 1)
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/set", nil))
+	cookie := rec.Result().Cookies()[0]
+
+	req := httptest.NewRequest(http.MethodPost, "/set", nil)
+	req.AddCookie(cookie)
+
+	// less than a second left rounds up rather than dropping Max-Age
+	now = created.Add(time.Minute - 500*time.Millisecond)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assertEqual(t, rec.Result().Cookies()[0].MaxAge, 1)
+
+	// no time left expires the session rather than persisting it without a ttl
+	now = created.Add(time.Minute)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	assertEqual(t, rec.Result().Cookies()[0].MaxAge, -1)
+
+	_, err := store.Get(req.Context(), cookie.Value)
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected session to be removed, got %v", err)
+	}
+}
+
+func Test_Session_Destroy(t *testing.T) {
+	store := NewMemoryStore()
+
+	chain := errchain.New(testErrHandler)
+	chain.Use(Session(store))
+
+	mux := errchain.NewMux(chain)
+	mux.Post("/login", func(w http.ResponseWriter, r *http.Request) error {
+		return SessionSet(r.Context(), "user_id", 42)
+	})
+	mux.Post("/logout", func(w http.ResponseWriter, r *http.Request) error {
+		GetSession(r.Context()).Destroy()
+		return nil
+	})
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/login", nil))
+	cookie := rec.Result().Cookies()[0]
+
+	req := httptest.NewRequest(http.MethodPost, "/logout", nil)
+	req.AddCookie(cookie)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].MaxAge != -1 {
+		t.Fatalf("expected expired session cookie, got %v", cookies)
+	}
+
+	_, err := store.Get(req.Context(), cookie.Value)
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("expected session to be removed, got %v", err)
+	}
+}
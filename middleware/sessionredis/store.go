@@ -0,0 +1,49 @@
+// Package sessionredis provides a middleware.SessionStore backed by Redis. It is a
+// separate module so that the Redis client is only a dependency of applications
+// using it.
+package sessionredis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hay-kot/httpkit/middleware"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ middleware.SessionStore = (*Store)(nil)
+
+// Store is a middleware.SessionStore backed by Redis. Expiry is delegated to Redis
+// using the ttl provided by the Session middleware.
+type Store struct {
+	client redis.Cmdable
+	prefix string
+}
+
+// New creates a new Store using the provided client. All keys are prefixed with
+// prefix, for example "session:".
+//
+// Example:
+//
+//	mux.Use(middleware.Session(sessionredis.New(rdb, "session:")))
+func New(client redis.Cmdable, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+func (s *Store) Get(ctx context.Context, id string) ([]byte, error) {
+	data, err := s.client.Get(ctx, s.prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, middleware.ErrSessionNotFound
+	}
+
+	return data, err
+}
+
+func (s *Store) Set(ctx context.Context, id string, data []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+id, data, ttl).Err()
+}
+
+func (s *Store) Delete(ctx context.Context, id string) error {
+	return s.client.Del(ctx, s.prefix+id).Err()
+}
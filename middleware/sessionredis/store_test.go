@@ -0,0 +1,54 @@
+package sessionredis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hay-kot/httpkit/middleware"
+	"github.com/redis/go-redis/v9"
+)
+
+func Test_Store(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "session:")
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "missing")
+	if !errors.Is(err, middleware.ErrSessionNotFound) {
+		t.Fatalf("expected ErrSessionNotFound, got %v", err)
+	}
+
+	if err := store.Set(ctx, "abc", []byte("data"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if ttl := mr.TTL("session:abc"); ttl != time.Minute {
+		t.Errorf("expected ttl of a minute, got %s", ttl)
+	}
+
+	data, err := store.Get(ctx, "abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "data" {
+		t.Errorf("expected data, got %q", data)
+	}
+
+	mr.FastForward(time.Minute)
+	_, err = store.Get(ctx, "abc")
+	if !errors.Is(err, middleware.ErrSessionNotFound) {
+		t.Fatalf("expected expired session, got %v", err)
+	}
+
+	if err := store.Set(ctx, "abc", []byte("data"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Delete(ctx, "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if mr.Exists("session:abc") {
+		t.Error("expected session to be deleted")
+	}
+}
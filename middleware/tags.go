@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"github.com/hay-kot/httpkit/ctxkit"
+)
+
+// requestTags are key/value pairs attached to a request by inner middleware that are
+// read by outer middleware once the request has been served. This allows values that
+// are resolved within the error chain, like the tenant, to be included in the access
+// log and metrics.
+type requestTags struct {
+	mu   sync.Mutex
+	tags map[string]string
+}
+
+var tagsKey = ctxkit.NewKey[*requestTags]("tags")
+
+// withTags returns a context carrying a requestTags value. If the context already
+// carries one, it is reused.
+func withTags(ctx context.Context) (context.Context, *requestTags) {
+	if t, ok := tagsKey.Get(ctx); ok {
+		return ctx, t
+	}
+
+	t := &requestTags{tags: map[string]string{}}
+	return tagsKey.Set(ctx, t), t
+}
+
+// setTag sets a tag on the request if an outer middleware is collecting tags.
+func setTag(ctx context.Context, key, value string) {
+	t, ok := tagsKey.Get(ctx)
+	if !ok {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tags[key] = value
+}
+
+func (t *requestTags) get(key string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.tags[key]
+}
+
+func (t *requestTags) all() map[string]string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.tags) == 0 {
+		return nil
+	}
+
+	return maps.Clone(t.tags)
+}
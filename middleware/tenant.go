@@ -0,0 +1,138 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/hay-kot/httpkit/ctxkit"
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrUnknownTenant should be returned by a TenantResolver when the request does not
+// identify a known tenant.
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// TenantID identifies a tenant in a multi-tenant application.
+type TenantID string
+
+// TenantResolver resolves the tenant of a request.
+type TenantResolver func(r *http.Request) (TenantID, error)
+
+// TenantKey is the context key of the tenant resolved by the Tenant middleware.
+var TenantKey = ctxkit.NewKey[TenantID]("tenant")
+
+// Tenant returns an errchain.Middleware that resolves the tenant of every request
+// and stores it in the request context, see GetTenant.
+//
+// If the resolver returns ErrUnknownTenant, a 404 Not Found error response is
+// written with server.Error. Any other error is returned to the error chain.
+//
+// The tenant is added as the "tenant.id" attribute of the active span when used
+// with the Otel middleware, to the tags of the AccessLog JSON format and to the
+// "tenant" label of the Metrics middleware when enabled with WithTenantLabel.
+func Tenant(resolver TenantResolver) errchain.Middleware {
+	return func(h errchain.Handler) errchain.Handler {
+		return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+			id, err := resolver(r)
+			if err != nil {
+				if errors.Is(err, ErrUnknownTenant) {
+					return server.Err(err).
+						Status(http.StatusNotFound).
+						Msg("unknown tenant").
+						Write(r.Context(), w)
+				}
+
+				return err
+			}
+
+			ctx := TenantKey.Set(r.Context(), id)
+
+			trace.SpanFromContext(ctx).SetAttributes(attribute.String("tenant.id", string(id)))
+			setTag(ctx, "tenant", string(id))
+
+			return h.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetTenant returns the tenant resolved by the Tenant middleware.
+func GetTenant(ctx context.Context) (TenantID, bool) {
+	return TenantKey.Get(ctx)
+}
+
+// TenantFromSubdomain returns a TenantResolver that uses the first label of the
+// request host as the tenant when the host is a subdomain of domain. For example
+// with domain "example.com", the host "acme.example.com" resolves to "acme".
+func TenantFromSubdomain(domain string) TenantResolver {
+	suffix := "." + strings.TrimPrefix(strings.ToLower(domain), ".")
+
+	return func(r *http.Request) (TenantID, error) {
+		host := strings.ToLower(r.Host)
+		if i := strings.LastIndexByte(host, ':'); i != -1 && !strings.Contains(host[i:], "]") {
+			host = host[:i]
+		}
+
+		sub, ok := strings.CutSuffix(host, suffix)
+		if !ok || sub == "" || strings.Contains(sub, ".") {
+			return "", ErrUnknownTenant
+		}
+
+		return TenantID(sub), nil
+	}
+}
+
+// TenantFromHeader returns a TenantResolver that reads the tenant from the provided
+// request header, for example "X-Tenant-ID".
+func TenantFromHeader(header string) TenantResolver {
+	return func(r *http.Request) (TenantID, error) {
+		v := r.Header.Get(header)
+		if v == "" {
+			return "", ErrUnknownTenant
+		}
+
+		return TenantID(v), nil
+	}
+}
+
+// TenantFromPath returns a TenantResolver that reads the tenant from the named path
+// wildcard of the route, for example "tenant" for the pattern "/{tenant}/users".
+//
+// Path values are only available once the request has been routed, so the Tenant
+// middleware must be registered on the ErrChain or the route.
+func TenantFromPath(name string) TenantResolver {
+	return func(r *http.Request) (TenantID, error) {
+		v := r.PathValue(name)
+		if v == "" {
+			return "", ErrUnknownTenant
+		}
+
+		return TenantID(v), nil
+	}
+}
+
+// KnownTenants wraps a TenantResolver and returns ErrUnknownTenant for any tenant
+// that is not in the provided list.
+func KnownTenants(resolver TenantResolver, tenants ...TenantID) TenantResolver {
+	known := make(map[TenantID]struct{}, len(tenants))
+	for _, t := range tenants {
+		known[t] = struct{}{}
+	}
+
+	return func(r *http.Request) (TenantID, error) {
+		id, err := resolver(r)
+		if err != nil {
+			return "", err
+		}
+
+		if _, ok := known[id]; !ok {
+			return "", ErrUnknownTenant
+		}
+
+		return id, nil
+	}
+}
@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+func Test_TenantResolvers(t *testing.T) {
+	tests := []struct {
+		name     string
+		resolver TenantResolver
+		pattern  string
+		target   string
+		header   string
+		want     TenantID
+		wantCode int
+	}{
+		{
+			name:     "subdomain",
+			resolver: TenantFromSubdomain("example.com"),
+			pattern:  "/",
+			target:   "http://acme.example.com:8080/",
+			want:     "acme",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "subdomain missing",
+			resolver: TenantFromSubdomain("example.com"),
+			pattern:  "/",
+			target:   "http://example.com/",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "header",
+			resolver: TenantFromHeader("X-Tenant-ID"),
+			pattern:  "/",
+			target:   "/",
+			header:   "acme",
+			want:     "acme",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "path",
+			resolver: TenantFromPath("tenant"),
+			pattern:  "/{tenant}/users",
+			target:   "/acme/users",
+			want:     "acme",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "known tenants",
+			resolver: KnownTenants(TenantFromPath("tenant"), "acme"),
+			pattern:  "/{tenant}/users",
+			target:   "/other/users",
+			wantCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got TenantID
+
+			chain := errchain.New(testErrHandler)
+			chain.Use(Tenant(tt.resolver))
+
+			mux := errchain.NewMux(chain)
+			mux.Get(tt.pattern, func(w http.ResponseWriter, r *http.Request) error {
+				got, _ = GetTenant(r.Context())
+				return nil
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+
+			assertEqual(t, rec.Code, tt.wantCode)
+			assertEqual(t, got, tt.want)
+		})
+	}
+}
+
+func Test_Tenant_AccessLogTag(t *testing.T) {
+	out := &bytes.Buffer{}
+
+	chain := errchain.New(testErrHandler)
+	chain.Use(Tenant(TenantFromHeader("X-Tenant-ID")))
+
+	mux := errchain.NewMux(chain)
+	mux.Use(AccessLog(out, FormatJSON))
+	mux.Get("/", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(out.String(), `"tags":{"tenant":"acme"}`) {
+		t.Errorf("expected tenant tag in access log, got %s", out.String())
+	}
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/hay-kot/httpkit/internal/env"
+)
+
+// NewFromEnv returns an http.Server configured from environment variables named
+// "<prefix>_<NAME>", for twelve-factor deployments. All variables are optional:
+//
+//	<prefix>_HOST                 host to listen on (default all interfaces)
+//	<prefix>_PORT                 port to listen on (default 8080)
+//	<prefix>_READ_TIMEOUT         http.Server.ReadTimeout (default 10s)
+//	<prefix>_READ_HEADER_TIMEOUT  http.Server.ReadHeaderTimeout (default 5s)
+//	<prefix>_WRITE_TIMEOUT        http.Server.WriteTimeout (default 10s)
+//	<prefix>_IDLE_TIMEOUT         http.Server.IdleTimeout (default 60s)
+//	<prefix>_TLS_CERT_FILE        PEM certificate file, requires TLS_KEY_FILE
+//	<prefix>_TLS_KEY_FILE         PEM key file, requires TLS_CERT_FILE
+//
+// Durations use the time.ParseDuration format, for example "30s". When the TLS
+// files are set, the certificate is loaded into the TLSConfig of the server, so
+// it is started with ListenAndServeTLS("", ""). Every invalid variable is
+// reported in the returned error.
+//
+// The Handler of the server is not set.
+//
+// Example:
+//
+//	srv, err := server.NewFromEnv("HTTP")
+//	if err != nil {
+//	  return err
+//	}
+//	srv.Handler = mux
+func NewFromEnv(prefix string) (*http.Server, error) {
+	r := env.New(prefix)
+
+	host := r.String("HOST", "")
+	port := r.Int("PORT", 8080)
+	if port < 0 || port > 65535 {
+		r.Errorf("PORT", "port %d out of range", port)
+	}
+
+	srv := &http.Server{
+		Addr:              net.JoinHostPort(host, strconv.Itoa(port)),
+		ReadTimeout:       r.Duration("READ_TIMEOUT", 10*time.Second),
+		ReadHeaderTimeout: r.Duration("READ_HEADER_TIMEOUT", 5*time.Second),
+		WriteTimeout:      r.Duration("WRITE_TIMEOUT", 10*time.Second),
+		IdleTimeout:       r.Duration("IDLE_TIMEOUT", 60*time.Second),
+	}
+
+	certFile, hasCert := r.Lookup("TLS_CERT_FILE")
+	keyFile, hasKey := r.Lookup("TLS_KEY_FILE")
+
+	switch {
+	case hasCert && hasKey:
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			r.Errorf("TLS_CERT_FILE", "load key pair: %v", err)
+			break
+		}
+
+		srv.TLSConfig = &tls.Config{
+			MinVersion:   tls.VersionTLS12,
+			Certificates: []tls.Certificate{cert},
+		}
+	case hasCert:
+		r.Errorf("TLS_KEY_FILE", "required when %s is set", r.Name("TLS_CERT_FILE"))
+	case hasKey:
+		r.Errorf("TLS_CERT_FILE", "required when %s is set", r.Name("TLS_KEY_FILE"))
+	}
+
+	if err := r.Err(); err != nil {
+		return nil, fmt.Errorf("server: invalid environment: %w", err)
+	}
+
+	return srv, nil
+}
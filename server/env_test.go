@@ -0,0 +1,47 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_NewFromEnv(t *testing.T) {
+	t.Setenv("HTTP_HOST", "127.0.0.1")
+	t.Setenv("HTTP_PORT", "9000")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "1m")
+
+	srv, err := NewFromEnv("HTTP")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if srv.Addr != "127.0.0.1:9000" {
+		t.Errorf("expected addr 127.0.0.1:9000, got %s", srv.Addr)
+	}
+
+	if srv.WriteTimeout != time.Minute || srv.ReadTimeout != 10*time.Second {
+		t.Errorf("unexpected timeouts write=%s read=%s", srv.WriteTimeout, srv.ReadTimeout)
+	}
+
+	if srv.TLSConfig != nil {
+		t.Error("expected no TLS config")
+	}
+}
+
+func Test_NewFromEnv_Invalid(t *testing.T) {
+	t.Setenv("HTTP_PORT", "http")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "forever")
+	t.Setenv("HTTP_TLS_CERT_FILE", "cert.pem")
+
+	_, err := NewFromEnv("HTTP")
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	for _, want := range []string{"HTTP_PORT", "HTTP_IDLE_TIMEOUT", "HTTP_TLS_KEY_FILE: required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %s, got %v", want, err)
+		}
+	}
+}
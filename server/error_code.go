@@ -0,0 +1,91 @@
+package server
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+// ErrorCoder is implemented by errors that carry a machine-readable code, for example
+// errors created with errtrace.WithCode.
+type ErrorCoder interface {
+	ErrorCode() string
+}
+
+// StatusCoder is implemented by errors that carry the HTTP status the response should
+// use, for example errors created with errtrace.WrapStatus.
+type StatusCoder interface {
+	HTTPStatus() int
+}
+
+// ErrorDataer is implemented by errors that carry additional data for the client,
+// for example the field errors returned by the bind package.
+type ErrorDataer interface {
+	ErrorData() any
+}
+
+// CodeMapperFunc maps an error code to the HTTP status and message sent to the
+// client. ok must be false if the code is unknown.
+type CodeMapperFunc func(code string) (status int, msg string, ok bool)
+
+var codeMapper atomic.Pointer[CodeMapperFunc]
+
+// SetCodeMapper sets the function used by the ErrorBuilder to map error codes to
+// HTTP status codes and client messages. The mapping is only applied when the
+// error provided to the ErrorBuilder carries a code (see ErrorCoder) and only for
+// values that were not set explicitly with Status or Msg. It is safe to call
+// concurrently with requests being served. Passing nil removes the mapper.
+//
+// Example:
+//
+//	server.SetCodeMapper(func(code string) (int, string, bool) {
+//	  switch code {
+//	  case "user_not_found":
+//	    return http.StatusNotFound, "user not found", true
+//	  }
+//	  return 0, "", false
+//	})
+func SetCodeMapper(fn CodeMapperFunc) {
+	if fn == nil {
+		codeMapper.Store(nil)
+		return
+	}
+
+	codeMapper.Store(&fn)
+}
+
+// mapCode maps code using the function set by SetCodeMapper.
+func mapCode(code string) (status int, msg string, ok bool) {
+	fn := codeMapper.Load()
+	if fn == nil {
+		return 0, "", false
+	}
+
+	return (*fn)(code)
+}
+
+func errorCode(err error) string {
+	var coder ErrorCoder
+	if err == nil || !errors.As(err, &coder) {
+		return ""
+	}
+
+	return coder.ErrorCode()
+}
+
+func errorStatus(err error) int {
+	var coder StatusCoder
+	if err == nil || !errors.As(err, &coder) {
+		return 0
+	}
+
+	return coder.HTTPStatus()
+}
+
+func errorData(err error) any {
+	var dataer ErrorDataer
+	if err == nil || !errors.As(err, &dataer) {
+		return nil
+	}
+
+	return dataer.ErrorData()
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type codedError struct {
+	error
+	code string
+}
+
+func (e codedError) ErrorCode() string { return e.code }
+
+type statusError struct {
+	error
+	status int
+}
+
+func (e statusError) HTTPStatus() int { return e.status }
+func (e statusError) Unwrap() error   { return e.error }
+
+func Test_ErrorBuilder_CodeMapper(t *testing.T) {
+	defer SetCodeMapper(nil)
+
+	SetCodeMapper(func(code string) (int, string, bool) {
+		if code == "user_not_found" {
+			return http.StatusNotFound, "user not found", true
+		}
+		return 0, "", false
+	})
+
+	err := codedError{error: errors.New("sql: no rows"), code: "user_not_found"}
+
+	tests := []struct {
+		name       string
+		builder    *ErrorBuilder
+		wantStatus int
+		wantJSON   string
+	}{
+		{
+			name:       "mapped",
+			builder:    Err(err),
+			wantStatus: http.StatusNotFound,
+			wantJSON:   `{"message":"user not found","statusCode":404,"code":"user_not_found"}`,
+		},
+		{
+			name:       "explicit values win",
+			builder:    Err(err).Status(http.StatusGone).Msg("gone"),
+			wantStatus: http.StatusGone,
+			wantJSON:   `{"message":"gone","statusCode":410,"code":"user_not_found"}`,
+		},
+		{
+			name:       "error status wins over mapper",
+			builder:    Err(statusError{error: err, status: http.StatusConflict}),
+			wantStatus: http.StatusConflict,
+			wantJSON:   `{"message":"user not found","statusCode":409,"code":"user_not_found"}`,
+		},
+		{
+			name:       "error status",
+			builder:    Err(statusError{error: errors.New("taken"), status: http.StatusConflict}),
+			wantStatus: http.StatusConflict,
+			wantJSON:   `{"message":"taken","statusCode":409}`,
+		},
+		{
+			name:       "unknown code",
+			builder:    Err(codedError{error: errors.New("boom"), code: "other"}),
+			wantStatus: http.StatusInternalServerError,
+			wantJSON:   `{"message":"boom","statusCode":500,"code":"other"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			_ = tt.builder.Write(context.Background(), rec)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			if rec.Body.String() != tt.wantJSON {
+				t.Errorf("expected body %s, got %s", tt.wantJSON, rec.Body.String())
+			}
+		})
+	}
+}
+
+type dataError struct {
+	error
+	data any
+}
+
+func (e dataError) ErrorData() any { return e.data }
+
+func Test_ErrorBuilder_ErrorData(t *testing.T) {
+	err := dataError{error: errors.New("invalid"), data: map[string]string{"field": "name"}}
+
+	tests := []struct {
+		name     string
+		builder  *ErrorBuilder
+		wantJSON string
+	}{
+		{
+			name:     "from error",
+			builder:  Err(err).Status(http.StatusBadRequest),
+			wantJSON: `{"message":"invalid","statusCode":400,"data":{"field":"name"}}`,
+		},
+		{
+			name:     "explicit data wins",
+			builder:  Err(err).Status(http.StatusBadRequest).Data("other"),
+			wantJSON: `{"message":"invalid","statusCode":400,"data":"other"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			_ = tt.builder.Write(context.Background(), rec)
+
+			if rec.Body.String() != tt.wantJSON {
+				t.Errorf("expected body %s, got %s", tt.wantJSON, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"time"
+
+	"github.com/hay-kot/httpkit/ctxkit"
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+// ProxyError is returned by the handler of Proxy when the target could not be
+// reached. It carries the 502 Bad Gateway status, see StatusCoder.
+type ProxyError struct {
+	Target string
+	Err    error
+}
+
+func (e *ProxyError) Error() string {
+	return fmt.Sprintf("proxy %s: %v", e.Target, e.Err)
+}
+
+func (e *ProxyError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ProxyError) HTTPStatus() int {
+	return http.StatusBadGateway
+}
+
+type proxyOpts struct {
+	transport     http.RoundTripper
+	retries       int
+	backoff       time.Duration
+	flushInterval time.Duration
+	rewrites      []func(*httputil.ProxyRequest)
+	modify        []func(*http.Response) error
+}
+
+type ProxyOptFunc func(*proxyOpts)
+
+// WithProxyTransport sets the transport used to send requests to the target.
+//
+// Defaults to http.DefaultTransport
+func WithProxyTransport(rt http.RoundTripper) ProxyOptFunc {
+	return func(o *proxyOpts) {
+		o.transport = rt
+	}
+}
+
+// WithProxyRetries retries requests with idempotent methods up to n times when
+// the target cannot be reached or responds with 502, 503 or 504. The delay before
+// each retry starts at backoff and doubles with every attempt. Requests with a
+// body are only retried if the body can be replayed, see http.Request.GetBody.
+//
+// Defaults to no retries
+func WithProxyRetries(n int, backoff time.Duration) ProxyOptFunc {
+	return func(o *proxyOpts) {
+		o.retries = n
+		o.backoff = backoff
+	}
+}
+
+// WithProxyHeader sets a header on every request sent to the target. An empty
+// value removes the header.
+func WithProxyHeader(key, value string) ProxyOptFunc {
+	return WithProxyRewrite(func(pr *httputil.ProxyRequest) {
+		if value == "" {
+			pr.Out.Header.Del(key)
+			return
+		}
+		pr.Out.Header.Set(key, value)
+	})
+}
+
+// WithProxyResponseHeader sets a header on every response from the target. An
+// empty value removes the header.
+func WithProxyResponseHeader(key, value string) ProxyOptFunc {
+	return func(o *proxyOpts) {
+		o.modify = append(o.modify, func(resp *http.Response) error {
+			if value == "" {
+				resp.Header.Del(key)
+				return nil
+			}
+			resp.Header.Set(key, value)
+			return nil
+		})
+	}
+}
+
+// WithProxyRewrite adds a function that modifies every request sent to the target,
+// after its URL has been rewritten to the target. Multiple calls add functions,
+// which are applied in order.
+func WithProxyRewrite(fn func(*httputil.ProxyRequest)) ProxyOptFunc {
+	return func(o *proxyOpts) {
+		o.rewrites = append(o.rewrites, fn)
+	}
+}
+
+// WithProxyFlushInterval sets the interval the response body is flushed to the
+// client at while it is copied. A negative value flushes after every write.
+// Streaming responses, such as server-sent events and responses without a
+// content length, are always flushed immediately.
+//
+// Defaults to 0
+func WithProxyFlushInterval(d time.Duration) ProxyOptFunc {
+	return func(o *proxyOpts) {
+		o.flushInterval = d
+	}
+}
+
+var proxyErrKey = ctxkit.NewKey[*error]("proxy error")
+
+// Proxy returns a handler that forwards requests to target with
+// httputil.ReverseProxy, for services acting as a lightweight gateway. The path of
+// the request is appended to the path of target, the X-Forwarded headers are set,
+// and responses are streamed to the client.
+//
+// Failures to reach the target are returned through the error chain as a
+// *ProxyError instead of being written by the proxy, so they are logged and
+// rendered like every other error.
+//
+// Example:
+//
+//	target, _ := url.Parse("http://users.internal:8080/api")
+//	mux.ErrHandle("/users/", server.Proxy(target,
+//	  server.WithProxyRetries(2, 50*time.Millisecond),
+//	  server.WithProxyHeader("X-Gateway", "edge"),
+//	))
+func Proxy(target *url.URL, opts ...ProxyOptFunc) errchain.Handler {
+	o := &proxyOpts{transport: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	transport := o.transport
+	if o.retries > 0 {
+		transport = &retryTransport{next: transport, retries: o.retries, backoff: o.backoff}
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			for _, rewrite := range o.rewrites {
+				rewrite(pr)
+			}
+		},
+		Transport:     transport,
+		FlushInterval: o.flushInterval,
+		ModifyResponse: func(resp *http.Response) error {
+			for _, modify := range o.modify {
+				if err := modify(resp); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		ErrorHandler: func(_ http.ResponseWriter, r *http.Request, err error) {
+			if errp, ok := proxyErrKey.Get(r.Context()); ok {
+				*errp = err
+			}
+		},
+	}
+
+	return errchain.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		var err error
+		proxy.ServeHTTP(w, proxyErrKey.SetRequest(r, &err))
+
+		if err == nil || r.Context().Err() != nil {
+			return nil // the client went away
+		}
+
+		return &ProxyError{Target: target.Redacted(), Err: err}
+	})
+}
+
+// retryTransport retries requests with idempotent methods that fail or receive a
+// 502, 503 or 504 response.
+type retryTransport struct {
+	next    http.RoundTripper
+	retries int
+	backoff time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !retryable(req) {
+		return t.next.RoundTrip(req)
+	}
+
+	delay := t.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.retries || !shouldRetry(resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		}
+		delay *= 2
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+	}
+}
+
+func retryable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProxy(t *testing.T) {
+	var attempts atomic.Int32
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("X-Upstream", "secret")
+		_, _ = io.WriteString(w, r.URL.Path+" "+r.Header.Get("X-Gateway")+" "+r.Header.Get("X-Forwarded-Host"))
+	}))
+	defer upstream.Close()
+
+	target, _ := url.Parse(upstream.URL + "/api")
+	h := Proxy(target,
+		WithProxyRetries(2, time.Millisecond),
+		WithProxyHeader("X-Gateway", "edge"),
+		WithProxyResponseHeader("X-Upstream", ""),
+	)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://gateway.example/users/1", nil)
+
+	if err := h.ServeHTTP(rec, req); err != nil {
+		t.Fatal(err)
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+
+	if body := rec.Body.String(); body != "/api/users/1 edge gateway.example" {
+		t.Errorf("unexpected body %q", body)
+	}
+
+	if rec.Header().Get("X-Upstream") != "" {
+		t.Error("expected X-Upstream header to be removed")
+	}
+
+	if attempts.Load() != 2 {
+		t.Errorf("expected 2 attempts, got %d", attempts.Load())
+	}
+}
+
+func TestProxy_Error(t *testing.T) {
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+
+	target, _ := url.Parse(upstream.URL)
+
+	rec := httptest.NewRecorder()
+	err := Proxy(target).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", nil))
+
+	var proxyErr *ProxyError
+	if !errors.As(err, &proxyErr) {
+		t.Fatalf("expected ProxyError, got %v", err)
+	}
+
+	if errorStatus(err) != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %d", errorStatus(err))
+	}
+
+	if rec.Body.Len() != 0 {
+		t.Errorf("expected proxy not to write a response, got %q", rec.Body.String())
+	}
+}
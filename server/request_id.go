@@ -1,14 +1,33 @@
 package server
 
-import "context"
+import (
+	"context"
+	"sync/atomic"
+)
 
 type RequestIDFunc func(ctx context.Context) string
 
-var requestIDFunc RequestIDFunc = func(ctx context.Context) string {
-	return ""
-}
+var requestIDFunc atomic.Pointer[RequestIDFunc]
 
-// SetRequestIDFunc sets the function used to get the request ID from a context.
+// SetRequestIDFunc sets the function used to get the request ID from a context. It is
+// safe to call concurrently with requests being served. Passing nil removes the
+// function, request IDs are then omitted.
 func SetRequestIDFunc(fn RequestIDFunc) {
-	requestIDFunc = fn
+	if fn == nil {
+		requestIDFunc.Store(nil)
+		return
+	}
+
+	requestIDFunc.Store(&fn)
+}
+
+// RequestID returns the request ID carried by ctx using the function set by
+// SetRequestIDFunc.
+func RequestID(ctx context.Context) string {
+	fn := requestIDFunc.Load()
+	if fn == nil {
+		return ""
+	}
+
+	return (*fn)(ctx)
 }
@@ -37,10 +37,11 @@ func Error() *ErrorBuilder {
 }
 
 type ErrorBuilder struct {
-	err    error
-	msg    string
-	status int
-	data   any
+	err       error
+	msg       string
+	status    int
+	statusSet bool
+	data      any
 }
 
 // Err sets the error value that will be embedded into the resulting ResponseError
@@ -86,6 +87,7 @@ func (b *ErrorBuilder) Code(code int) *ErrorBuilder {
 // provided, http.StatusInternalServerError will be used.
 func (b *ErrorBuilder) Status(status int) *ErrorBuilder {
 	b.status = status
+	b.statusSet = true
 	return b
 }
 
@@ -115,10 +117,11 @@ func (b *ErrorBuilder) responseMsg() string {
 }
 
 // ErrorResp is the JSON response body for an error response. It contains the
-// message, status code, request ID, and optional data.
+// message, status code, request ID, and optional error code and data.
 type ErrorResp struct {
 	Message    string `json:"message"`
 	StatusCode int    `json:"statusCode"`
+	Code       string `json:"code,omitempty"`
 	RequestID  string `json:"requestId,omitempty"`
 	Data       any    `json:"data,omitempty"`
 }
@@ -127,22 +130,57 @@ type ErrorResp struct {
 // ErrorBuilder. If the code is http.StatusNoContent, no body is sent. It returns
 // a type of server.ResponseError that can be if you are using an error middleware
 // that logs errors.
+//
+// The message sent to the client is passed through the translation function set by
+// SetTranslateFunc, the message of the returned ResponseError is not translated.
+//
+// If the error carries a code (see ErrorCoder), the code is included in the response
+// and the mapper set by SetCodeMapper is used to choose the status and message when
+// they were not set explicitly. If the error carries an HTTP status (see
+// StatusCoder), it is used when the status was not set explicitly and takes
+// precedence over the status of the code mapper. If the error carries data (see
+// ErrorDataer), it is included in the response when no data was set with Data.
 func (b *ErrorBuilder) Write(ctx context.Context, w http.ResponseWriter) error {
+	status, msg := b.status, b.responseMsg()
+
+	code := errorCode(b.err)
+	if code != "" {
+		if mappedStatus, mappedMsg, ok := mapCode(code); ok {
+			if !b.statusSet && mappedStatus != 0 {
+				status = mappedStatus
+			}
+
+			if b.msg == "" && mappedMsg != "" {
+				msg = mappedMsg
+			}
+		}
+	}
+
+	if errStatus := errorStatus(b.err); errStatus != 0 && !b.statusSet {
+		status = errStatus
+	}
+
+	data := b.data
+	if data == nil {
+		data = errorData(b.err)
+	}
+
 	body := ErrorResp{
-		Message:    b.responseMsg(),
-		StatusCode: b.status,
-		RequestID:  requestIDFunc(ctx),
-		Data:       b.data,
+		Message:    translate(ctx, msg),
+		StatusCode: status,
+		Code:       code,
+		RequestID:  RequestID(ctx),
+		Data:       data,
 	}
 
-	err := JSON(w, b.status, body)
+	err := JSON(w, status, body)
 	if err != nil {
 		return err
 	}
 
 	return ResponseError{
 		cause: b.err,
-		msg:   b.responseMsg(),
+		msg:   msg,
 	}
 }
 
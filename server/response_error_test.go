@@ -23,9 +23,11 @@ func unwrap(err error) error {
 // UnsetRequestIDFunc sets the request ID function to return an empty string.
 // This is useful for testing.
 func unsetRequestIDFunc() {
-	requestIDFunc = func(ctx context.Context) string {
-		return ""
-	}
+	SetRequestIDFunc(nil)
+}
+
+func unsetTranslateFunc() {
+	SetTranslateFunc(nil)
 }
 
 func Test_ErrorBuilder(t *testing.T) {
@@ -80,11 +82,28 @@ func Test_ErrorBuilder(t *testing.T) {
 				return context.WithValue(ctx, requestIDKey, "test-request-id")
 			},
 		},
+		{
+			name:       "with translation",
+			builder:    Error().Msg("not found"),
+			wantErr:    errors.New("unknown error"),
+			expectJSON: `{"message":"introuvable","statusCode":500}`,
+			hook: func(ctx context.Context) context.Context {
+				SetTranslateFunc(func(_ context.Context, msg string) string {
+					if msg == "not found" {
+						return "introuvable"
+					}
+					return msg
+				})
+
+				return ctx
+			},
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			defer unsetRequestIDFunc()
+			defer unsetTranslateFunc()
 			bg := context.Background()
 			if c.hook != nil {
 				bg = c.hook(bg)
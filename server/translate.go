@@ -0,0 +1,41 @@
+package server
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// TranslateFunc translates a message sent to the client in an error response. The
+// context is the one passed to ErrorBuilder.Write and can be used to look up the
+// locale of the request.
+type TranslateFunc func(ctx context.Context, msg string) string
+
+var translateFunc atomic.Pointer[TranslateFunc]
+
+// SetTranslateFunc sets the function used to translate error messages written by
+// the ErrorBuilder. It is safe to call concurrently with requests being served.
+// By default, or after passing nil, messages are returned unchanged.
+//
+// Example:
+//
+//	server.SetTranslateFunc(func(ctx context.Context, msg string) string {
+//	  return catalog.Translate(middleware.GetLocale(ctx), msg)
+//	})
+func SetTranslateFunc(fn TranslateFunc) {
+	if fn == nil {
+		translateFunc.Store(nil)
+		return
+	}
+
+	translateFunc.Store(&fn)
+}
+
+// translate translates msg using the function set by SetTranslateFunc.
+func translate(ctx context.Context, msg string) string {
+	fn := translateFunc.Load()
+	if fn == nil {
+		return msg
+	}
+
+	return (*fn)(ctx, msg)
+}
@@ -0,0 +1,310 @@
+// Package sse provides a Broker for server-sent events. Clients subscribe to
+// topics through an errchain handler and receive the events published to those
+// topics, with per-client buffers, replay of missed events for reconnecting
+// clients and heartbeats to keep idle connections open.
+package sse
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+// ErrBrokerClosed is returned by Broker.Publish once the broker is closed.
+var ErrBrokerClosed = errors.New("sse: broker closed")
+
+type brokerOpts struct {
+	name      string
+	buffer    int
+	replay    int
+	heartbeat time.Duration
+}
+
+type BrokerOptFunc func(*brokerOpts)
+
+// WithName sets the name of the broker when it is used as a graceful.Plugin.
+//
+// Defaults to "sse"
+func WithName(name string) BrokerOptFunc {
+	return func(o *brokerOpts) {
+		o.name = name
+	}
+}
+
+// WithBufferSize sets the number of events buffered for every client. A client
+// that falls further behind is disconnected, and can resume with the events it
+// missed by reconnecting with the Last-Event-ID header.
+//
+// Defaults to 16
+func WithBufferSize(n int) BrokerOptFunc {
+	return func(o *brokerOpts) {
+		o.buffer = n
+	}
+}
+
+// WithReplayWindow sets the number of recent events kept for every topic to be
+// replayed to clients reconnecting with the Last-Event-ID header.
+//
+// Defaults to 100
+func WithReplayWindow(n int) BrokerOptFunc {
+	return func(o *brokerOpts) {
+		o.replay = n
+	}
+}
+
+// WithHeartbeat sets the interval of the comments sent to idle clients to keep
+// proxies from closing their connections. Zero disables heartbeats.
+//
+// Defaults to 15 seconds
+func WithHeartbeat(d time.Duration) BrokerOptFunc {
+	return func(o *brokerOpts) {
+		o.heartbeat = d
+	}
+}
+
+// Broker distributes published events to the clients subscribed to their topic.
+// Create one with NewBroker.
+//
+// A Broker implements graceful.Plugin: once the context passed to Start is
+// cancelled, every stream is ended so the HTTP server can shut down without
+// waiting for long lived connections.
+type Broker struct {
+	opts *brokerOpts
+
+	mu      sync.Mutex
+	closed  bool
+	done    chan struct{}
+	lastID  uint64
+	clients map[string]map[*client]struct{}
+	history map[string][]Event
+}
+
+type client struct {
+	events chan Event
+	closed bool
+}
+
+// NewBroker returns a new Broker.
+//
+// Example:
+//
+//	broker := sse.NewBroker(sse.WithHeartbeat(30 * time.Second))
+//	runner.AddPlugin(broker)
+//
+//	mux.Get("/events/orders", broker.Handler("orders"))
+//	err := broker.Publish("orders", sse.Event{Event: "created", Data: string(body)})
+func NewBroker(opts ...BrokerOptFunc) *Broker {
+	o := &brokerOpts{
+		name:      "sse",
+		buffer:    16,
+		replay:    100,
+		heartbeat: 15 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Broker{
+		opts:    o,
+		done:    make(chan struct{}),
+		clients: make(map[string]map[*client]struct{}),
+		history: make(map[string][]Event),
+	}
+}
+
+func (b *Broker) Name() string {
+	return b.opts.name
+}
+
+// Start blocks until ctx is done and then closes the broker, see Close.
+func (b *Broker) Start(ctx context.Context) error {
+	<-ctx.Done()
+	b.Close()
+	return nil
+}
+
+// Close ends every stream and rejects new events and subscribers.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.closed = true
+	close(b.done)
+}
+
+// Publish sends e to every client subscribed to topic and records it in the replay
+// window of the topic. An ID is assigned to e if it has none. Clients that cannot
+// keep up are disconnected.
+func (b *Broker) Publish(topic string, e Event) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return ErrBrokerClosed
+	}
+
+	b.lastID++
+	if e.ID == "" {
+		e.ID = strconv.FormatUint(b.lastID, 10)
+	}
+
+	if b.opts.replay > 0 {
+		history := append(b.history[topic], e)
+		if len(history) > b.opts.replay {
+			history = slices.Clone(history[len(history)-b.opts.replay:])
+		}
+		b.history[topic] = history
+	}
+
+	for c := range b.clients[topic] {
+		select {
+		case c.events <- e:
+		default:
+			b.remove(c)
+		}
+	}
+
+	return nil
+}
+
+// Clients returns the number of clients subscribed to topic.
+func (b *Broker) Clients(topic string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.clients[topic])
+}
+
+// subscribe adds a client for topics and returns it with the events published
+// after lastID that are still in the replay window.
+func (b *Broker) subscribe(topics []string, lastID string) (*client, []Event, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil, nil, ErrBrokerClosed
+	}
+
+	c := &client{events: make(chan Event, b.opts.buffer)}
+
+	var missed []Event
+	for _, topic := range topics {
+		if b.clients[topic] == nil {
+			b.clients[topic] = make(map[*client]struct{})
+		}
+		b.clients[topic][c] = struct{}{}
+
+		if lastID == "" {
+			continue
+		}
+
+		history := b.history[topic]
+		for i, e := range history {
+			if e.ID == lastID {
+				missed = append(missed, history[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return c, missed, nil
+}
+
+// remove unsubscribes c from every topic and closes its channel. It must be called
+// with b.mu held.
+func (b *Broker) remove(c *client) {
+	if c.closed {
+		return
+	}
+
+	c.closed = true
+	close(c.events)
+
+	for topic, clients := range b.clients {
+		delete(clients, c)
+		if len(clients) == 0 {
+			delete(b.clients, topic)
+		}
+	}
+}
+
+func (b *Broker) unsubscribe(c *client) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.remove(c)
+}
+
+// Handler returns a handler that streams the events published to topics to the
+// client. Clients reconnecting with the Last-Event-ID header first receive the
+// events they missed that are still in the replay window.
+//
+// The handler returns once the client disconnects, falls behind, or the broker is
+// closed. It responds with 503 Service Unavailable if the broker is already closed.
+func (b *Broker) Handler(topics ...string) errchain.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		c, missed, err := b.subscribe(topics, r.Header.Get("Last-Event-ID"))
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return nil
+		}
+		defer b.unsubscribe(c)
+
+		rc := http.NewResponseController(w)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		for _, e := range missed {
+			if _, err := e.WriteTo(w); err != nil {
+				return err
+			}
+		}
+
+		if err := rc.Flush(); err != nil {
+			return err
+		}
+
+		var heartbeat <-chan time.Time
+		if b.opts.heartbeat > 0 {
+			ticker := time.NewTicker(b.opts.heartbeat)
+			defer ticker.Stop()
+			heartbeat = ticker.C
+		}
+
+		for {
+			select {
+			case e, ok := <-c.events:
+				if !ok {
+					return nil
+				}
+
+				if _, err := e.WriteTo(w); err != nil {
+					return err
+				}
+			case <-heartbeat:
+				if _, err := w.Write([]byte(": heartbeat\n\n")); err != nil {
+					return err
+				}
+			case <-b.done:
+				return nil
+			case <-r.Context().Done():
+				return nil
+			}
+
+			if err := rc.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+}
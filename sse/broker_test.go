@@ -0,0 +1,157 @@
+package sse_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/sse"
+)
+
+func newServer(t *testing.T, broker *sse.Broker) *httptest.Server {
+	t.Helper()
+
+	chain := errchain.New(func(h errchain.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := h.ServeHTTP(w, r); err != nil {
+				t.Log(err)
+			}
+		})
+	})
+
+	srv := httptest.NewServer(chain.ToHandler(broker.Handler("orders")))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// connect opens a stream and returns a reader of its lines once the broker has
+// registered the client.
+func connect(t *testing.T, broker *sse.Broker, url, lastID string) *bufio.Reader {
+	t.Helper()
+
+	req, _ := http.NewRequest(http.MethodGet, url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = resp.Body.Close() })
+
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	for broker.Clients("orders") == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	return bufio.NewReader(resp.Body)
+}
+
+// readEvent reads the lines of the next event.
+func readEvent(t *testing.T, r *bufio.Reader) string {
+	t.Helper()
+
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return strings.Join(lines, "|")
+		}
+		lines = append(lines, line)
+	}
+}
+
+func Test_Broker_Publish(t *testing.T) {
+	broker := sse.NewBroker()
+	srv := newServer(t, broker)
+
+	stream := connect(t, broker, srv.URL, "")
+
+	if err := broker.Publish("orders", sse.Event{Event: "created", Data: "line 1\nline 2"}); err != nil {
+		t.Fatal(err)
+	}
+	_ = broker.Publish("invoices", sse.Event{Data: "ignored"})
+	_ = broker.Publish("orders", sse.Event{ID: "custom", Data: "second"})
+
+	if got := readEvent(t, stream); got != "id: 1|event: created|data: line 1|data: line 2" {
+		t.Errorf("unexpected event %q", got)
+	}
+
+	if got := readEvent(t, stream); got != "id: custom|data: second" {
+		t.Errorf("unexpected event %q", got)
+	}
+}
+
+func Test_Broker_Replay(t *testing.T) {
+	broker := sse.NewBroker(sse.WithReplayWindow(2))
+	srv := newServer(t, broker)
+
+	for _, data := range []string{"a", "b", "c"} {
+		_ = broker.Publish("orders", sse.Event{Data: data})
+	}
+
+	stream := connect(t, broker, srv.URL, "2")
+
+	if got := readEvent(t, stream); got != "id: 3|data: c" {
+		t.Errorf("unexpected event %q", got)
+	}
+}
+
+func Test_Broker_Heartbeat(t *testing.T) {
+	broker := sse.NewBroker(sse.WithHeartbeat(time.Millisecond))
+	srv := newServer(t, broker)
+
+	stream := connect(t, broker, srv.URL, "")
+
+	if got := readEvent(t, stream); got != ": heartbeat" {
+		t.Errorf("unexpected event %q", got)
+	}
+}
+
+func Test_Broker_Start(t *testing.T) {
+	broker := sse.NewBroker()
+	srv := newServer(t, broker)
+
+	stream := connect(t, broker, srv.URL, "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := broker.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := stream.ReadString('\n'); err == nil {
+		t.Error("expected stream to end once the broker is closed")
+	}
+
+	if err := broker.Publish("orders", sse.Event{}); !errors.Is(err, sse.ErrBrokerClosed) {
+		t.Errorf("expected ErrBrokerClosed, got %v", err)
+	}
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,47 @@
+package sse
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a single server-sent event.
+type Event struct {
+	// ID is assigned by Broker.Publish when empty and sent as the event id, so
+	// reconnecting clients can resume with the Last-Event-ID header.
+	ID string
+	// Event is the event type. Clients receive events without a type as "message".
+	Event string
+	// Data is the event payload. Multi-line data is sent as multiple data lines.
+	Data string
+	// Retry tells the client how long to wait before reconnecting. Zero omits it.
+	Retry time.Duration
+}
+
+// WriteTo writes the event to w in the text/event-stream format.
+func (e Event) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	if e.ID != "" {
+		b.WriteString("id: " + e.ID + "\n")
+	}
+
+	if e.Event != "" {
+		b.WriteString("event: " + e.Event + "\n")
+	}
+
+	if e.Retry > 0 {
+		b.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	for _, line := range strings.Split(e.Data, "\n") {
+		b.WriteString("data: " + line + "\n")
+	}
+
+	b.WriteString("\n")
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,354 @@
+// Package static serves the frontend assets of an application embedded in the
+// binary. Every file gets a content-hashed URL, so responses can be cached
+// forever and a new build busts the cache. Templates resolve the hashed URLs
+// with the "asset" function, pre-compressed brotli and gzip variants are served
+// to clients that accept them, and a development mode serves the files from
+// disk so changes show up without rebuilding.
+//
+// Example:
+//
+//	//go:embed dist
+//	var dist embed.FS
+//
+//	assets, err := static.New(dist, static.WithRoot("dist"), static.WithPrefix("/static/"))
+//	if err != nil {
+//	  return err
+//	}
+//
+//	mux.Handle("GET /static/", assets)
+//	tmpl := template.New("").Funcs(assets.FuncMap())
+//
+//	// {{ asset "app.js" }} renders /static/app.3f2a1b9c.js
+package static
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// hashLen is the number of hex characters of the content hash used in URLs.
+const hashLen = 10
+
+// precompressed lists the extensions of pre-compressed variants in order of
+// preference with their Content-Encoding.
+var precompressed = []struct {
+	ext      string
+	encoding string
+}{
+	{".br", "br"},
+	{".gz", "gzip"},
+}
+
+type opts struct {
+	root   string
+	prefix string
+	devDir string
+}
+
+type OptFunc func(*opts)
+
+// WithRoot sets the directory of the file system that contains the assets, for
+// example the directory name used in the go:embed directive.
+//
+// Defaults to the root of the file system
+func WithRoot(root string) OptFunc {
+	return func(o *opts) {
+		o.root = root
+	}
+}
+
+// WithPrefix sets the URL path the assets are served under. It must match the
+// pattern the Assets are registered with.
+//
+// Defaults to "/static/"
+func WithPrefix(prefix string) OptFunc {
+	return func(o *opts) {
+		o.prefix = prefix
+	}
+}
+
+// WithDevDir enables the development mode: assets are read from dir on disk on
+// every request instead of the embedded file system, URLs are not hashed and
+// responses are not cached. Use it with the source directory of the embedded
+// assets so changes show up without rebuilding the binary.
+func WithDevDir(dir string) OptFunc {
+	return func(o *opts) {
+		o.devDir = dir
+	}
+}
+
+type asset struct {
+	name string
+	hash string
+}
+
+// Assets serves a file system of assets under content-hashed URLs. Create one
+// with New.
+type Assets struct {
+	opts *opts
+	fsys fs.FS
+
+	// manifest maps the original names to the hashed names, byHash the reverse
+	manifest map[string]string
+	byHash   map[string]asset
+}
+
+// New hashes every file of fsys and returns the Assets serving them. In
+// development mode fsys is ignored, see WithDevDir.
+func New(fsys fs.FS, fns ...OptFunc) (*Assets, error) {
+	o := &opts{
+		prefix: "/static/",
+	}
+
+	for _, fn := range fns {
+		fn(o)
+	}
+
+	if !strings.HasSuffix(o.prefix, "/") {
+		o.prefix += "/"
+	}
+
+	a := &Assets{
+		opts:     o,
+		manifest: map[string]string{},
+		byHash:   map[string]asset{},
+	}
+
+	if o.devDir != "" {
+		a.fsys = os.DirFS(o.devDir)
+		return a, nil
+	}
+
+	if o.root != "" && o.root != "." {
+		sub, err := fs.Sub(fsys, o.root)
+		if err != nil {
+			return nil, fmt.Errorf("static: %w", err)
+		}
+		fsys = sub
+	}
+
+	a.fsys = fsys
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || isPrecompressed(fsys, name) {
+			return err
+		}
+
+		hash, err := hashFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		hashed := hashedName(name, hash)
+		a.manifest[name] = hashed
+		a.byHash[hashed] = asset{name: name, hash: hash}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("static: hash assets: %w", err)
+	}
+
+	return a, nil
+}
+
+// Path returns the URL of the asset with the given name, relative to the root of
+// the assets, for example "/static/app.3f2a1b9c0d.js" for "app.js". Unknown
+// names are returned unhashed.
+func (a *Assets) Path(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if hashed, ok := a.manifest[name]; ok {
+		return a.opts.prefix + hashed
+	}
+
+	return a.opts.prefix + name
+}
+
+// FuncMap returns the template functions resolving asset URLs:
+//
+//	{{ asset "app.js" }}
+func (a *Assets) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"asset": a.Path,
+	}
+}
+
+// Manifest returns a copy of the mapping of asset names to hashed names. It is
+// empty in development mode.
+func (a *Assets) Manifest() map[string]string {
+	m := make(map[string]string, len(a.manifest))
+	for k, v := range a.manifest {
+		m[k] = v
+	}
+	return m
+}
+
+// WriteManifest writes the manifest as a JSON object to w, for tools outside of
+// Go that need the hashed names.
+func (a *Assets) WriteManifest(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(a.manifest)
+}
+
+// ServeHTTP serves the asset at the request path below the prefix. Hashed URLs
+// are served with immutable cache headers, unhashed URLs must be revalidated.
+// Pre-compressed variants of the asset with a .br or .gz extension are served
+// when the client accepts them.
+func (a *Assets) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	reqName, ok := strings.CutPrefix(r.URL.Path, a.opts.prefix)
+	if !ok || reqName == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	name, cacheControl, etag := reqName, "no-cache", ""
+	if as, ok := a.byHash[reqName]; ok {
+		name, cacheControl, etag = as.name, "public, max-age=31536000, immutable", `"`+as.hash+`"`
+	} else if hashed, ok := a.manifest[reqName]; ok {
+		etag = `"` + a.byHash[hashed].hash + `"`
+	}
+
+	if a.opts.devDir != "" {
+		cacheControl, etag = "no-store", ""
+	}
+
+	file, encoding, err := a.open(name, r.Header.Get("Accept-Encoding"))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer func() { _ = file.Close() }()
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		content = bytes.NewReader(data)
+	}
+
+	h := w.Header()
+	h.Set("Cache-Control", cacheControl)
+	h.Add("Vary", "Accept-Encoding")
+
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		h.Set("Content-Type", ctype)
+	}
+
+	if encoding != "" {
+		h.Set("Content-Encoding", encoding)
+		if etag != "" {
+			etag = etag[:len(etag)-1] + "-" + encoding + `"`
+		}
+	}
+
+	if etag != "" {
+		h.Set("ETag", etag)
+	}
+
+	http.ServeContent(w, r, name, time.Time{}, content)
+}
+
+// open opens the preferred variant of name the client accepts and returns its
+// Content-Encoding, empty for the original file.
+func (a *Assets) open(name, acceptEncoding string) (fs.File, string, error) {
+	if strings.Contains(name, "..") {
+		return nil, "", fs.ErrNotExist
+	}
+
+	for _, pc := range precompressed {
+		if !accepts(acceptEncoding, pc.encoding) {
+			continue
+		}
+
+		if f, err := a.fsys.Open(name + pc.ext); err == nil {
+			return f, pc.encoding, nil
+		}
+	}
+
+	f, err := a.fsys.Open(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if stat, err := f.Stat(); err != nil || stat.IsDir() {
+		_ = f.Close()
+		return nil, "", fs.ErrNotExist
+	}
+
+	return f, "", nil
+}
+
+// accepts reports whether the Accept-Encoding header value accepts encoding.
+func accepts(header, encoding string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), encoding) {
+			continue
+		}
+
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(v, 64)
+			return err == nil && q > 0
+		}
+		return true
+	}
+
+	return false
+}
+
+// isPrecompressed reports whether name is a pre-compressed variant of another
+// file in fsys.
+func isPrecompressed(fsys fs.FS, name string) bool {
+	for _, pc := range precompressed {
+		if orig, ok := strings.CutSuffix(name, pc.ext); ok {
+			if _, err := fs.Stat(fsys, orig); err == nil {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func hashFile(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:hashLen], nil
+}
+
+// hashedName inserts the hash before the extension of name, for example
+// "js/app.js" becomes "js/app.3f2a1b9c0d.js".
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
@@ -0,0 +1,201 @@
+package static
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"dist/app.js":          {Data: []byte("console.log('hi')")},
+		"dist/app.js.br":       {Data: []byte("brotli")},
+		"dist/css/site.css":    {Data: []byte("body {}")},
+		"dist/css/site.css.gz": {Data: []byte("gzip")},
+	}
+}
+
+func Test_Assets_Path(t *testing.T) {
+	assets, err := New(testFS(), WithRoot("dist"), WithPrefix("/assets"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	js := assets.Path("app.js")
+	if !strings.HasPrefix(js, "/assets/app.") || !strings.HasSuffix(js, ".js") || len(js) != len("/assets/app..js")+hashLen {
+		t.Errorf("unexpected hashed path %s", js)
+	}
+
+	if got := assets.Path("/css/site.css"); !strings.HasPrefix(got, "/assets/css/site.") {
+		t.Errorf("unexpected hashed path %s", got)
+	}
+
+	if got := assets.Path("missing.png"); got != "/assets/missing.png" {
+		t.Errorf("expected unknown assets to be unhashed, got %s", got)
+	}
+
+	if len(assets.Manifest()) != 2 {
+		t.Errorf("expected pre-compressed variants to be excluded from the manifest, got %v", assets.Manifest())
+	}
+
+	tmpl := template.Must(template.New("").Funcs(assets.FuncMap()).Parse(`{{ asset "app.js" }}`))
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil || buf.String() != js {
+		t.Errorf("expected template to render %s, got %s: %v", js, buf.String(), err)
+	}
+}
+
+func Test_Assets_ServeHTTP(t *testing.T) {
+	assets, err := New(testFS(), WithRoot("dist"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		encoding     string
+		wantStatus   int
+		wantBody     string
+		wantCache    string
+		wantEncoding string
+		wantType     string
+	}{
+		{
+			name:       "hashed",
+			path:       assets.Path("app.js"),
+			wantStatus: http.StatusOK,
+			wantBody:   "console.log('hi')",
+			wantCache:  "public, max-age=31536000, immutable",
+			wantType:   "text/javascript; charset=utf-8",
+		},
+		{
+			name:         "brotli",
+			path:         assets.Path("app.js"),
+			encoding:     "gzip, br",
+			wantStatus:   http.StatusOK,
+			wantBody:     "brotli",
+			wantCache:    "public, max-age=31536000, immutable",
+			wantEncoding: "br",
+			wantType:     "text/javascript; charset=utf-8",
+		},
+		{
+			name:         "gzip",
+			path:         assets.Path("css/site.css"),
+			encoding:     "gzip, br;q=0",
+			wantStatus:   http.StatusOK,
+			wantBody:     "gzip",
+			wantCache:    "public, max-age=31536000, immutable",
+			wantEncoding: "gzip",
+			wantType:     "text/css; charset=utf-8",
+		},
+		{
+			name:       "unhashed",
+			path:       "/static/app.js",
+			wantStatus: http.StatusOK,
+			wantBody:   "console.log('hi')",
+			wantCache:  "no-cache",
+			wantType:   "text/javascript; charset=utf-8",
+		},
+		{
+			name:       "missing",
+			path:       "/static/missing.js",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "directory",
+			path:       "/static/css",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.encoding != "" {
+				req.Header.Set("Accept-Encoding", tt.encoding)
+			}
+
+			rec := httptest.NewRecorder()
+			assets.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			if rec.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+
+			if got := rec.Header().Get("Cache-Control"); got != tt.wantCache {
+				t.Errorf("expected Cache-Control %q, got %q", tt.wantCache, got)
+			}
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Errorf("expected Content-Encoding %q, got %q", tt.wantEncoding, got)
+			}
+
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("expected Content-Type %q, got %q", tt.wantType, got)
+			}
+		})
+	}
+}
+
+func Test_Assets_NotModified(t *testing.T) {
+	assets, err := New(testFS(), WithRoot("dist"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	assets.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+
+	req := httptest.NewRequest(http.MethodGet, "/static/app.js", nil)
+	req.Header.Set("If-None-Match", rec.Header().Get("ETag"))
+
+	rec = httptest.NewRecorder()
+	assets.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("expected status 304, got %d", rec.Code)
+	}
+}
+
+func Test_Assets_DevDir(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.js")
+
+	assets, err := New(nil, WithDevDir(dir))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := assets.Path("app.js"); got != "/static/app.js" {
+		t.Errorf("expected unhashed path in development mode, got %s", got)
+	}
+
+	for _, content := range []string{"v1", "v2"} {
+		if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		rec := httptest.NewRecorder()
+		assets.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/app.js", nil))
+
+		if rec.Body.String() != content || rec.Header().Get("Cache-Control") != "no-store" {
+			t.Errorf("expected fresh %q without caching, got %q (%s)", content, rec.Body.String(), rec.Header().Get("Cache-Control"))
+		}
+	}
+}
@@ -0,0 +1,5 @@
+// Package sqlitetest runs the store tests against tasks.SQLiteStore with an
+// in-memory SQLite database. It is a separate module so the SQLite driver is not
+// a dependency of httpkit, as SQLiteStore leaves the choice of driver to the
+// application.
+package sqlitetest
@@ -0,0 +1,29 @@
+package sqlitetest
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/hay-kot/httpkit/tasks"
+	"github.com/hay-kot/httpkit/tasks/storetest"
+	_ "modernc.org/sqlite"
+)
+
+func Test_SQLiteStore(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	// every connection opens its own in-memory database
+	db.SetMaxOpenConns(1)
+
+	store := tasks.NewSQLiteStore(db, "tasks")
+	if err := store.Migrate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	storetest.Run(t, store)
+}
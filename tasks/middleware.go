@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/hay-kot/httpkit/internal/promutil"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Logger returns a Middleware that logs every handled task with its type, ID,
+// attempt and duration. Failed tasks are logged at the error level.
+func Logger(l *slog.Logger) Middleware {
+	return func(h Handler) Handler {
+		return func(ctx context.Context, t *Task) error {
+			start := time.Now()
+			err := h(ctx, t)
+
+			attrs := []slog.Attr{
+				slog.String("task_type", t.Type),
+				slog.String("task_id", t.ID),
+				slog.Int("attempt", t.Attempts+1),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				l.LogAttrs(ctx, slog.LevelError, "task_failed", attrs...)
+				return err
+			}
+
+			l.LogAttrs(ctx, slog.LevelInfo, "task_handled", attrs...)
+			return nil
+		}
+	}
+}
+
+// Metrics returns a Middleware that records Prometheus metrics for every handled
+// task and registers the collectors with the provided registerer. If registerer
+// is nil, prometheus.DefaultRegisterer is used.
+//
+// The following metrics are exported, labeled by task type and status ("ok" or
+// "error"):
+//   - tasks_handled_total
+//   - tasks_duration_seconds
+func Metrics(registerer prometheus.Registerer) Middleware {
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+
+	labels := []string{"type", "status"}
+
+	handled := promutil.Register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tasks_handled_total",
+		Help: "Total number of handled tasks.",
+	}, labels))
+	duration := promutil.Register(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tasks_duration_seconds",
+		Help:    "Duration of task handlers in seconds.",
+		Buckets: prometheus.DefBuckets,
+	}, labels))
+
+	return func(h Handler) Handler {
+		return func(ctx context.Context, t *Task) error {
+			start := time.Now()
+			err := h(ctx, t)
+
+			status := "ok"
+			if err != nil {
+				status = "error"
+			}
+
+			handled.WithLabelValues(t.Type, status).Inc()
+			duration.WithLabelValues(t.Type, status).Observe(time.Since(start).Seconds())
+
+			return err
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func Test_Logger(t *testing.T) {
+	var buf bytes.Buffer
+	h := Logger(slog.New(slog.NewTextHandler(&buf, nil)))(func(ctx context.Context, t *Task) error {
+		return errors.New("boom")
+	})
+
+	_ = h(context.Background(), &Task{ID: "1", Type: "email"})
+
+	for _, want := range []string{"msg=task_failed", "task_type=email", "task_id=1", "attempt=1", "error=boom"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected log to contain %q, got %s", want, buf.String())
+		}
+	}
+}
+
+func Test_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	mw := Metrics(reg)
+
+	ok := mw(func(ctx context.Context, t *Task) error { return nil })
+	fail := Metrics(reg)(func(ctx context.Context, t *Task) error { return errors.New("boom") })
+
+	_ = ok(context.Background(), &Task{Type: "email"})
+	_ = ok(context.Background(), &Task{Type: "email"})
+	_ = fail(context.Background(), &Task{Type: "email"})
+
+	expected := `
+# HELP tasks_handled_total Total number of handled tasks.
+# TYPE tasks_handled_total counter
+tasks_handled_total{status="error",type="email"} 1
+tasks_handled_total{status="ok",type="email"} 2
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "tasks_handled_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,430 @@
+package tasks
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type opts struct {
+	name         string
+	store        Store
+	concurrency  int
+	poll         time.Duration
+	lease        time.Duration
+	drainTimeout time.Duration
+	retry        RetryPolicy
+	onError      func(err error)
+}
+
+type OptFunc func(*opts)
+
+// WithName sets the name of the queue when it is used as a graceful.Plugin.
+//
+// Defaults to "tasks"
+func WithName(name string) OptFunc {
+	return func(o *opts) {
+		o.name = name
+	}
+}
+
+// WithStore sets the Store tasks are persisted in.
+//
+// Defaults to a MemoryStore
+func WithStore(s Store) OptFunc {
+	return func(o *opts) {
+		o.store = s
+	}
+}
+
+// WithConcurrency sets the number of tasks handled at the same time across all
+// task types.
+//
+// Defaults to 4
+func WithConcurrency(n int) OptFunc {
+	return func(o *opts) {
+		o.concurrency = max(n, 1)
+	}
+}
+
+// WithPollInterval sets the interval the Store is polled for due tasks. Tasks
+// enqueued without a delay on the same Queue are picked up immediately.
+//
+// Defaults to 1 second
+func WithPollInterval(d time.Duration) OptFunc {
+	return func(o *opts) {
+		o.poll = d
+	}
+}
+
+// WithLease sets the time a claimed task is hidden from other claims. A task
+// that is not finished before its lease expires is handled again, so the lease
+// should be longer than the longest running task.
+//
+// Defaults to 5 minutes
+func WithLease(d time.Duration) OptFunc {
+	return func(o *opts) {
+		o.lease = d
+	}
+}
+
+// WithDrainTimeout sets the time the queue waits for the tasks being handled
+// after shutdown begins. It should be lower than the shutdown timeout of the
+// plugin in the Runner.
+//
+// Defaults to 10 seconds
+func WithDrainTimeout(d time.Duration) OptFunc {
+	return func(o *opts) {
+		o.drainTimeout = d
+	}
+}
+
+// WithRetryPolicy sets the RetryPolicy of task types registered without one.
+//
+// Defaults to DefaultRetryPolicy
+func WithRetryPolicy(p RetryPolicy) OptFunc {
+	return func(o *opts) {
+		o.retry = p
+	}
+}
+
+// WithErrorHandler sets the function called when a task fails, with a
+// *TaskError, and with errors of the Store.
+func WithErrorHandler(fn func(err error)) OptFunc {
+	return func(o *opts) {
+		o.onError = fn
+	}
+}
+
+// EnqueueOptFunc configures a single call to Queue.Enqueue.
+type EnqueueOptFunc func(*Task)
+
+// WithDelay delays the task by d.
+func WithDelay(d time.Duration) EnqueueOptFunc {
+	return func(t *Task) {
+		t.RunAt = t.RunAt.Add(d)
+	}
+}
+
+// WithRunAt schedules the task at the given time.
+func WithRunAt(at time.Time) EnqueueOptFunc {
+	return func(t *Task) {
+		t.RunAt = at
+	}
+}
+
+// TaskType is a task type registered on a Queue with Register.
+type TaskType struct {
+	name    string
+	handler Handler
+	retry   *RetryPolicy
+	timeout time.Duration
+	slots   chan struct{}
+}
+
+// Retry sets the RetryPolicy of the task type. It returns the TaskType for
+// chaining.
+func (tt *TaskType) Retry(p RetryPolicy) *TaskType {
+	tt.retry = &p
+	return tt
+}
+
+// Concurrency limits the number of tasks of this type handled at the same time,
+// within the concurrency of the Queue. It returns the TaskType for chaining.
+func (tt *TaskType) Concurrency(n int) *TaskType {
+	tt.slots = make(chan struct{}, max(n, 1))
+	return tt
+}
+
+// Timeout sets the maximum time a task of this type is handled before its
+// context is cancelled. It returns the TaskType for chaining.
+func (tt *TaskType) Timeout(d time.Duration) *TaskType {
+	tt.timeout = d
+	return tt
+}
+
+// Queue handles tasks in the background. Create one with New.
+type Queue struct {
+	opts   *opts
+	notify chan struct{}
+	now    func() time.Time
+
+	mu    sync.RWMutex
+	types map[string]*TaskType
+	mw    []Middleware
+
+	inFlight atomic.Int64
+}
+
+// New returns a new Queue configured with the provided options.
+func New(fns ...OptFunc) *Queue {
+	o := &opts{
+		name:         "tasks",
+		concurrency:  4,
+		poll:         time.Second,
+		lease:        5 * time.Minute,
+		drainTimeout: 10 * time.Second,
+		retry:        DefaultRetryPolicy,
+		onError:      func(error) {}, // NOOP
+	}
+
+	for _, fn := range fns {
+		fn(o)
+	}
+
+	if o.store == nil {
+		o.store = NewMemoryStore()
+	}
+
+	return &Queue{
+		opts:   o,
+		notify: make(chan struct{}, 1),
+		now:    time.Now,
+		types:  map[string]*TaskType{},
+	}
+}
+
+func (q *Queue) Name() string {
+	return q.opts.name
+}
+
+// Use adds middleware to the handlers of all task types. Middleware are applied
+// in the order they are added, the first middleware is the outermost.
+func (q *Queue) Use(mw ...Middleware) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.mw = append(q.mw, mw...)
+}
+
+// Register registers the handler for tasks of the given type, replacing any
+// handler registered before.
+func (q *Queue) Register(name string, h Handler) *TaskType {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tt := &TaskType{name: name, handler: h}
+	q.types[name] = tt
+	return tt
+}
+
+// Enqueue stores a task of the given type with the JSON encoding of payload and
+// returns its ID. The task is due immediately unless WithDelay or WithRunAt is
+// provided.
+func (q *Queue) Enqueue(ctx context.Context, taskType string, payload any, fns ...EnqueueOptFunc) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("tasks: encode payload: %w", err)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	now := q.now()
+	t := Task{
+		ID:      id,
+		Type:    taskType,
+		Payload: data,
+		Created: now,
+		RunAt:   now,
+	}
+
+	for _, fn := range fns {
+		fn(&t)
+	}
+
+	if err := q.opts.store.Add(ctx, t); err != nil {
+		return "", fmt.Errorf("tasks: store task: %w", err)
+	}
+
+	if !t.RunAt.After(now) {
+		select {
+		case q.notify <- struct{}{}:
+		default:
+		}
+	}
+
+	return id, nil
+}
+
+// InFlight returns the number of tasks being handled.
+func (q *Queue) InFlight() int {
+	return int(q.inFlight.Load())
+}
+
+// Start claims and handles due tasks until ctx is cancelled, then waits for the
+// tasks being handled. It returns an *UnfinishedTasksError if tasks were still
+// being handled once the drain timeout expired.
+func (q *Queue) Start(ctx context.Context) error {
+	// handlers keep their context while the queue is drained
+	handleCtx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+
+	var (
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, q.opts.concurrency)
+	)
+
+	ticker := time.NewTicker(q.opts.poll)
+	defer ticker.Stop()
+
+poll:
+	for {
+		free := cap(slots) - len(slots)
+		if free > 0 {
+			claimed, err := q.opts.store.Claim(ctx, q.now(), free, q.opts.lease)
+			if err != nil && ctx.Err() == nil {
+				q.opts.onError(fmt.Errorf("tasks: claim tasks: %w", err))
+			}
+
+			for _, t := range claimed {
+				slots <- struct{}{}
+				q.inFlight.Add(1)
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-slots }()
+					defer q.inFlight.Add(-1)
+
+					q.run(handleCtx, t)
+				}()
+			}
+
+			if len(claimed) == free {
+				// more tasks may be due, poll again once a slot is free
+				select {
+				case slots <- struct{}{}:
+					<-slots
+					continue
+				case <-ctx.Done():
+					break poll
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			break poll
+		case <-ticker.C:
+		case <-q.notify:
+		}
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	timer := time.NewTimer(q.opts.drainTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-drained:
+		return nil
+	case <-timer.C:
+	}
+
+	unfinished := q.InFlight()
+	cancelHandlers()
+
+	<-drained
+	return &UnfinishedTasksError{Unfinished: unfinished}
+}
+
+func (q *Queue) run(ctx context.Context, t Task) {
+	q.mu.RLock()
+	tt, ok := q.types[t.Type]
+	mw := q.mw
+	q.mu.RUnlock()
+
+	var err error
+	if !ok {
+		err = ErrUnknownType
+	} else {
+		err = q.handle(ctx, tt, mw, &t)
+	}
+
+	if ctx.Err() != nil {
+		// the drain timeout expired, the task is handled again once its lease
+		// expires
+		return
+	}
+
+	if err == nil {
+		if err := q.opts.store.Complete(ctx, t.ID); err != nil {
+			q.opts.onError(fmt.Errorf("tasks: complete task %s: %w", t.ID, err))
+		}
+		return
+	}
+
+	policy := q.opts.retry
+	if ok && tt.retry != nil {
+		policy = *tt.retry
+	}
+
+	t.Attempts++
+	t.LastError = err.Error()
+
+	if t.Attempts >= policy.MaxAttempts || errors.Is(err, ErrSkipRetry) {
+		q.opts.onError(&TaskError{Task: &t, Err: err, Final: true})
+		if err := q.opts.store.Complete(ctx, t.ID); err != nil {
+			q.opts.onError(fmt.Errorf("tasks: complete task %s: %w", t.ID, err))
+		}
+		return
+	}
+
+	q.opts.onError(&TaskError{Task: &t, Err: err})
+
+	t.RunAt = q.now().Add(policy.delay(t.Attempts))
+	if err := q.opts.store.Reschedule(ctx, t); err != nil {
+		q.opts.onError(fmt.Errorf("tasks: reschedule task %s: %w", t.ID, err))
+	}
+}
+
+func (q *Queue) handle(ctx context.Context, tt *TaskType, mw []Middleware, t *Task) (err error) {
+	if tt.slots != nil {
+		select {
+		case tt.slots <- struct{}{}:
+			defer func() { <-tt.slots }()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if tt.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("tasks: panic in %s: %v", tt.name, r)
+		}
+	}()
+
+	h := tt.handler
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+
+	return h(ctx, t)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("tasks: generate id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,272 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func startQueue(t *testing.T, q *Queue) (stop func() error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- q.Start(ctx) }()
+
+	return func() error {
+		cancel()
+		return <-errCh
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test_Queue_Enqueue(t *testing.T) {
+	store := NewMemoryStore()
+	q := New(WithStore(store))
+
+	got := make(chan string, 1)
+	q.Register("greet", func(ctx context.Context, t *Task) error {
+		var name string
+		if err := t.Decode(&name); err != nil {
+			return err
+		}
+		got <- name
+		return nil
+	})
+
+	stop := startQueue(t, q)
+
+	if _, err := q.Enqueue(context.Background(), "greet", "gopher"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case name := <-got:
+		if name != "gopher" {
+			t.Errorf("expected gopher, got %s", name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("task not handled")
+	}
+
+	waitFor(t, func() bool { return store.Len() == 0 })
+
+	if err := stop(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_Queue_Delay(t *testing.T) {
+	var handled atomic.Bool
+
+	q := New(WithPollInterval(5 * time.Millisecond))
+	q.Register("later", func(ctx context.Context, t *Task) error {
+		handled.Store(true)
+		return nil
+	})
+
+	stop := startQueue(t, q)
+	defer func() { _ = stop() }()
+
+	start := time.Now()
+	if _, err := q.Enqueue(context.Background(), "later", nil, WithDelay(50*time.Millisecond)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, handled.Load)
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("task handled after %s, before its delay", elapsed)
+	}
+}
+
+func Test_Queue_Retry(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls int
+		errs  []*TaskError
+	)
+
+	store := NewMemoryStore()
+	q := New(
+		WithStore(store),
+		WithPollInterval(time.Millisecond),
+		WithErrorHandler(func(err error) {
+			var taskErr *TaskError
+			if errors.As(err, &taskErr) {
+				mu.Lock()
+				errs = append(errs, taskErr)
+				mu.Unlock()
+			}
+		}),
+	)
+
+	q.Register("flaky", func(ctx context.Context, t *Task) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return errors.New("boom")
+	}).Retry(RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	stop := startQueue(t, q)
+	defer func() { _ = stop() }()
+
+	if _, err := q.Enqueue(context.Background(), "flaky", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, func() bool { return store.Len() == 0 })
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+
+	if len(errs) != 3 || errs[0].Final || !errs[2].Final || errs[2].Task.Attempts != 3 {
+		t.Errorf("unexpected task errors %v", errs)
+	}
+}
+
+func Test_Queue_SkipRetry(t *testing.T) {
+	var calls atomic.Int32
+
+	store := NewMemoryStore()
+	q := New(WithStore(store), WithPollInterval(time.Millisecond))
+	q.Register("invalid", func(ctx context.Context, t *Task) error {
+		calls.Add(1)
+		return errors.Join(errors.New("bad payload"), ErrSkipRetry)
+	})
+
+	stop := startQueue(t, q)
+	defer func() { _ = stop() }()
+
+	if _, err := q.Enqueue(context.Background(), "invalid", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, func() bool { return store.Len() == 0 })
+
+	if calls.Load() != 1 {
+		t.Errorf("expected 1 call, got %d", calls.Load())
+	}
+}
+
+func Test_Queue_Concurrency(t *testing.T) {
+	var running, peak atomic.Int32
+
+	store := NewMemoryStore()
+	q := New(WithStore(store), WithConcurrency(4))
+	q.Register("slow", func(ctx context.Context, t *Task) error {
+		n := running.Add(1)
+		defer running.Add(-1)
+
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}).Concurrency(2)
+
+	for range 6 {
+		if _, err := q.Enqueue(context.Background(), "slow", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stop := startQueue(t, q)
+	defer func() { _ = stop() }()
+
+	waitFor(t, func() bool { return store.Len() == 0 })
+
+	if peak.Load() != 2 {
+		t.Errorf("expected at most 2 concurrent tasks, got %d", peak.Load())
+	}
+}
+
+func Test_Queue_Middleware(t *testing.T) {
+	var order []string
+	var mu sync.Mutex
+
+	record := func(name string) Middleware {
+		return func(h Handler) Handler {
+			return func(ctx context.Context, t *Task) error {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				return h(ctx, t)
+			}
+		}
+	}
+
+	store := NewMemoryStore()
+	q := New(WithStore(store))
+	q.Use(record("first"), record("second"))
+	q.Register("noop", func(ctx context.Context, t *Task) error { return nil })
+
+	stop := startQueue(t, q)
+	defer func() { _ = stop() }()
+
+	if _, err := q.Enqueue(context.Background(), "noop", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, func() bool { return store.Len() == 0 })
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(order) != 2 || order[0] != "first" || order[1] != "second" {
+		t.Errorf("unexpected middleware order %v", order)
+	}
+}
+
+func Test_Queue_Drain(t *testing.T) {
+	release := make(chan struct{})
+
+	store := NewMemoryStore()
+	q := New(WithStore(store), WithDrainTimeout(20*time.Millisecond))
+	q.Register("blocking", func(ctx context.Context, t *Task) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return ctx.Err()
+	})
+
+	stop := startQueue(t, q)
+
+	if _, err := q.Enqueue(context.Background(), "blocking", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, func() bool { return q.InFlight() == 1 })
+
+	var unfinished *UnfinishedTasksError
+	if err := stop(); !errors.As(err, &unfinished) || unfinished.Unfinished != 1 {
+		t.Errorf("expected 1 unfinished task, got %v", err)
+	}
+
+	if store.Len() != 1 {
+		t.Errorf("expected the task to stay in the store, got %d", store.Len())
+	}
+}
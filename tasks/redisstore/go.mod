@@ -0,0 +1,25 @@
+module github.com/hay-kot/httpkit/tasks/redisstore
+
+go 1.22
+
+require (
+	github.com/alicebob/miniredis/v2 v2.35.0
+	github.com/hay-kot/httpkit v0.0.0-00010101000000-000000000000
+	github.com/redis/go-redis/v9 v9.6.1
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/dgryski/go-rendezvous v0.0.0-20200823014737-9f7001d12a5f // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_golang v1.20.5 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	github.com/yuin/gopher-lua v1.1.1 // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	google.golang.org/protobuf v1.34.2 // indirect
+)
+
+replace github.com/hay-kot/httpkit => ../..
@@ -0,0 +1,121 @@
+// Package redisstore provides a tasks.Store backed by Redis. It is a separate module
+// so that the Redis client is only a dependency of applications using it.
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/hay-kot/httpkit/tasks"
+	"github.com/redis/go-redis/v9"
+)
+
+// claimScript moves the due tasks of the schedule (KEYS[1]) to the end of their
+// lease and returns them from the task hash (KEYS[2]) in a single step, so
+// concurrent claims never return the same task.
+var claimScript = redis.NewScript(`
+local ids = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+local tasks = {}
+for _, id in ipairs(ids) do
+  redis.call('ZADD', KEYS[1], ARGV[3], id)
+  table.insert(tasks, redis.call('HGET', KEYS[2], id))
+end
+return tasks
+`)
+
+var _ tasks.Store = (*Store)(nil)
+
+// Store is a tasks.Store backed by Redis. Tasks are kept in a hash and scheduled in
+// a sorted set, both keyed with the provided prefix.
+type Store struct {
+	client   redis.Cmdable
+	schedule string
+	tasks    string
+}
+
+// New creates a new Store using the provided client. All keys are prefixed with
+// prefix, for example "tasks:".
+//
+// Example:
+//
+//	queue := tasks.New(tasks.WithStore(redisstore.New(rdb, "tasks:")))
+func New(client redis.Cmdable, prefix string) *Store {
+	return &Store{
+		client:   client,
+		schedule: prefix + "schedule",
+		tasks:    prefix + "tasks",
+	}
+}
+
+func (s *Store) Add(ctx context.Context, t tasks.Task) error {
+	return s.save(ctx, t)
+}
+
+func (s *Store) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]tasks.Task, error) {
+	res, err := claimScript.Run(ctx, s.client,
+		[]string{s.schedule, s.tasks},
+		strconv.FormatInt(now.UnixMilli(), 10),
+		limit,
+		strconv.FormatInt(now.Add(lease).UnixMilli(), 10),
+	).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := make([]tasks.Task, 0, len(res))
+	for _, raw := range res {
+		data, ok := raw.(string)
+		if !ok {
+			// the task was completed between the range and the lookup
+			continue
+		}
+
+		var t tasks.Task
+		if err := json.Unmarshal([]byte(data), &t); err != nil {
+			return nil, err
+		}
+
+		t.RunAt = now.Add(lease)
+		claimed = append(claimed, t)
+	}
+
+	return claimed, nil
+}
+
+func (s *Store) Reschedule(ctx context.Context, t tasks.Task) error {
+	exists, err := s.client.HExists(ctx, s.tasks, t.ID).Result()
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return tasks.ErrTaskNotFound
+	}
+
+	return s.save(ctx, t)
+}
+
+func (s *Store) Complete(ctx context.Context, id string) error {
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRem(ctx, s.schedule, id)
+		pipe.HDel(ctx, s.tasks, id)
+		return nil
+	})
+	return err
+}
+
+func (s *Store) save(ctx context.Context, t tasks.Task) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, s.tasks, t.ID, data)
+		pipe.ZAdd(ctx, s.schedule, redis.Z{Score: float64(t.RunAt.UnixMilli()), Member: t.ID})
+		return nil
+	})
+	return err
+}
@@ -0,0 +1,14 @@
+package redisstore
+
+import (
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hay-kot/httpkit/tasks/storetest"
+	"github.com/redis/go-redis/v9"
+)
+
+func Test_Store(t *testing.T) {
+	mr := miniredis.RunT(t)
+	storetest.Run(t, New(redis.NewClient(&redis.Options{Addr: mr.Addr()}), "tasks:"))
+}
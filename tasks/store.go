@@ -0,0 +1,109 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrTaskNotFound is returned by a Store when a task does not exist.
+var ErrTaskNotFound = errors.New("tasks: task not found")
+
+// Store persists tasks until they are handled.
+//
+// Claimed tasks are hidden from other claims until the lease expires, so tasks
+// that were being handled by a process that crashed are handled again.
+type Store interface {
+	// Add stores a new task.
+	Add(ctx context.Context, t Task) error
+	// Claim returns up to limit tasks with a RunAt at or before now, ordered by
+	// RunAt, and moves their RunAt to now plus lease.
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Task, error)
+	// Reschedule replaces a stored task after a failed attempt.
+	Reschedule(ctx context.Context, t Task) error
+	// Complete removes a task once it was handled or failed permanently.
+	Complete(ctx context.Context, id string) error
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
+
+// MemoryStore is an in-memory Store. Pending tasks are lost when the process
+// exits, so it is primarily useful for development, tests and tasks that can be
+// lost.
+type MemoryStore struct {
+	mu    sync.Mutex
+	tasks map[string]Task
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tasks: map[string]Task{}}
+}
+
+func (s *MemoryStore) Add(_ context.Context, t Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tasks[t.ID] = t
+	return nil
+}
+
+func (s *MemoryStore) Claim(_ context.Context, now time.Time, limit int, lease time.Duration) ([]Task, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []Task
+	for _, t := range s.tasks {
+		if !t.RunAt.After(now) {
+			due = append(due, t)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].RunAt.Before(due[j].RunAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	for i := range due {
+		due[i].RunAt = now.Add(lease)
+		s.tasks[due[i].ID] = due[i]
+	}
+
+	return due, nil
+}
+
+func (s *MemoryStore) Reschedule(_ context.Context, t Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tasks[t.ID]; !ok {
+		return ErrTaskNotFound
+	}
+
+	s.tasks[t.ID] = t
+	return nil
+}
+
+func (s *MemoryStore) Complete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tasks, id)
+	return nil
+}
+
+// Len returns the number of pending tasks.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.tasks)
+}
@@ -0,0 +1,136 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLiteStore is a Store backed by a SQLite database through database/sql. The
+// driver is not imported by this package, open the database with the driver of
+// your choice, for example modernc.org/sqlite or github.com/mattn/go-sqlite3.
+//
+// The table is created by Migrate:
+//
+//	CREATE TABLE IF NOT EXISTS <table> (
+//	  id         TEXT PRIMARY KEY,
+//	  type       TEXT NOT NULL,
+//	  payload    BLOB,
+//	  created    INTEGER NOT NULL,
+//	  attempts   INTEGER NOT NULL DEFAULT 0,
+//	  run_at     INTEGER NOT NULL,
+//	  last_error TEXT NOT NULL DEFAULT ''
+//	)
+//
+// Timestamps are stored as Unix milliseconds.
+type SQLiteStore struct {
+	db    *sql.DB
+	table string
+}
+
+// NewSQLiteStore creates a new SQLiteStore storing tasks in table. The table name
+// is used in queries as is and must not come from user input.
+func NewSQLiteStore(db *sql.DB, table string) *SQLiteStore {
+	return &SQLiteStore{db: db, table: table}
+}
+
+// Migrate creates the table and its index if they do not exist.
+func (s *SQLiteStore) Migrate(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %[1]s (
+  id         TEXT PRIMARY KEY,
+  type       TEXT NOT NULL,
+  payload    BLOB,
+  created    INTEGER NOT NULL,
+  attempts   INTEGER NOT NULL DEFAULT 0,
+  run_at     INTEGER NOT NULL,
+  last_error TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS %[1]s_run_at ON %[1]s (run_at);`, s.table))
+	return err
+}
+
+func (s *SQLiteStore) Add(ctx context.Context, t Task) error {
+	_, err := s.db.ExecContext(ctx,
+		"INSERT INTO "+s.table+" (id, type, payload, created, attempts, run_at, last_error) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		t.ID, t.Type, t.Payload, t.Created.UnixMilli(), t.Attempts, t.RunAt.UnixMilli(), t.LastError,
+	)
+	return err
+}
+
+func (s *SQLiteStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]Task, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, type, payload, created, attempts, last_error FROM "+s.table+" WHERE run_at <= ? ORDER BY run_at LIMIT ?",
+		now.UnixMilli(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	for rows.Next() {
+		var (
+			t       Task
+			created int64
+		)
+
+		if err := rows.Scan(&t.ID, &t.Type, &t.Payload, &created, &t.Attempts, &t.LastError); err != nil {
+			_ = rows.Close()
+			return nil, err
+		}
+
+		t.Created = time.UnixMilli(created)
+		t.RunAt = now.Add(lease)
+		tasks = append(tasks, t)
+	}
+
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, t := range tasks {
+		_, err := tx.ExecContext(ctx, "UPDATE "+s.table+" SET run_at = ? WHERE id = ?", t.RunAt.UnixMilli(), t.ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return tasks, tx.Commit()
+}
+
+func (s *SQLiteStore) Reschedule(ctx context.Context, t Task) error {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE "+s.table+" SET attempts = ?, run_at = ?, last_error = ? WHERE id = ?",
+		t.Attempts, t.RunAt.UnixMilli(), t.LastError, t.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return ErrTaskNotFound
+	}
+
+	return nil
+}
+
+func (s *SQLiteStore) Complete(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM "+s.table+" WHERE id = ?", id)
+	return err
+}
@@ -0,0 +1,12 @@
+package tasks_test
+
+import (
+	"testing"
+
+	"github.com/hay-kot/httpkit/tasks"
+	"github.com/hay-kot/httpkit/tasks/storetest"
+)
+
+func Test_MemoryStore(t *testing.T) {
+	storetest.Run(t, tasks.NewMemoryStore())
+}
@@ -0,0 +1,130 @@
+// Package storetest checks that an implementation of tasks.Store behaves like the
+// stores of the tasks package, for use in the tests of custom stores.
+//
+// Example:
+//
+//	func TestStore(t *testing.T) {
+//	  storetest.Run(t, mystore.New(db))
+//	}
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/tasks"
+)
+
+// Run adds, claims, reschedules and completes tasks in store, which must be empty,
+// and reports every deviation from the contract of tasks.Store. Timestamps are
+// compared at millisecond precision.
+func Run(t *testing.T, store tasks.Store) {
+	t.Helper()
+
+	ctx := context.Background()
+	now := time.UnixMilli(time.Now().UnixMilli())
+	lease := time.Minute
+
+	for i, id := range []string{"c", "a", "b"} {
+		err := store.Add(ctx, tasks.Task{
+			ID:      id,
+			Type:    "greet",
+			Payload: []byte(`"gopher"`),
+			Created: now,
+			RunAt:   now.Add(-time.Duration(3-i) * time.Second),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := store.Add(ctx, tasks.Task{ID: "later", Type: "greet", Created: now, RunAt: now.Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	// due tasks are claimed in RunAt order, up to limit
+	claimed, err := store.Claim(ctx, now, 2, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(claimed) != 2 || claimed[0].ID != "c" || claimed[1].ID != "a" {
+		t.Fatalf("expected tasks c and a, got %v", claimed)
+	}
+
+	got := claimed[0]
+	if got.Type != "greet" || string(got.Payload) != `"gopher"` || !got.Created.Equal(now) {
+		t.Errorf("unexpected task %+v", got)
+	}
+
+	for _, task := range claimed {
+		if !task.RunAt.Equal(now.Add(lease)) {
+			t.Errorf("expected RunAt at the end of the lease, got %s", task.RunAt)
+		}
+	}
+
+	// claimed tasks are hidden until their lease expires
+	claimed, err = store.Claim(ctx, now, 10, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(claimed) != 1 || claimed[0].ID != "b" {
+		t.Fatalf("expected task b, got %v", claimed)
+	}
+
+	claimed, err = store.Claim(ctx, now.Add(lease), 10, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(claimed) != 3 {
+		t.Fatalf("expected the 3 leased tasks to be claimed again, got %v", claimed)
+	}
+
+	// a rescheduled task is claimed with its new attempts and error
+	retry := claimed[0]
+	retry.Attempts, retry.LastError, retry.RunAt = 1, "failed", now.Add(3*lease)
+	if err := store.Reschedule(ctx, retry); err != nil {
+		t.Fatal(err)
+	}
+
+	claimed, err = store.Claim(ctx, now.Add(3*lease), 10, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, task := range claimed {
+		if task.ID == retry.ID {
+			found = task.Attempts == 1 && task.LastError == "failed"
+		}
+	}
+
+	if !found {
+		t.Errorf("expected rescheduled task %s, got %v", retry.ID, claimed)
+	}
+
+	err = store.Reschedule(ctx, tasks.Task{ID: "missing", RunAt: now})
+	if !errors.Is(err, tasks.ErrTaskNotFound) {
+		t.Errorf("expected tasks.ErrTaskNotFound, got %v", err)
+	}
+
+	// completed tasks are never claimed again
+	for _, id := range []string{"a", "b", "c", "later"} {
+		if err := store.Complete(ctx, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	claimed, err = store.Claim(ctx, now.Add(24*time.Hour), 10, lease)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(claimed) != 0 {
+		t.Errorf("expected no tasks, got %v", claimed)
+	}
+}
@@ -0,0 +1,133 @@
+// Package tasks is an in-process task queue. Handlers are registered for named
+// task types, tasks are enqueued with an optional delay and handled in the
+// background with retries, concurrency limits and middleware. Tasks are kept in
+// a Store until they are handled, so they survive restarts when the store is
+// durable. A Redis store is provided by the separate
+// github.com/hay-kot/httpkit/tasks/redisstore module.
+//
+// The Queue is a graceful.Plugin that drains the tasks being handled when the
+// Runner shuts down.
+//
+// Example:
+//
+//	queue := tasks.New(tasks.WithStore(redisstore.New(rdb, "tasks:")))
+//	queue.Use(tasks.Logger(logger))
+//
+//	queue.Register("email.welcome", func(ctx context.Context, t *tasks.Task) error {
+//	  var user User
+//	  if err := t.Decode(&user); err != nil {
+//	    return errors.Join(err, tasks.ErrSkipRetry)
+//	  }
+//	  return mailer.SendWelcome(ctx, user)
+//	}).Concurrency(2)
+//
+//	runner.AddPlugin(queue)
+//
+//	_, err := queue.Enqueue(ctx, "email.welcome", user, tasks.WithDelay(time.Minute))
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrSkipRetry can be wrapped in the error returned by a Handler to fail the
+	// task without retrying it.
+	ErrSkipRetry = errors.New("tasks: skip retry")
+	// ErrUnknownType is reported for tasks without a registered handler.
+	ErrUnknownType = errors.New("tasks: unknown task type")
+)
+
+// Task is a unit of work handled by the Handler registered for its type.
+type Task struct {
+	ID      string    `json:"id"`
+	Type    string    `json:"type"`
+	Payload []byte    `json:"payload"`
+	Created time.Time `json:"created"`
+
+	// Attempts is the number of failed attempts to handle the task.
+	Attempts int `json:"attempts"`
+	// RunAt is the time the task is due.
+	RunAt time.Time `json:"run_at"`
+	// LastError describes why the last attempt failed.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Decode decodes the JSON payload of the task into v.
+func (t *Task) Decode(v any) error {
+	if err := json.Unmarshal(t.Payload, v); err != nil {
+		return fmt.Errorf("tasks: decode payload of %s: %w", t.Type, err)
+	}
+	return nil
+}
+
+// Handler handles a task. Returning an error retries the task according to the
+// RetryPolicy of its type.
+type Handler func(ctx context.Context, t *Task) error
+
+// Middleware wraps the Handler of every task type registered on a Queue.
+type Middleware func(Handler) Handler
+
+// TaskError is passed to the error handler of a Queue when a task fails.
+type TaskError struct {
+	Task *Task
+	Err  error
+	// Final is true if the task is not retried and was removed from the Store.
+	Final bool
+}
+
+func (e *TaskError) Error() string {
+	if e.Final {
+		return fmt.Sprintf("task %s (%s) failed permanently: %v", e.Task.ID, e.Task.Type, e.Err)
+	}
+	return fmt.Sprintf("task %s (%s) failed: %v", e.Task.ID, e.Task.Type, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// UnfinishedTasksError is returned by Queue.Start when tasks were still being
+// handled once the drain timeout expired. The tasks remain in the Store and are
+// handled again once their lease expires.
+type UnfinishedTasksError struct {
+	Unfinished int
+}
+
+func (e *UnfinishedTasksError) Error() string {
+	return fmt.Sprintf("queue stopped with %d unfinished tasks", e.Unfinished)
+}
+
+// RetryPolicy controls how failed tasks are retried.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts after which a task fails
+	// permanently. A value of 1 disables retries.
+	MaxAttempts int
+	// Backoff is the delay before the first retry, it doubles with every failed
+	// attempt up to MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used for task types without a RetryPolicy.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	Backoff:     time.Second,
+	MaxBackoff:  5 * time.Minute,
+}
+
+func (p RetryPolicy) delay(attempts int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempts && delay < p.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if p.MaxBackoff > 0 {
+		return min(delay, p.MaxBackoff)
+	}
+	return delay
+}
@@ -0,0 +1,45 @@
+package tenancy
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter is a token bucket that refills at rate tokens per second up to burst
+// tokens.
+type limiter struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newLimiter(rate float64, burst int, now time.Time) *limiter {
+	b := float64(max(burst, 1))
+	return &limiter{rate: rate, burst: b, tokens: b, last: now}
+}
+
+// sameLimit reports whether the limiter was created with rate and burst.
+func (l *limiter) sameLimit(rate float64, burst int) bool {
+	return l.rate == rate && l.burst == float64(max(burst, 1))
+}
+
+// allow reports whether a request at now is within the limit and takes a token
+// if it is.
+func (l *limiter) allow(now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.burst, l.tokens+elapsed.Seconds()*l.rate)
+		l.last = now
+	}
+
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
@@ -0,0 +1,330 @@
+// Package tenancy builds a separate errchain.Mux for every tenant of a
+// multi-tenant application from a shared template and dispatches requests to
+// them by host or path prefix. Tenants can be added and removed while the
+// application is running, and every tenant has its own rate limit and metric
+// labels.
+package tenancy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/internal/promutil"
+	"github.com/hay-kot/httpkit/middleware"
+	"github.com/hay-kot/httpkit/server"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ErrInvalidTenant is returned by Router.Add for tenants without an ID or with
+// an ID that is not a single path segment in path prefix mode.
+var ErrInvalidTenant = errors.New("tenancy: invalid tenant")
+
+// Tenant is the configuration of a tenant. C is the type of the tenant specific
+// configuration passed to the BuildFunc.
+type Tenant[C any] struct {
+	ID middleware.TenantID
+	// Hosts are the hosts the tenant is served on, for example custom domains.
+	// Hosts take precedence over the resolver set with WithResolver.
+	Hosts []string
+	// Middleware is applied to every route of the tenant, see errchain.Mux.Use.
+	Middleware []func(http.Handler) http.Handler
+	// RateLimit is the number of requests per second allowed for the tenant with
+	// bursts of up to Burst requests. Zero disables the rate limit.
+	RateLimit float64
+	Burst     int
+	// Config is the tenant specific configuration.
+	Config C
+}
+
+// BuildFunc registers the routes of a tenant on mux.
+type BuildFunc[C any] func(mux *errchain.Mux, t Tenant[C]) error
+
+type opts struct {
+	resolver   middleware.TenantResolver
+	pathPrefix bool
+	registerer prometheus.Registerer
+	now        func() time.Time
+}
+
+type OptFunc func(*opts)
+
+// WithResolver dispatches requests by the tenant resolved by resolver, for
+// example middleware.TenantFromSubdomain, when the host of the request is not
+// one of the Hosts of a tenant.
+func WithResolver(resolver middleware.TenantResolver) OptFunc {
+	return func(o *opts) {
+		o.resolver = resolver
+	}
+}
+
+// WithPathPrefix dispatches requests by the first segment of the path, for
+// example "/acme/users" to the tenant "acme". The routes of every tenant are
+// registered with the prefix "/<tenant>", see errchain.Mux.UsePrefix.
+func WithPathPrefix() OptFunc {
+	return func(o *opts) {
+		o.pathPrefix = true
+	}
+}
+
+// WithMetrics records Prometheus metrics labeled by tenant and registers the
+// collectors with registerer. The series of a tenant are deleted when it is
+// removed.
+//
+// The following metrics are exported, labeled by tenant, method and status:
+//   - tenancy_http_requests_total
+//   - tenancy_http_request_duration_seconds
+func WithMetrics(registerer prometheus.Registerer) OptFunc {
+	return func(o *opts) {
+		o.registerer = registerer
+	}
+}
+
+type entry struct {
+	handler http.Handler
+	hosts   []string
+	limiter *limiter
+}
+
+// Router dispatches requests to the Mux of their tenant. Create one with
+// NewRouter.
+type Router[C any] struct {
+	opts  *opts
+	chain *errchain.ErrChain
+	build BuildFunc[C]
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+
+	mu      sync.RWMutex
+	tenants map[middleware.TenantID]*entry
+	hosts   map[string]middleware.TenantID
+}
+
+// NewRouter returns a new Router that builds the Mux of every tenant with build.
+// The muxes share chain, so error handling and global middleware are the same for
+// all tenants.
+//
+// Example:
+//
+//	router := tenancy.NewRouter(chain, func(mux *errchain.Mux, t tenancy.Tenant[Config]) error {
+//	  h := handlers.New(t.Config.Database)
+//	  mux.Get("/users", h.ListUsers)
+//	  return nil
+//	}, tenancy.WithResolver(middleware.TenantFromSubdomain("example.com")))
+//
+//	err := router.Add(tenancy.Tenant[Config]{ID: "acme", RateLimit: 100, Burst: 200})
+func NewRouter[C any](chain *errchain.ErrChain, build BuildFunc[C], fns ...OptFunc) *Router[C] {
+	o := &opts{
+		now: time.Now,
+	}
+
+	for _, fn := range fns {
+		fn(o)
+	}
+
+	r := &Router[C]{
+		opts:    o,
+		chain:   chain,
+		build:   build,
+		tenants: map[middleware.TenantID]*entry{},
+		hosts:   map[string]middleware.TenantID{},
+	}
+
+	if o.registerer != nil {
+		labels := []string{"tenant", "method", "status"}
+		r.requests = promutil.Register(o.registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "tenancy_http_requests_total",
+			Help: "Total number of HTTP requests processed per tenant.",
+		}, labels))
+		r.duration = promutil.Register(o.registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "tenancy_http_request_duration_seconds",
+			Help:    "Duration of HTTP requests per tenant in seconds.",
+			Buckets: prometheus.DefBuckets,
+		}, labels))
+	}
+
+	return r
+}
+
+// Add builds the Mux of t and starts serving it. If a tenant with the same ID
+// exists, it is replaced once the new Mux was built, requests being served by the
+// old Mux are not interrupted. The replaced tenant keeps the state of its rate
+// limit if RateLimit and Burst are unchanged, and starts with a full burst
+// otherwise.
+//
+// A host already served by another tenant is moved to t.
+func (r *Router[C]) Add(t Tenant[C]) error {
+	if t.ID == "" || (r.opts.pathPrefix && strings.Contains(string(t.ID), "/")) {
+		return fmt.Errorf("%w: %q", ErrInvalidTenant, t.ID)
+	}
+
+	mux := errchain.NewMux(r.chain)
+	if r.opts.pathPrefix {
+		mux.UsePrefix("/" + string(t.ID))
+	}
+	mux.Use(t.Middleware...)
+
+	if err := r.build(mux, t); err != nil {
+		return fmt.Errorf("tenancy: build tenant %s: %w", t.ID, err)
+	}
+
+	e := &entry{handler: mux}
+	for _, host := range t.Hosts {
+		e.hosts = append(e.hosts, normalizeHost(host))
+	}
+
+	if t.RateLimit > 0 {
+		e.limiter = newLimiter(t.RateLimit, t.Burst, r.opts.now())
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if old, ok := r.tenants[t.ID]; ok {
+		r.deleteHosts(t.ID, old)
+
+		if old.limiter != nil && e.limiter != nil && old.limiter.sameLimit(t.RateLimit, t.Burst) {
+			e.limiter = old.limiter
+		}
+	}
+
+	r.tenants[t.ID] = e
+	for _, host := range e.hosts {
+		r.hosts[host] = t.ID
+	}
+
+	return nil
+}
+
+// Remove stops serving the tenant with the given ID and deletes its metric
+// series. It reports whether the tenant existed.
+func (r *Router[C]) Remove(id middleware.TenantID) bool {
+	r.mu.Lock()
+	e, ok := r.tenants[id]
+	if ok {
+		delete(r.tenants, id)
+		r.deleteHosts(id, e)
+	}
+	r.mu.Unlock()
+
+	if ok && r.requests != nil {
+		r.requests.DeletePartialMatch(prometheus.Labels{"tenant": string(id)})
+		r.duration.DeletePartialMatch(prometheus.Labels{"tenant": string(id)})
+	}
+
+	return ok
+}
+
+// deleteHosts deletes the hosts of e that are still served by the tenant id, as
+// they may have been moved to another tenant since. It must be called with r.mu
+// held.
+func (r *Router[C]) deleteHosts(id middleware.TenantID, e *entry) {
+	for _, host := range e.hosts {
+		if r.hosts[host] == id {
+			delete(r.hosts, host)
+		}
+	}
+}
+
+// Tenants returns the IDs of the tenants being served in sorted order.
+func (r *Router[C]) Tenants() []middleware.TenantID {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]middleware.TenantID, 0, len(r.tenants))
+	for id := range r.tenants {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+	return ids
+}
+
+// ServeHTTP dispatches the request to the Mux of its tenant. The tenant is stored
+// in the request context, see middleware.GetTenant. Requests for unknown tenants
+// receive a 404 Not Found error response and requests over the rate limit of
+// their tenant a 429 Too Many Requests error response.
+func (r *Router[C]) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	id, e, err := r.lookup(req)
+	if err != nil {
+		if !errors.Is(err, middleware.ErrUnknownTenant) {
+			_ = server.Err(err).Write(req.Context(), w)
+			return
+		}
+
+		_ = server.Err(err).
+			Status(http.StatusNotFound).
+			Msg("unknown tenant").
+			Write(req.Context(), w)
+		return
+	}
+
+	start := r.opts.now()
+	rec := promutil.NewResponseRecorder(w)
+
+	if e.limiter != nil && !e.limiter.allow(start) {
+		rec.Header().Set("Retry-After", "1")
+		_ = server.Error().
+			Status(http.StatusTooManyRequests).
+			Msg("rate limit exceeded").
+			Write(req.Context(), rec)
+	} else {
+		e.handler.ServeHTTP(rec, req.WithContext(middleware.TenantKey.Set(req.Context(), id)))
+	}
+
+	if r.requests != nil {
+		labels := prometheus.Labels{
+			"tenant": string(id),
+			"method": req.Method,
+			"status": strconv.Itoa(rec.Status),
+		}
+		r.requests.With(labels).Inc()
+		r.duration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (r *Router[C]) lookup(req *http.Request) (middleware.TenantID, *entry, error) {
+	r.mu.RLock()
+	id, ok := r.hosts[normalizeHost(req.Host)]
+	r.mu.RUnlock()
+
+	if !ok {
+		switch {
+		case r.opts.pathPrefix:
+			segment, _, _ := strings.Cut(strings.TrimPrefix(req.URL.Path, "/"), "/")
+			id = middleware.TenantID(segment)
+		case r.opts.resolver != nil:
+			resolved, err := r.opts.resolver(req)
+			if err != nil {
+				return "", nil, err
+			}
+			id = resolved
+		}
+	}
+
+	r.mu.RLock()
+	e, ok := r.tenants[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		return "", nil, middleware.ErrUnknownTenant
+	}
+
+	return id, e, nil
+}
+
+// normalizeHost lowercases host and strips its port.
+func normalizeHost(host string) string {
+	host = strings.ToLower(host)
+	if i := strings.LastIndexByte(host, ':'); i != -1 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	return host
+}
@@ -0,0 +1,208 @@
+package tenancy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+type config struct {
+	Greeting string
+}
+
+var testChain = errchain.New(func(h errchain.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := h.ServeHTTP(w, r); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+})
+
+func build(mux *errchain.Mux, t Tenant[config]) error {
+	mux.Get("/hello", func(w http.ResponseWriter, r *http.Request) error {
+		id, _ := middleware.GetTenant(r.Context())
+		_, err := w.Write([]byte(t.Config.Greeting + " " + string(id)))
+		return err
+	})
+	return nil
+}
+
+func do(h http.Handler, host, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Host = host
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func Test_Router_Host(t *testing.T) {
+	router := NewRouter(testChain, build, WithResolver(middleware.TenantFromSubdomain("example.com")))
+
+	must(t, router.Add(Tenant[config]{ID: "acme", Config: config{Greeting: "hello"}}))
+	must(t, router.Add(Tenant[config]{ID: "globex", Hosts: []string{"Globex.test"}, Config: config{Greeting: "hi"}}))
+
+	tests := []struct {
+		host       string
+		wantStatus int
+		wantBody   string
+	}{
+		{host: "acme.example.com", wantStatus: http.StatusOK, wantBody: "hello acme"},
+		{host: "globex.test:8080", wantStatus: http.StatusOK, wantBody: "hi globex"},
+		{host: "globex.example.com", wantStatus: http.StatusOK, wantBody: "hi globex"},
+		{host: "initech.example.com", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			rec := do(router, tt.host, "/hello")
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+
+			if tt.wantBody != "" && rec.Body.String() != tt.wantBody {
+				t.Errorf("expected body %q, got %q", tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func Test_Router_PathPrefix(t *testing.T) {
+	router := NewRouter(testChain, build, WithPathPrefix())
+	must(t, router.Add(Tenant[config]{ID: "acme", Config: config{Greeting: "hello"}}))
+
+	if rec := do(router, "example.com", "/acme/hello"); rec.Body.String() != "hello acme" {
+		t.Errorf("unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+
+	if rec := do(router, "example.com", "/globex/hello"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404, got %d", rec.Code)
+	}
+
+	if err := router.Add(Tenant[config]{ID: "a/b"}); !errors.Is(err, ErrInvalidTenant) {
+		t.Errorf("expected ErrInvalidTenant, got %v", err)
+	}
+}
+
+func Test_Router_AddRemove(t *testing.T) {
+	router := NewRouter(testChain, build, WithPathPrefix())
+	must(t, router.Add(Tenant[config]{ID: "acme", Config: config{Greeting: "hello"}}))
+	must(t, router.Add(Tenant[config]{ID: "acme", Config: config{Greeting: "howdy"}}))
+
+	if rec := do(router, "", "/acme/hello"); rec.Body.String() != "howdy acme" {
+		t.Errorf("expected replaced tenant to be served, got %q", rec.Body.String())
+	}
+
+	if !router.Remove("acme") || router.Remove("acme") {
+		t.Error("expected the tenant to be removed once")
+	}
+
+	if rec := do(router, "", "/acme/hello"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected status 404 after removal, got %d", rec.Code)
+	}
+
+	if len(router.Tenants()) != 0 {
+		t.Errorf("expected no tenants, got %v", router.Tenants())
+	}
+}
+
+func Test_Router_MovedHost(t *testing.T) {
+	router := NewRouter(testChain, build)
+	must(t, router.Add(Tenant[config]{ID: "acme", Hosts: []string{"shop.test"}, Config: config{Greeting: "hello"}}))
+	must(t, router.Add(Tenant[config]{ID: "globex", Hosts: []string{"shop.test"}, Config: config{Greeting: "hi"}}))
+
+	// replacing and removing acme keeps the host it no longer serves
+	must(t, router.Add(Tenant[config]{ID: "acme", Hosts: []string{"acme.test"}, Config: config{Greeting: "hello"}}))
+	router.Remove("acme")
+
+	if rec := do(router, "shop.test", "/hello"); rec.Body.String() != "hi globex" {
+		t.Errorf("expected the host to be served by globex, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func Test_Router_RateLimit(t *testing.T) {
+	now := time.Now()
+
+	router := NewRouter(testChain, build, WithPathPrefix())
+	router.opts.now = func() time.Time { return now }
+
+	must(t, router.Add(Tenant[config]{ID: "acme", RateLimit: 1, Burst: 2}))
+	must(t, router.Add(Tenant[config]{ID: "globex"}))
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if rec := do(router, "", "/acme/hello"); rec.Code != want {
+			t.Errorf("request %d: expected status %d, got %d", i, want, rec.Code)
+		}
+	}
+
+	if rec := do(router, "", "/globex/hello"); rec.Code != http.StatusOK {
+		t.Errorf("expected other tenants to be unaffected, got %d", rec.Code)
+	}
+
+	now = now.Add(time.Second)
+	if rec := do(router, "", "/acme/hello"); rec.Code != http.StatusOK {
+		t.Errorf("expected the limit to refill, got %d", rec.Code)
+	}
+
+	// replacing the tenant with the same limit keeps its state
+	must(t, router.Add(Tenant[config]{ID: "acme", RateLimit: 1, Burst: 2}))
+	if rec := do(router, "", "/acme/hello"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the limit to be kept, got %d", rec.Code)
+	}
+
+	// and a new limit starts with a full burst
+	must(t, router.Add(Tenant[config]{ID: "acme", RateLimit: 1, Burst: 3}))
+	if rec := do(router, "", "/acme/hello"); rec.Code != http.StatusOK {
+		t.Errorf("expected a full burst, got %d", rec.Code)
+	}
+}
+
+func Test_Router_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	router := NewRouter(testChain, build, WithPathPrefix(), WithMetrics(reg))
+	must(t, router.Add(Tenant[config]{ID: "acme"}))
+	must(t, router.Add(Tenant[config]{ID: "globex"}))
+
+	do(router, "", "/acme/hello")
+	do(router, "", "/globex/hello")
+	do(router, "", "/globex/missing")
+
+	expected := `
+# HELP tenancy_http_requests_total Total number of HTTP requests processed per tenant.
+# TYPE tenancy_http_requests_total counter
+tenancy_http_requests_total{method="GET",status="200",tenant="acme"} 1
+tenancy_http_requests_total{method="GET",status="200",tenant="globex"} 1
+tenancy_http_requests_total{method="GET",status="404",tenant="globex"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "tenancy_http_requests_total"); err != nil {
+		t.Error(err)
+	}
+
+	router.Remove("globex")
+
+	expected = `
+# HELP tenancy_http_requests_total Total number of HTTP requests processed per tenant.
+# TYPE tenancy_http_requests_total counter
+tenancy_http_requests_total{method="GET",status="200",tenant="acme"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "tenancy_http_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,18 @@
+// Package testkit provides an in-process test harness for handlers built with the
+// httpkit packages. Handlers are served on an in-memory listener, requests are
+// built with a fluent builder, and responses come with assertions on the status,
+// headers and JSON body, replacing the scaffolding usually rebuilt around
+// httptest in every project.
+//
+// Example:
+//
+//	func Test_GetUser(t *testing.T) {
+//	  srv := testkit.NewServer(t, newMux())
+//
+//	  testkit.Get("/users/1").
+//	    Header("Accept", "application/json").
+//	    Do(t, srv).
+//	    Status(http.StatusOK).
+//	    JSON(`{"id": 1, "name": "gopher"}`)
+//	}
+package testkit
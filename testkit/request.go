@@ -0,0 +1,142 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// Request is a fluent builder for a request sent to a handler under test. Create
+// one with Get, Post, Put, Patch, Delete or NewRequest.
+type Request struct {
+	method string
+	path   string
+	header http.Header
+	query  url.Values
+	body   io.Reader
+	ctx    context.Context
+	err    error
+}
+
+// NewRequest returns a Request with the given method and path. The path may
+// include a query string.
+func NewRequest(method, path string) *Request {
+	return &Request{
+		method: method,
+		path:   path,
+		header: make(http.Header),
+		query:  make(url.Values),
+		ctx:    context.Background(),
+	}
+}
+
+// Get returns a GET Request for path.
+func Get(path string) *Request { return NewRequest(http.MethodGet, path) }
+
+// Post returns a POST Request for path.
+func Post(path string) *Request { return NewRequest(http.MethodPost, path) }
+
+// Put returns a PUT Request for path.
+func Put(path string) *Request { return NewRequest(http.MethodPut, path) }
+
+// Patch returns a PATCH Request for path.
+func Patch(path string) *Request { return NewRequest(http.MethodPatch, path) }
+
+// Delete returns a DELETE Request for path.
+func Delete(path string) *Request { return NewRequest(http.MethodDelete, path) }
+
+// Header adds a header to the request.
+func (r *Request) Header(key, value string) *Request {
+	r.header.Add(key, value)
+	return r
+}
+
+// Query adds a query parameter to the request.
+func (r *Request) Query(key, value string) *Request {
+	r.query.Add(key, value)
+	return r
+}
+
+// Context sets the context of the request.
+func (r *Request) Context(ctx context.Context) *Request {
+	r.ctx = ctx
+	return r
+}
+
+// Body sets the body of the request.
+func (r *Request) Body(body io.Reader) *Request {
+	r.body = body
+	return r
+}
+
+// JSON sets the body of the request to v encoded as JSON, and the Content-Type
+// header to application/json.
+func (r *Request) JSON(v any) *Request {
+	b, err := json.Marshal(v)
+	if err != nil {
+		r.err = err
+		return r
+	}
+
+	r.header.Set("Content-Type", "application/json")
+	return r.Body(bytes.NewReader(b))
+}
+
+// Do sends the request to h and returns the response, failing the test if the
+// request cannot be sent. If h is not a *Server, it is served by a new Server for
+// the duration of the test.
+//
+// Example:
+//
+//	testkit.Post("/users").
+//	  Header("Authorization", "Bearer "+token).
+//	  JSON(user).
+//	  Do(t, mux).
+//	  Status(http.StatusCreated).
+//	  JSON(`{"id": 1, "name": "gopher"}`)
+func (r *Request) Do(t testing.TB, h http.Handler) *Response {
+	t.Helper()
+
+	if r.err != nil {
+		t.Fatalf("testkit: %s %s: %v", r.method, r.path, r.err)
+	}
+
+	srv, ok := h.(*Server)
+	if !ok {
+		srv = NewServer(t, h)
+	}
+
+	req, err := http.NewRequestWithContext(r.ctx, r.method, "http://testkit"+r.path, r.body)
+	if err != nil {
+		t.Fatalf("testkit: %s %s: %v", r.method, r.path, err)
+	}
+
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+
+	if len(r.query) > 0 {
+		q := req.URL.Query()
+		for k, v := range r.query {
+			q[k] = append(q[k], v...)
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("testkit: %s %s: %v", r.method, r.path, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("testkit: %s %s: read body: %v", r.method, r.path, err)
+	}
+
+	return &Response{Response: resp, t: t, body: body}
+}
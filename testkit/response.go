@@ -0,0 +1,103 @@
+package testkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Response is the response to a Request, with assertions that report failures on
+// the test the request was sent with. Every assertion returns the Response for
+// chaining.
+type Response struct {
+	*http.Response
+	t    testing.TB
+	body []byte
+}
+
+// Body returns the response body.
+func (r *Response) Body() []byte {
+	return r.body
+}
+
+// Status asserts the status code of the response.
+func (r *Response) Status(code int) *Response {
+	r.t.Helper()
+
+	if r.StatusCode != code {
+		r.t.Errorf("testkit: expected status %d, got %d: %s", code, r.StatusCode, r.body)
+	}
+
+	return r
+}
+
+// Header asserts that the response header key has the given value.
+func (r *Response) Header(key, value string) *Response {
+	r.t.Helper()
+
+	if got := r.Response.Header.Get(key); got != value {
+		r.t.Errorf("testkit: expected header %s %q, got %q", key, value, got)
+	}
+
+	return r
+}
+
+// Contains asserts that the response body contains s.
+func (r *Response) Contains(s string) *Response {
+	r.t.Helper()
+
+	if !bytes.Contains(r.body, []byte(s)) {
+		r.t.Errorf("testkit: expected body to contain %q, got %s", s, r.body)
+	}
+
+	return r
+}
+
+// JSON asserts that the response body is JSON equal to expected, ignoring
+// formatting and the order of object keys. expected is either a JSON string or a
+// value that is encoded as JSON.
+func (r *Response) JSON(expected any) *Response {
+	r.t.Helper()
+
+	var want any
+	switch v := expected.(type) {
+	case string:
+		if err := json.Unmarshal([]byte(v), &want); err != nil {
+			r.t.Fatalf("testkit: invalid expected JSON: %v", err)
+		}
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			r.t.Fatalf("testkit: encode expected JSON: %v", err)
+		}
+		_ = json.Unmarshal(b, &want)
+	}
+
+	var got any
+	if err := json.Unmarshal(r.body, &got); err != nil {
+		r.t.Errorf("testkit: response is not JSON: %v: %s", err, r.body)
+		return r
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		wantJSON, _ := json.Marshal(want)
+		r.t.Errorf("testkit: expected JSON %s, got %s", wantJSON, strings.TrimSpace(string(r.body)))
+	}
+
+	return r
+}
+
+// Decode decodes the JSON response body into v, failing the test if it cannot be
+// decoded.
+func (r *Response) Decode(v any) *Response {
+	r.t.Helper()
+
+	if err := json.Unmarshal(r.body, v); err != nil {
+		r.t.Fatalf("testkit: decode response: %v: %s", err, r.body)
+	}
+
+	return r
+}
@@ -0,0 +1,68 @@
+package testkit
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// Server serves a handler on an in-memory listener for the duration of a test.
+// Requests sent with Request.Do travel through a real http.Server and
+// http.Transport, so headers, streaming and connection handling behave like they
+// do in production, without binding a port.
+type Server struct {
+	handler http.Handler
+	srv     *http.Server
+	client  *http.Client
+}
+
+// NewServer starts serving h, for example an errchain.Mux, and stops the server
+// once the test and its subtests have completed.
+//
+// Example:
+//
+//	srv := testkit.NewServer(t, mux)
+//
+//	testkit.Get("/users/1").Do(t, srv).Status(http.StatusOK)
+func NewServer(t testing.TB, h http.Handler) *Server {
+	t.Helper()
+
+	ln := newMemListener()
+
+	s := &Server{
+		handler: h,
+		srv:     &http.Server{Handler: h}, //nolint:gosec
+		client: &http.Client{
+			Transport: &http.Transport{DialContext: ln.DialContext},
+			// redirects are returned to the test so they can be asserted
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+
+	go func() {
+		if err := s.srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			t.Errorf("testkit: serve: %v", err)
+		}
+	}()
+
+	t.Cleanup(func() {
+		s.client.CloseIdleConnections()
+		_ = s.srv.Close()
+	})
+
+	return s
+}
+
+// Client returns a client that sends its requests to the server, whatever their
+// host. It does not follow redirects.
+func (s *Server) Client() *http.Client {
+	return s.client
+}
+
+// ServeHTTP serves the request with the handler of the server directly, so a Server
+// can be used anywhere a http.Handler is expected.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,93 @@
+package testkit_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/server"
+	"github.com/hay-kot/httpkit/testkit"
+)
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func newMux() *errchain.Mux {
+	mux := errchain.NewMux(errchain.New(func(h errchain.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := h.ServeHTTP(w, r); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+		})
+	}))
+
+	mux.Get("/users/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+		return server.JSON(w, http.StatusOK, user{ID: 1, Name: r.URL.Query().Get("name")})
+	})
+
+	mux.Post("/users", func(w http.ResponseWriter, r *http.Request) error {
+		var u user
+		if err := server.Decode(r, &u); err != nil {
+			return err
+		}
+		return server.JSON(w, http.StatusCreated, u)
+	})
+
+	return mux
+}
+
+func Test_Request_Do(t *testing.T) {
+	srv := testkit.NewServer(t, newMux())
+
+	testkit.Get("/users/1").
+		Header("X-Request-Id", "abc").
+		Query("name", "gopher").
+		Do(t, srv).
+		Status(http.StatusOK).
+		Header("X-Request-Id", "abc").
+		Header("Content-Type", "application/json; charset=utf-8").
+		JSON(`{"name": "gopher", "id": 1}`)
+
+	var created user
+	testkit.Post("/users").
+		JSON(user{ID: 2, Name: "ferris"}).
+		Do(t, srv).
+		Status(http.StatusCreated).
+		JSON(user{ID: 2, Name: "ferris"}).
+		Decode(&created)
+
+	if created.Name != "ferris" {
+		t.Errorf("expected ferris, got %s", created.Name)
+	}
+}
+
+func Test_Request_Do_Handler(t *testing.T) {
+	testkit.Delete("/users/1").
+		Do(t, newMux()).
+		Status(http.StatusMethodNotAllowed)
+}
+
+func Test_Response_Failures(t *testing.T) {
+	mt := &mockT{TB: t}
+
+	testkit.Get("/users/1").
+		Do(mt, newMux()).
+		Status(http.StatusNotFound).
+		Header("X-Missing", "value").
+		JSON(`{"id": 2}`).
+		Contains("ferris")
+
+	if mt.errors != 4 {
+		t.Errorf("expected 4 failed assertions, got %d", mt.errors)
+	}
+}
+
+type mockT struct {
+	testing.TB
+	errors int
+}
+
+func (m *mockT) Errorf(string, ...any) { m.errors++ }
@@ -0,0 +1,409 @@
+// Package webhooks delivers outbound webhooks. Payloads are signed with HMAC
+// secrets that can be rotated, persisted in a Store until they are delivered and
+// retried with exponential backoff, so every webhook is delivered at least once.
+// Deliveries that keep failing are reported to a dead-letter handler.
+//
+// The Dispatcher is a graceful.Plugin that delivers webhooks in the background
+// and drains the pending deliveries when the Runner shuts down.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrDrainTimeout is returned by Dispatcher.Start when deliveries were still
+// pending once the drain timeout expired.
+var ErrDrainTimeout = errors.New("webhooks: drain timeout expired with pending deliveries")
+
+// DeliveryError is passed to the dead-letter handler and describes why the last
+// attempt of a delivery failed.
+type DeliveryError struct {
+	// StatusCode is the status of the response, zero if no response was received.
+	StatusCode int
+	Err        error
+}
+
+func (e *DeliveryError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("unexpected status %d", e.StatusCode)
+}
+
+func (e *DeliveryError) Unwrap() error {
+	return e.Err
+}
+
+type dispatcherOpts struct {
+	name         string
+	secrets      []string
+	httpClient   *http.Client
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	poll         time.Duration
+	concurrency  int
+	drainTimeout time.Duration
+	deadLetter   func(ctx context.Context, d Delivery, err error)
+	onError      func(err error)
+}
+
+type DispatcherOptFunc func(*dispatcherOpts)
+
+// WithName sets the name of the dispatcher when it is used as a graceful.Plugin.
+//
+// Defaults to "webhooks"
+func WithName(name string) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.name = name
+	}
+}
+
+// WithSecrets sets the secrets payloads are signed with. Every secret produces a
+// signature, so a new secret can be added before the old one is removed once all
+// receivers were updated. See Sign.
+func WithSecrets(secrets ...string) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.secrets = secrets
+	}
+}
+
+// WithHTTPClient sets the http.Client used to send deliveries.
+//
+// Defaults to a client with a 10 second timeout
+func WithHTTPClient(c *http.Client) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.httpClient = c
+	}
+}
+
+// WithMaxAttempts sets the number of attempts after which a delivery is
+// dead-lettered.
+//
+// Defaults to 10
+func WithMaxAttempts(n int) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.maxAttempts = n
+	}
+}
+
+// WithBackoff sets the delay before the first retry of a delivery. The delay
+// doubles with every failed attempt up to maxBackoff.
+//
+// Defaults to 5 seconds and 1 hour
+func WithBackoff(backoff, maxBackoff time.Duration) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.backoff = backoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithPollInterval sets the interval the Store is polled for due deliveries.
+// Deliveries sent with Dispatcher.Send are picked up immediately.
+//
+// Defaults to 1 second
+func WithPollInterval(d time.Duration) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.poll = d
+	}
+}
+
+// WithConcurrency sets the number of deliveries sent at the same time.
+//
+// Defaults to 4
+func WithConcurrency(n int) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.concurrency = max(n, 1)
+	}
+}
+
+// WithDrainTimeout sets the time the dispatcher keeps delivering due webhooks
+// after shutdown begins. It should be lower than the shutdown timeout of the
+// plugin in the Runner. Deliveries that are not sent remain in the Store.
+//
+// Defaults to 10 seconds
+func WithDrainTimeout(d time.Duration) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.drainTimeout = d
+	}
+}
+
+// WithDeadLetter sets the function called with deliveries that failed the
+// maximum number of attempts, before they are removed from the Store. err is a
+// *DeliveryError.
+func WithDeadLetter(fn func(ctx context.Context, d Delivery, err error)) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.deadLetter = fn
+	}
+}
+
+// WithErrorHandler sets the function called with errors of the Store while
+// delivering in the background.
+func WithErrorHandler(fn func(err error)) DispatcherOptFunc {
+	return func(o *dispatcherOpts) {
+		o.onError = fn
+	}
+}
+
+// Dispatcher sends webhooks stored in a Store. Create one with NewDispatcher.
+type Dispatcher struct {
+	opts   *dispatcherOpts
+	store  Store
+	notify chan struct{}
+	now    func() time.Time
+}
+
+// NewDispatcher returns a new Dispatcher persisting deliveries in store.
+//
+// Example:
+//
+//	hooks := webhooks.NewDispatcher(webhooks.NewMemoryStore(),
+//	  webhooks.WithSecrets(os.Getenv("WEBHOOK_SECRET")),
+//	  webhooks.WithDeadLetter(func(ctx context.Context, d webhooks.Delivery, err error) {
+//	    log.Printf("webhook %s to %s dropped: %v", d.ID, d.URL, err)
+//	  }),
+//	)
+//	runner.AddPlugin(hooks)
+//
+//	id, err := hooks.Send(ctx, endpoint, "order.created", order)
+func NewDispatcher(store Store, fns ...DispatcherOptFunc) *Dispatcher {
+	o := &dispatcherOpts{
+		name:         "webhooks",
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		maxAttempts:  10,
+		backoff:      5 * time.Second,
+		maxBackoff:   time.Hour,
+		poll:         time.Second,
+		concurrency:  4,
+		drainTimeout: 10 * time.Second,
+		deadLetter:   func(context.Context, Delivery, error) {}, // NOOP
+		onError:      func(error) {},                            // NOOP
+	}
+
+	for _, fn := range fns {
+		fn(o)
+	}
+
+	return &Dispatcher{
+		opts:   o,
+		store:  store,
+		notify: make(chan struct{}, 1),
+		now:    time.Now,
+	}
+}
+
+func (d *Dispatcher) Name() string {
+	return d.opts.name
+}
+
+// Send stores a delivery of the JSON encoding of payload to url and returns its
+// ID. Once Send returns, the delivery is attempted until it succeeds or is
+// dead-lettered, even across restarts when the Store is durable.
+func (d *Dispatcher) Send(ctx context.Context, url, event string, payload any) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("webhooks: encode payload: %w", err)
+	}
+
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	now := d.now()
+	err = d.store.Add(ctx, Delivery{
+		ID:          id,
+		URL:         url,
+		Event:       event,
+		Payload:     data,
+		Created:     now,
+		NextAttempt: now,
+	})
+	if err != nil {
+		return "", fmt.Errorf("webhooks: store delivery: %w", err)
+	}
+
+	select {
+	case d.notify <- struct{}{}:
+	default:
+	}
+
+	return id, nil
+}
+
+// Start delivers due webhooks until ctx is cancelled, then keeps delivering the
+// webhooks that are due for up to the drain timeout. It returns ErrDrainTimeout
+// if the drain timeout expired before all due webhooks were attempted.
+func (d *Dispatcher) Start(ctx context.Context) error {
+	// deliveries keep their context while the dispatcher is drained
+	deliverCtx, cancelDeliveries := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelDeliveries()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-deliverCtx.Done():
+			return
+		}
+
+		timer := time.NewTimer(d.opts.drainTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			cancelDeliveries()
+		case <-deliverCtx.Done():
+		}
+	}()
+
+	ticker := time.NewTicker(d.opts.poll)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue(deliverCtx)
+
+		select {
+		case <-ctx.Done():
+			return d.drain(deliverCtx)
+		case <-ticker.C:
+		case <-d.notify:
+		}
+	}
+}
+
+func (d *Dispatcher) drain(ctx context.Context) error {
+	for d.deliverDue(ctx) > 0 {
+		if ctx.Err() != nil {
+			return ErrDrainTimeout
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ErrDrainTimeout
+	}
+
+	return nil
+}
+
+// deliverDue attempts a batch of due deliveries and returns the number of
+// deliveries attempted.
+func (d *Dispatcher) deliverDue(ctx context.Context) int {
+	due, err := d.store.Due(ctx, d.now(), d.opts.concurrency*4)
+	if err != nil {
+		if ctx.Err() == nil {
+			d.opts.onError(fmt.Errorf("webhooks: load due deliveries: %w", err))
+		}
+		return 0
+	}
+
+	var (
+		wg    sync.WaitGroup
+		slots = make(chan struct{}, d.opts.concurrency)
+	)
+
+	for _, delivery := range due {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			d.attempt(ctx, delivery)
+		}()
+	}
+
+	wg.Wait()
+	return len(due)
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery Delivery) {
+	err := d.deliver(ctx, delivery)
+	if ctx.Err() != nil {
+		// the drain timeout expired, the delivery stays due and is retried on
+		// the next start
+		return
+	}
+
+	if err == nil {
+		if err := d.store.Delete(ctx, delivery.ID); err != nil {
+			d.opts.onError(fmt.Errorf("webhooks: delete delivery %s: %w", delivery.ID, err))
+		}
+		return
+	}
+
+	delivery.Attempts++
+	delivery.LastError = err.Error()
+
+	if delivery.Attempts >= d.opts.maxAttempts {
+		d.opts.deadLetter(ctx, delivery, err)
+		if err := d.store.Delete(ctx, delivery.ID); err != nil {
+			d.opts.onError(fmt.Errorf("webhooks: delete delivery %s: %w", delivery.ID, err))
+		}
+		return
+	}
+
+	delivery.NextAttempt = d.now().Add(d.backoff(delivery.Attempts))
+	if err := d.store.Update(ctx, delivery); err != nil {
+		d.opts.onError(fmt.Errorf("webhooks: update delivery %s: %w", delivery.ID, err))
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.backoff
+	for i := 1; i < attempts && delay < d.opts.maxBackoff; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.opts.maxBackoff)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery Delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return &DeliveryError{Err: err}
+	}
+
+	now := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderID, delivery.ID)
+	req.Header.Set(HeaderEvent, delivery.Event)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	if len(d.opts.secrets) > 0 {
+		req.Header.Set(HeaderSignature, Sign(delivery.ID, now, delivery.Payload, d.opts.secrets...))
+	}
+
+	resp, err := d.opts.httpClient.Do(req)
+	if err != nil {
+		return &DeliveryError{Err: err}
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &DeliveryError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("webhooks: generate id: %w", err)
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,191 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func startDispatcher(t *testing.T, d *Dispatcher) (stop func() error) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- d.Start(ctx) }()
+
+	return func() error {
+		cancel()
+		return <-errCh
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func Test_Dispatcher_Send(t *testing.T) {
+	received := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := io.ReadAll(r.Body)
+		if r.Header.Get(HeaderEvent) != "order.created" || string(payload) != `{"id":1}` {
+			t.Errorf("unexpected delivery %v %s", r.Header, payload)
+		}
+		received <- Verify(r.Header, payload, time.Minute, "old")
+	}))
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	d := NewDispatcher(store, WithSecrets("new", "old"))
+	stop := startDispatcher(t, d)
+
+	if _, err := d.Send(context.Background(), srv.URL, "order.created", map[string]int{"id": 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case err := <-received:
+		if err != nil {
+			t.Errorf("signature did not verify: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("delivery not received")
+	}
+
+	waitFor(t, func() bool { return store.Len() == 0 })
+
+	if err := stop(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func Test_Dispatcher_Retry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	d := NewDispatcher(store, WithBackoff(time.Millisecond, time.Millisecond), WithPollInterval(time.Millisecond))
+	stop := startDispatcher(t, d)
+	defer func() { _ = stop() }()
+
+	if _, err := d.Send(context.Background(), srv.URL, "ping", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	waitFor(t, func() bool { return calls.Load() == 3 && store.Len() == 0 })
+}
+
+func Test_Dispatcher_DeadLetter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	dead := make(chan Delivery, 1)
+	var deadErr error
+
+	store := NewMemoryStore()
+	d := NewDispatcher(store,
+		WithMaxAttempts(3),
+		WithBackoff(time.Millisecond, time.Millisecond),
+		WithPollInterval(time.Millisecond),
+		WithDeadLetter(func(ctx context.Context, d Delivery, err error) {
+			deadErr = err
+			dead <- d
+		}),
+	)
+	stop := startDispatcher(t, d)
+	defer func() { _ = stop() }()
+
+	if _, err := d.Send(context.Background(), srv.URL, "ping", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case delivery := <-dead:
+		if delivery.Attempts != 3 {
+			t.Errorf("expected 3 attempts, got %d", delivery.Attempts)
+		}
+
+		var deliveryErr *DeliveryError
+		if !errors.As(deadErr, &deliveryErr) || deliveryErr.StatusCode != http.StatusInternalServerError {
+			t.Errorf("expected *DeliveryError with status 500, got %v", deadErr)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("delivery not dead-lettered")
+	}
+
+	waitFor(t, func() bool { return store.Len() == 0 })
+}
+
+func Test_Dispatcher_Drain(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	d := NewDispatcher(store, WithConcurrency(1), WithPollInterval(time.Hour))
+
+	for range 10 {
+		if _, err := d.Send(context.Background(), srv.URL, "ping", nil); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := d.Start(ctx); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if calls.Load() != 10 || store.Len() != 0 {
+		t.Errorf("expected all deliveries to be drained, got %d calls and %d pending", calls.Load(), store.Len())
+	}
+}
+
+func Test_Dispatcher_DrainTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the context is only cancelled once the body was read
+		_, _ = io.Copy(io.Discard, r.Body)
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	store := NewMemoryStore()
+	d := NewDispatcher(store, WithDrainTimeout(20*time.Millisecond))
+
+	if _, err := d.Send(context.Background(), srv.URL, "ping", nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := d.Start(ctx); !errors.Is(err, ErrDrainTimeout) {
+		t.Errorf("expected ErrDrainTimeout, got %v", err)
+	}
+
+	if store.Len() != 1 {
+		t.Errorf("expected the delivery to stay pending, got %d", store.Len())
+	}
+}
@@ -0,0 +1,100 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Headers set on every delivery.
+const (
+	HeaderID        = "Webhook-Id"
+	HeaderEvent     = "Webhook-Event"
+	HeaderTimestamp = "Webhook-Timestamp"
+	HeaderSignature = "Webhook-Signature"
+)
+
+var (
+	// ErrInvalidSignature is returned by Verify when no signature of the request
+	// matches one of the secrets.
+	ErrInvalidSignature = errors.New("webhooks: invalid signature")
+	// ErrTimestampExpired is returned by Verify when the timestamp of the request
+	// is outside of the tolerance.
+	ErrTimestampExpired = errors.New("webhooks: timestamp outside of tolerance")
+)
+
+// Sign returns the value of the Webhook-Signature header for a delivery. The
+// payload is signed with every secret, so receivers that know any of them can
+// verify it while a secret is being rotated. Each signature is the hex encoded
+// HMAC-SHA256 of "<id>.<timestamp>.<payload>", prefixed with "v1=" and separated
+// by commas.
+func Sign(id string, timestamp time.Time, payload []byte, secrets ...string) string {
+	sigs := make([]string, len(secrets))
+	for i, secret := range secrets {
+		sigs[i] = "v1=" + hex.EncodeToString(signature(secret, id, timestamp.Unix(), payload))
+	}
+
+	return strings.Join(sigs, ",")
+}
+
+func signature(secret, id string, timestamp int64, payload []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(id))
+	mac.Write([]byte("."))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// Verify checks the signature headers of a received webhook against the payload
+// for receivers of webhooks sent with a Dispatcher. It returns nil if a signature
+// matches any of the secrets and the timestamp is within tolerance of the
+// current time. A tolerance of zero disables the timestamp check.
+//
+// Example:
+//
+//	payload, _ := io.ReadAll(r.Body)
+//	if err := webhooks.Verify(r.Header, payload, 5*time.Minute, secret); err != nil {
+//	  return server.Err(err).Status(http.StatusUnauthorized).Write(r.Context(), w)
+//	}
+func Verify(header http.Header, payload []byte, tolerance time.Duration, secrets ...string) error {
+	ts, err := strconv.ParseInt(header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(ts, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrTimestampExpired
+		}
+	}
+
+	id := header.Get(HeaderID)
+
+	for _, sig := range strings.Split(header.Get(HeaderSignature), ",") {
+		raw, ok := strings.CutPrefix(strings.TrimSpace(sig), "v1=")
+		if !ok {
+			continue
+		}
+
+		got, err := hex.DecodeString(raw)
+		if err != nil {
+			continue
+		}
+
+		for _, secret := range secrets {
+			if hmac.Equal(got, signature(secret, id, ts, payload)) {
+				return nil
+			}
+		}
+	}
+
+	return ErrInvalidSignature
+}
@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func Test_Verify(t *testing.T) {
+	payload := []byte(`{"id":1}`)
+	now := time.Now()
+
+	header := func(ts time.Time, secrets ...string) http.Header {
+		h := http.Header{}
+		h.Set(HeaderID, "abc")
+		h.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+		h.Set(HeaderSignature, Sign("abc", ts, payload, secrets...))
+		return h
+	}
+
+	tests := []struct {
+		name    string
+		header  http.Header
+		payload []byte
+		secrets []string
+		want    error
+	}{
+		{
+			name:    "valid",
+			header:  header(now, "new", "old"),
+			payload: payload,
+			secrets: []string{"old"},
+		},
+		{
+			name:    "wrong secret",
+			header:  header(now, "new"),
+			payload: payload,
+			secrets: []string{"old"},
+			want:    ErrInvalidSignature,
+		},
+		{
+			name:    "tampered payload",
+			header:  header(now, "new"),
+			payload: []byte(`{"id":2}`),
+			secrets: []string{"new"},
+			want:    ErrInvalidSignature,
+		},
+		{
+			name:    "expired",
+			header:  header(now.Add(-time.Hour), "new"),
+			payload: payload,
+			secrets: []string{"new"},
+			want:    ErrTimestampExpired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Verify(tt.header, tt.payload, 5*time.Minute, tt.secrets...)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrDeliveryNotFound is returned by a Store when a delivery does not exist.
+var ErrDeliveryNotFound = errors.New("webhooks: delivery not found")
+
+// Delivery is a webhook waiting to be delivered.
+type Delivery struct {
+	ID      string    `json:"id"`
+	URL     string    `json:"url"`
+	Event   string    `json:"event"`
+	Payload []byte    `json:"payload"`
+	Created time.Time `json:"created"`
+
+	// Attempts is the number of failed delivery attempts.
+	Attempts int `json:"attempts"`
+	// NextAttempt is the time the delivery is due.
+	NextAttempt time.Time `json:"next_attempt"`
+	// LastError describes why the last attempt failed.
+	LastError string `json:"last_error,omitempty"`
+}
+
+// Store persists deliveries until they succeed or are dead-lettered. A delivery
+// is only removed from the store after it was delivered, so deliveries survive
+// restarts when the store is durable.
+type Store interface {
+	// Add stores a new delivery.
+	Add(ctx context.Context, d Delivery) error
+	// Due returns up to limit deliveries with a NextAttempt at or before now,
+	// ordered by NextAttempt.
+	Due(ctx context.Context, now time.Time, limit int) ([]Delivery, error)
+	// Update replaces a stored delivery after a failed attempt.
+	Update(ctx context.Context, d Delivery) error
+	// Delete removes a delivery once it was delivered or dead-lettered.
+	Delete(ctx context.Context, id string) error
+}
+
+var _ Store = (*MemoryStore)(nil)
+
+// MemoryStore is an in-memory Store. Pending deliveries are lost when the process
+// exits, so it is primarily useful for development, tests and deployments that
+// can tolerate losing webhooks.
+type MemoryStore struct {
+	mu         sync.Mutex
+	deliveries map[string]Delivery
+}
+
+// NewMemoryStore creates a new, empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{deliveries: map[string]Delivery{}}
+}
+
+func (s *MemoryStore) Add(_ context.Context, d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+func (s *MemoryStore) Due(_ context.Context, now time.Time, limit int) ([]Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []Delivery
+	for _, d := range s.deliveries {
+		if !d.NextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].NextAttempt.Before(due[j].NextAttempt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	return due, nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, d Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.deliveries[d.ID]; !ok {
+		return ErrDeliveryNotFound
+	}
+
+	s.deliveries[d.ID] = d
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.deliveries, id)
+	return nil
+}
+
+// Len returns the number of pending deliveries.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.deliveries)
+}
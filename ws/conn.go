@@ -0,0 +1,169 @@
+package ws
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+var (
+	// ErrQueueFull is returned by Conn.Send when the send queue of the connection
+	// is full.
+	ErrQueueFull = errors.New("ws: send queue full")
+	// ErrConnClosed is returned by Conn.Send once the connection is closing.
+	ErrConnClosed = errors.New("ws: connection closed")
+)
+
+// Conn is a WebSocket connection managed by a Hub. Messages are written by a
+// dedicated goroutine from a bounded send queue, so Send never blocks on a slow
+// client.
+type Conn struct {
+	// Request is the request the connection was upgraded from.
+	Request *http.Request
+
+	ws   *websocket.Conn
+	hub  *Hub
+	send chan []byte
+
+	closeOnce sync.Once
+	closing   chan struct{}
+	closeMsg  []byte
+	done      chan struct{} // closed once the writer has stopped
+
+	mu    sync.Mutex
+	rooms map[string]struct{}
+}
+
+func newConn(hub *Hub, ws *websocket.Conn, r *http.Request) *Conn {
+	return &Conn{
+		Request: r,
+		ws:      ws,
+		hub:     hub,
+		send:    make(chan []byte, hub.opts.sendQueue),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+		rooms:   make(map[string]struct{}),
+	}
+}
+
+// Read blocks until the next message is received and returns it. It returns io.EOF
+// once the client closed the connection normally or the connection is closing.
+func (c *Conn) Read() ([]byte, error) {
+	_, msg, err := c.ws.ReadMessage()
+	if err != nil {
+		select {
+		case <-c.closing:
+			return nil, io.EOF
+		default:
+		}
+
+		if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+			return nil, io.EOF
+		}
+
+		return nil, err
+	}
+
+	return msg, nil
+}
+
+// Send queues msg to be written to the client as a text message. It never blocks,
+// and returns ErrQueueFull when the send queue is full and ErrConnClosed once the
+// connection is closing.
+func (c *Conn) Send(msg []byte) error {
+	select {
+	case <-c.closing:
+		return ErrConnClosed
+	default:
+	}
+
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Join adds the connection to room, see Hub.BroadcastTo.
+func (c *Conn) Join(room string) {
+	c.mu.Lock()
+	c.rooms[room] = struct{}{}
+	c.mu.Unlock()
+
+	c.hub.join(c, room)
+}
+
+// Leave removes the connection from room.
+func (c *Conn) Leave(room string) {
+	c.mu.Lock()
+	delete(c.rooms, room)
+	c.mu.Unlock()
+
+	c.hub.leave(c, room)
+}
+
+// Close sends a close frame with the given code and reason to the client once the
+// queued messages are written. The connection is closed once the client
+// acknowledges it or the handler returns.
+func (c *Conn) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeMsg = websocket.FormatCloseMessage(code, reason)
+		close(c.closing)
+	})
+}
+
+// write writes the queued messages and pings to the client until the connection
+// is closing, and then writes the close frame.
+func (c *Conn) write() {
+	defer close(c.done)
+
+	var ping <-chan time.Time
+	if c.hub.opts.pingInterval > 0 {
+		ticker := time.NewTicker(c.hub.opts.pingInterval)
+		defer ticker.Stop()
+		ping = ticker.C
+	}
+
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(c.hub.opts.writeTimeout))
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				c.Close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ping:
+			deadline := time.Now().Add(c.hub.opts.writeTimeout)
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, deadline); err != nil {
+				c.Close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.closing:
+			c.flush()
+
+			deadline := time.Now().Add(c.hub.opts.writeTimeout)
+			_ = c.ws.WriteControl(websocket.CloseMessage, c.closeMsg, deadline)
+			return
+		}
+	}
+}
+
+// flush writes the messages still queued when the connection began closing.
+func (c *Conn) flush() {
+	for {
+		select {
+		case msg := <-c.send:
+			_ = c.ws.SetWriteDeadline(time.Now().Add(c.hub.opts.writeTimeout))
+			if err := c.ws.WriteMessage(websocket.TextMessage, msg); err != nil {
+				return
+			}
+		default:
+			return
+		}
+	}
+}
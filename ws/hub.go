@@ -0,0 +1,328 @@
+// Package ws provides a Hub for WebSocket connections built on errchain handlers.
+// The Hub tracks every connection, groups them in rooms for broadcasting, keeps
+// them alive with pings, and closes them with proper close frames when the
+// application shuts down.
+package ws
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/hay-kot/httpkit/errchain"
+)
+
+type hubOpts struct {
+	name         string
+	sendQueue    int
+	pingInterval time.Duration
+	pongTimeout  time.Duration
+	writeTimeout time.Duration
+	closeTimeout time.Duration
+	upgrader     *websocket.Upgrader
+}
+
+type HubOptFunc func(*hubOpts)
+
+// WithName sets the name of the hub when it is used as a graceful.Plugin.
+//
+// Defaults to "ws"
+func WithName(name string) HubOptFunc {
+	return func(o *hubOpts) {
+		o.name = name
+	}
+}
+
+// WithSendQueue sets the number of messages queued for every connection. A
+// connection whose queue is full when a message is broadcast is closed with the
+// 1013 Try Again Later close code, so slow clients can't hold back the others.
+//
+// Defaults to 32
+func WithSendQueue(n int) HubOptFunc {
+	return func(o *hubOpts) {
+		o.sendQueue = n
+	}
+}
+
+// WithKeepalive sets the interval pings are sent at, and the time a connection
+// may stay silent before it is considered dead. The pong timeout should be longer
+// than the ping interval. A zero ping interval disables keepalive.
+//
+// Defaults to pings every 30 seconds and a 60 second pong timeout
+func WithKeepalive(pingInterval, pongTimeout time.Duration) HubOptFunc {
+	return func(o *hubOpts) {
+		o.pingInterval = pingInterval
+		o.pongTimeout = pongTimeout
+	}
+}
+
+// WithCloseTimeout sets the time the hub waits for clients to acknowledge the close
+// frame on shutdown before their connections are closed.
+//
+// Defaults to 5 seconds
+func WithCloseTimeout(d time.Duration) HubOptFunc {
+	return func(o *hubOpts) {
+		o.closeTimeout = d
+	}
+}
+
+// WithUpgrader sets the upgrader used to upgrade requests, for example to check
+// the origin of requests or to negotiate subprotocols.
+func WithUpgrader(u *websocket.Upgrader) HubOptFunc {
+	return func(o *hubOpts) {
+		o.upgrader = u
+	}
+}
+
+// ConnFunc handles a single connection. The connection is closed once it returns.
+type ConnFunc func(ctx context.Context, c *Conn) error
+
+// Hub manages WebSocket connections. Create one with NewHub.
+//
+// A Hub implements graceful.Plugin: once the context passed to Start is
+// cancelled, every connection is sent the 1001 Going Away close code, and the hub
+// waits for the clients to disconnect before it returns.
+type Hub struct {
+	opts *hubOpts
+
+	mu     sync.Mutex
+	closed bool
+	conns  map[*Conn]struct{}
+	rooms  map[string]map[*Conn]struct{}
+	wg     sync.WaitGroup
+}
+
+// NewHub returns a new Hub.
+//
+// Example:
+//
+//	hub := ws.NewHub()
+//	runner.AddPlugin(hub)
+//
+//	mux.Get("/ws/chat", hub.Handler(func(ctx context.Context, c *ws.Conn) error {
+//	  c.Join("lobby")
+//	  for {
+//	    msg, err := c.Read()
+//	    if err != nil {
+//	      return err
+//	    }
+//	    hub.BroadcastTo("lobby", msg)
+//	  }
+//	}))
+func NewHub(opts ...HubOptFunc) *Hub {
+	o := &hubOpts{
+		name:         "ws",
+		sendQueue:    32,
+		pingInterval: 30 * time.Second,
+		pongTimeout:  60 * time.Second,
+		writeTimeout: 10 * time.Second,
+		closeTimeout: 5 * time.Second,
+		upgrader:     &websocket.Upgrader{},
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return &Hub{
+		opts:  o,
+		conns: make(map[*Conn]struct{}),
+		rooms: make(map[string]map[*Conn]struct{}),
+	}
+}
+
+func (h *Hub) Name() string {
+	return h.opts.name
+}
+
+// Start blocks until ctx is done and then closes the hub, see Close.
+func (h *Hub) Start(ctx context.Context) error {
+	<-ctx.Done()
+	h.Close()
+	return nil
+}
+
+// Close sends the 1001 Going Away close code to every connection and waits for the
+// clients to disconnect, up to the close timeout. New connections are rejected with
+// 503 Service Unavailable.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	h.closed = true
+	conns := make([]*Conn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range conns {
+		c.Close(websocket.CloseGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(h.opts.closeTimeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
+		for _, c := range conns {
+			_ = c.ws.Close()
+		}
+		<-done
+	}
+}
+
+// Len returns the number of open connections.
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.conns)
+}
+
+// Broadcast sends msg to every connection, see BroadcastTo.
+func (h *Hub) Broadcast(msg []byte) {
+	h.mu.Lock()
+	conns := make([]*Conn, 0, len(h.conns))
+	for c := range h.conns {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	broadcast(conns, msg)
+}
+
+// BroadcastTo sends msg to every connection in room. Connections whose send queue
+// is full are closed with the 1013 Try Again Later close code.
+func (h *Hub) BroadcastTo(room string, msg []byte) {
+	h.mu.Lock()
+	conns := make([]*Conn, 0, len(h.rooms[room]))
+	for c := range h.rooms[room] {
+		conns = append(conns, c)
+	}
+	h.mu.Unlock()
+
+	broadcast(conns, msg)
+}
+
+func broadcast(conns []*Conn, msg []byte) {
+	for _, c := range conns {
+		if err := c.Send(msg); errors.Is(err, ErrQueueFull) {
+			c.Close(websocket.CloseTryAgainLater, "slow consumer")
+		}
+	}
+}
+
+// Handler returns a handler that upgrades the request to a WebSocket connection and
+// calls fn with it. Once fn returns, the connection is closed with the 1000 Normal
+// Closure close code, or 1011 Internal Error if fn returned an error other than
+// io.EOF, and the error is returned through the error chain.
+func (h *Hub) Handler(fn ConnFunc) errchain.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		h.mu.Lock()
+		closed := h.closed
+		if !closed {
+			h.wg.Add(1)
+		}
+		h.mu.Unlock()
+
+		if closed {
+			http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+			return nil
+		}
+		defer h.wg.Done()
+
+		conn, err := h.opts.upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return nil // the upgrader has responded with an error
+		}
+
+		c := newConn(h, conn, r)
+		h.add(c)
+		defer h.remove(c)
+
+		if h.opts.pingInterval > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(h.opts.pongTimeout))
+			conn.SetPongHandler(func(string) error {
+				return conn.SetReadDeadline(time.Now().Add(h.opts.pongTimeout))
+			})
+		}
+
+		go c.write()
+
+		err = fn(r.Context(), c)
+		if errors.Is(err, io.EOF) {
+			err = nil
+		}
+
+		if err != nil {
+			c.Close(websocket.CloseInternalServerErr, "")
+		} else {
+			c.Close(websocket.CloseNormalClosure, "")
+		}
+
+		<-c.done
+		_ = conn.Close()
+
+		return err
+	}
+}
+
+func (h *Hub) add(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.conns[c] = struct{}{}
+
+	// a connection accepted while the hub was closing is closed right away
+	if h.closed {
+		c.Close(websocket.CloseGoingAway, "server shutting down")
+	}
+}
+
+func (h *Hub) remove(c *Conn) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.conns, c)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for room := range c.rooms {
+		h.removeFromRoom(c, room)
+	}
+}
+
+func (h *Hub) join(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.conns[c]; !ok {
+		return
+	}
+
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*Conn]struct{})
+	}
+	h.rooms[room][c] = struct{}{}
+}
+
+func (h *Hub) leave(c *Conn, room string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeFromRoom(c, room)
+}
+
+// removeFromRoom must be called with h.mu held.
+func (h *Hub) removeFromRoom(c *Conn, room string) {
+	delete(h.rooms[room], c)
+	if len(h.rooms[room]) == 0 {
+		delete(h.rooms, room)
+	}
+}
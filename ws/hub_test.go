@@ -0,0 +1,163 @@
+package ws_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/hay-kot/httpkit/errchain"
+	"github.com/hay-kot/httpkit/ws"
+)
+
+func newServer(t *testing.T, hub *ws.Hub, fn ws.ConnFunc) string {
+	t.Helper()
+
+	chain := errchain.New(func(h errchain.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := h.ServeHTTP(w, r); err != nil {
+				t.Log(err)
+			}
+		})
+	})
+
+	srv := httptest.NewServer(chain.ToHandler(hub.Handler(fn)))
+	t.Cleanup(srv.Close)
+
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+func dial(t *testing.T, url string) *websocket.Conn {
+	t.Helper()
+
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return conn
+}
+
+// chat joins the lobby and broadcasts every received message to it.
+func chat(hub *ws.Hub) ws.ConnFunc {
+	return func(ctx context.Context, c *ws.Conn) error {
+		c.Join("lobby")
+		for {
+			msg, err := c.Read()
+			if err != nil {
+				return err
+			}
+			hub.BroadcastTo("lobby", msg)
+		}
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func Test_Hub_BroadcastTo(t *testing.T) {
+	hub := ws.NewHub()
+	url := newServer(t, hub, chat(hub))
+
+	alice, bob := dial(t, url), dial(t, url)
+	waitFor(t, func() bool { return hub.Len() == 2 })
+
+	if err := alice.WriteMessage(websocket.TextMessage, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, conn := range []*websocket.Conn{alice, bob} {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(msg) != "hello" {
+			t.Errorf("expected hello, got %q", msg)
+		}
+	}
+}
+
+func Test_Hub_SlowConsumer(t *testing.T) {
+	hub := ws.NewHub(ws.WithSendQueue(1))
+	url := newServer(t, hub, func(ctx context.Context, c *ws.Conn) error {
+		<-ctx.Done()
+		return nil
+	})
+
+	conn := dial(t, url)
+	waitFor(t, func() bool { return hub.Len() == 1 })
+
+	for range 100 {
+		hub.Broadcast([]byte(strings.Repeat("x", 1024)))
+	}
+
+	var closeErr *websocket.CloseError
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+
+		var ok bool
+		if closeErr, ok = err.(*websocket.CloseError); !ok { //nolint:errorlint
+			t.Fatalf("expected close error, got %v", err)
+		}
+		break
+	}
+
+	if closeErr.Code != websocket.CloseTryAgainLater {
+		t.Errorf("expected close code %d, got %d", websocket.CloseTryAgainLater, closeErr.Code)
+	}
+}
+
+func Test_Hub_Start(t *testing.T) {
+	hub := ws.NewHub(ws.WithCloseTimeout(time.Second))
+	url := newServer(t, hub, chat(hub))
+
+	conn := dial(t, url)
+	waitFor(t, func() bool { return hub.Len() == 1 })
+
+	// the client acknowledges the close frame, as browsers do
+	go func() {
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := hub.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if hub.Len() != 0 {
+		t.Errorf("expected every connection to be closed, got %d", hub.Len())
+	}
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("expected dial to fail once the hub is closed")
+	}
+
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", resp.StatusCode)
+	}
+}